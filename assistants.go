@@ -0,0 +1,428 @@
+package oaiaux
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// The Assistants API is in beta: all 'assistants', 'threads', 'messages' and 'runs' calls are sent with the
+// "OpenAI-Beta: assistants=v2" header. For Azure OpenAI, the API requires a preview api-version
+// (e.g. "2024-05-01-preview", see OptAzureApiVersion).
+
+// Statuses of a run (see RunInfo.Status).
+const (
+	RunStatusQueued         = "queued"
+	RunStatusInProgress     = "in_progress"
+	RunStatusRequiresAction = "requires_action"
+	RunStatusCancelling     = "cancelling"
+	RunStatusCancelled      = "cancelled"
+	RunStatusFailed         = "failed"
+	RunStatusCompleted      = "completed"
+	RunStatusIncomplete     = "incomplete"
+	RunStatusExpired        = "expired"
+)
+
+// AssistantTool is a tool enabled on an assistant or a run: Type is one of "code_interpreter", "file_search" or
+// "function" (Function is required for "function" tools only).
+type AssistantTool struct {
+	Type     string              `json:"type"`
+	Function *FunctionDefinition `json:"function,omitempty"`
+}
+
+// AssistantFunctionTool is a convenient function to build a "function" AssistantTool.
+func AssistantFunctionTool(name, description string, parameters interface{}) AssistantTool {
+	return AssistantTool{Type: "function", Function: &FunctionDefinition{Name: name, Description: description, Parameters: parameters}}
+}
+
+// AssistantInput is the input of an 'assistants' creation API call.
+type AssistantInput struct {
+	Model        string            `json:"model"`
+	Name         string            `json:"name,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Instructions string            `json:"instructions,omitempty"`
+	Tools        []AssistantTool   `json:"tools,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Temperature  float64           `json:"temperature,omitempty"`
+	TopP         float64           `json:"top_p,omitempty"`
+}
+
+// AssistantInfo describes an assistant.
+type AssistantInfo struct {
+	Id           string            `json:"id"`
+	Object       string            `json:"object"`
+	CreatedAt    int64             `json:"created_at"`
+	Model        string            `json:"model"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Instructions string            `json:"instructions"`
+	Tools        []AssistantTool   `json:"tools"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+// AssistantOutput captures the output of an 'assistants' creation API call.
+type AssistantOutput struct {
+	BaseResponse `json:"-"`
+	AssistantInfo
+}
+
+// ListAssistantsOutput captures the output of an 'assistants' listing API call.
+type ListAssistantsOutput struct {
+	BaseResponse `json:"-"`
+	Object       string          `json:"object"`
+	Data         []AssistantInfo `json:"data"`
+	FirstId      string          `json:"first_id"`
+	LastId       string          `json:"last_id"`
+	HasMore      bool            `json:"has_more"`
+}
+
+// MessageInput is the input of a 'messages' creation API call. Role is either "user" (default) or "assistant".
+type MessageInput struct {
+	Role     string            `json:"role"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ThreadInput is the input of a 'threads' creation API call, optionally starting the thread with messages.
+type ThreadInput struct {
+	Messages []MessageInput    `json:"messages,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// ThreadInfo describes a thread, i.e. a conversation session between an assistant and a user.
+type ThreadInfo struct {
+	Id        string            `json:"id"`
+	Object    string            `json:"object"`
+	CreatedAt int64             `json:"created_at"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// ThreadOutput captures the output of a 'threads' creation API call.
+type ThreadOutput struct {
+	BaseResponse `json:"-"`
+	ThreadInfo
+}
+
+// MessageContent is a content part of a message: Type is either "text" (see Text) or "image_file".
+type MessageContent struct {
+	Type string `json:"type"`
+	Text *struct {
+		Value       string        `json:"value"`
+		Annotations []interface{} `json:"annotations"`
+	} `json:"text,omitempty"`
+	ImageFile *struct {
+		FileId string `json:"file_id"`
+	} `json:"image_file,omitempty"`
+}
+
+// MessageInfo describes a message of a thread.
+type MessageInfo struct {
+	Id          string            `json:"id"`
+	Object      string            `json:"object"`
+	CreatedAt   int64             `json:"created_at"`
+	ThreadId    string            `json:"thread_id"`
+	Role        string            `json:"role"`
+	Content     []MessageContent  `json:"content"`
+	AssistantId string            `json:"assistant_id"`
+	RunId       string            `json:"run_id"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// Text returns the text parts of the message's content, concatenated.
+func (m *MessageInfo) Text() string {
+	var texts []string
+	for _, content := range m.Content {
+		if content.Type == "text" && content.Text != nil {
+			texts = append(texts, content.Text.Value)
+		}
+	}
+	return strings.Join(texts, "")
+}
+
+// MessageOutput captures the output of a 'messages' creation API call.
+type MessageOutput struct {
+	BaseResponse `json:"-"`
+	MessageInfo
+}
+
+// ListMessagesOutput captures the output of a 'messages' listing API call. Messages are listed most recent first.
+type ListMessagesOutput struct {
+	BaseResponse `json:"-"`
+	Object       string        `json:"object"`
+	Data         []MessageInfo `json:"data"`
+	FirstId      string        `json:"first_id"`
+	LastId       string        `json:"last_id"`
+	HasMore      bool          `json:"has_more"`
+}
+
+// RunInput is the input of a 'runs' creation API call. Model, Instructions and Tools, if specified, override the
+// assistant's settings for the run.
+type RunInput struct {
+	AssistantId            string            `json:"assistant_id"`
+	Model                  string            `json:"model,omitempty"`
+	Instructions           string            `json:"instructions,omitempty"`
+	AdditionalInstructions string            `json:"additional_instructions,omitempty"`
+	Tools                  []AssistantTool   `json:"tools,omitempty"`
+	Metadata               map[string]string `json:"metadata,omitempty"`
+}
+
+// RunInfo describes a run, i.e. an invocation of an assistant on a thread.
+//
+// When Status is "requires_action", RequiredAction holds the tool calls the application must perform. Timestamps are
+// Unix seconds, zero if not reached yet.
+type RunInfo struct {
+	Id             string `json:"id"`
+	Object         string `json:"object"`
+	CreatedAt      int64  `json:"created_at"`
+	ThreadId       string `json:"thread_id"`
+	AssistantId    string `json:"assistant_id"`
+	Status         string `json:"status"`
+	RequiredAction *struct {
+		Type              string `json:"type"`
+		SubmitToolOutputs struct {
+			ToolCalls []ToolCall `json:"tool_calls"`
+		} `json:"submit_tool_outputs"`
+	} `json:"required_action"`
+	LastError *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"last_error"`
+	ExpiresAt    int64                 `json:"expires_at"`
+	StartedAt    int64                 `json:"started_at"`
+	CancelledAt  int64                 `json:"cancelled_at"`
+	FailedAt     int64                 `json:"failed_at"`
+	CompletedAt  int64                 `json:"completed_at"`
+	Model        string                `json:"model"`
+	Instructions string                `json:"instructions"`
+	Tools        []AssistantTool       `json:"tools"`
+	Metadata     map[string]string     `json:"metadata"`
+	Usage        *ChatCompletionsUsage `json:"usage"`
+}
+
+// IsFinished returns true if the run reached a final status (completed, incomplete, failed, cancelled or expired),
+// i.e. polling it can stop. Note that a "requires_action" run is not finished, but polling it is pointless until the
+// tool outputs are submitted.
+func (r *RunInfo) IsFinished() bool {
+	switch r.Status {
+	case RunStatusCompleted, RunStatusIncomplete, RunStatusFailed, RunStatusCancelled, RunStatusExpired:
+		return true
+	}
+	return false
+}
+
+// RunOutput captures the output of a 'runs' creation or retrieval API call.
+type RunOutput struct {
+	BaseResponse `json:"-"`
+	RunInfo
+}
+
+// assistantsPath builds the path of an Assistants API endpoint from its segments, e.g. ("threads", id, "runs").
+func assistantsPath(segments ...string) string {
+	path := ""
+	for _, segment := range segments {
+		path += "/" + url.PathEscape(segment)
+	}
+	return path
+}
+
+// withAssistantsBeta adds the header required by the Assistants API.
+func withAssistantsBeta(header http.Header) http.Header {
+	header.Set("OpenAI-Beta", "assistants=v2")
+	return header
+}
+
+func (bc *BaseClient) buildAssistantOutput(resp *gjrc.GjrcResponse) *AssistantOutput {
+	assistant := &AssistantOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if assistant.Error == nil {
+		assistant.Error = bc.unmarshalResponse(resp, assistant)
+	}
+	return assistant
+}
+
+func (bc *BaseClient) buildListAssistantsOutput(resp *gjrc.GjrcResponse) *ListAssistantsOutput {
+	assistants := &ListAssistantsOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if assistants.Error == nil {
+		assistants.Error = bc.unmarshalResponse(resp, assistants)
+	}
+	return assistants
+}
+
+func (bc *BaseClient) buildThreadOutput(resp *gjrc.GjrcResponse) *ThreadOutput {
+	thread := &ThreadOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if thread.Error == nil {
+		thread.Error = bc.unmarshalResponse(resp, thread)
+	}
+	return thread
+}
+
+func (bc *BaseClient) buildMessageOutput(resp *gjrc.GjrcResponse) *MessageOutput {
+	message := &MessageOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if message.Error == nil {
+		message.Error = bc.unmarshalResponse(resp, message)
+	}
+	return message
+}
+
+func (bc *BaseClient) buildListMessagesOutput(resp *gjrc.GjrcResponse) *ListMessagesOutput {
+	messages := &ListMessagesOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if messages.Error == nil {
+		messages.Error = bc.unmarshalResponse(resp, messages)
+	}
+	return messages
+}
+
+func (bc *BaseClient) buildRunOutput(resp *gjrc.GjrcResponse) *RunOutput {
+	run := &RunOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if run.Error == nil {
+		run.Error = bc.unmarshalResponse(resp, run)
+	}
+	return run
+}
+
+func prepareMessageInput(input *MessageInput) *MessageInput {
+	if input.Role != "" {
+		return input
+	}
+	clone := *input
+	clone.Role = "user"
+	return &clone
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlAssistants builds the url of an Assistants API endpoint (see assistantsPath).
+func (c *AzureOpenAIClient) buildUrlAssistants(resourceName, path string) string {
+	apiUrl := "{azure-base-url}/openai{path}?api-version={azure-api-version}"
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	apiUrl = strings.ReplaceAll(apiUrl, "{path}", path)
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-api-version}", c.apiVersion)
+	return apiUrl
+}
+
+// CreateAssistant implements Client.CreateAssistant
+//
+// Note: input.Model is mapped to its deployment name (see OptAzureDeployments). Like files, assistants and threads
+// belong to a resource, hence all Assistants API calls are sent to the primary resource only (no failover).
+func (c *AzureOpenAIClient) CreateAssistant(input *AssistantInput, opts ...Option) *AssistantOutput {
+	c = c.forCall(opts)
+	if input != nil && input.Model != "" {
+		clone := *input
+		clone.Model = c.deploymentName(input.Model)
+		input = &clone
+	}
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("assistants"))
+	return c.buildAssistantOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), input))
+}
+
+// ListAssistants implements Client.ListAssistants
+func (c *AzureOpenAIClient) ListAssistants(opts ...Option) *ListAssistantsOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("assistants"))
+	return c.buildListAssistantsOutput(c.getJson(apiUrl, withAssistantsBeta(c.buildRequestHeaders())))
+}
+
+// CreateThread implements Client.CreateThread
+func (c *AzureOpenAIClient) CreateThread(input *ThreadInput, opts ...Option) *ThreadOutput {
+	c = c.forCall(opts)
+	if input == nil {
+		input = &ThreadInput{}
+	}
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("threads"))
+	return c.buildThreadOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), input))
+}
+
+// CreateMessage implements Client.CreateMessage
+func (c *AzureOpenAIClient) CreateMessage(threadId string, input *MessageInput, opts ...Option) *MessageOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("threads", threadId, "messages"))
+	return c.buildMessageOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), prepareMessageInput(input)))
+}
+
+// ListMessages implements Client.ListMessages
+func (c *AzureOpenAIClient) ListMessages(threadId string, opts ...Option) *ListMessagesOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("threads", threadId, "messages"))
+	return c.buildListMessagesOutput(c.getJson(apiUrl, withAssistantsBeta(c.buildRequestHeaders())))
+}
+
+// CreateRun implements Client.CreateRun
+//
+// Note: input.Model, if specified, is mapped to its deployment name (see OptAzureDeployments).
+func (c *AzureOpenAIClient) CreateRun(threadId string, input *RunInput, opts ...Option) *RunOutput {
+	c = c.forCall(opts)
+	if input != nil && input.Model != "" {
+		clone := *input
+		clone.Model = c.deploymentName(input.Model)
+		input = &clone
+	}
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("threads", threadId, "runs"))
+	return c.buildRunOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), input))
+}
+
+// RetrieveRun implements Client.RetrieveRun
+func (c *AzureOpenAIClient) RetrieveRun(threadId, runId string, opts ...Option) *RunOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(c.resourceName, assistantsPath("threads", threadId, "runs", runId))
+	return c.buildRunOutput(c.getJson(apiUrl, withAssistantsBeta(c.buildRequestHeaders())))
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlAssistants builds the url of an Assistants API endpoint (see assistantsPath).
+func (c *PlatformOpenAIClient) buildUrlAssistants(path string) string {
+	return c.baseUrl + path
+}
+
+// CreateAssistant implements Client.CreateAssistant
+func (c *PlatformOpenAIClient) CreateAssistant(input *AssistantInput, opts ...Option) *AssistantOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(assistantsPath("assistants"))
+	return c.buildAssistantOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), input))
+}
+
+// ListAssistants implements Client.ListAssistants
+func (c *PlatformOpenAIClient) ListAssistants(opts ...Option) *ListAssistantsOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(assistantsPath("assistants"))
+	return c.buildListAssistantsOutput(c.getJson(apiUrl, withAssistantsBeta(c.buildRequestHeaders())))
+}
+
+// CreateThread implements Client.CreateThread
+func (c *PlatformOpenAIClient) CreateThread(input *ThreadInput, opts ...Option) *ThreadOutput {
+	c = c.forCall(opts)
+	if input == nil {
+		input = &ThreadInput{}
+	}
+	apiUrl := c.buildUrlAssistants(assistantsPath("threads"))
+	return c.buildThreadOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), input))
+}
+
+// CreateMessage implements Client.CreateMessage
+func (c *PlatformOpenAIClient) CreateMessage(threadId string, input *MessageInput, opts ...Option) *MessageOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(assistantsPath("threads", threadId, "messages"))
+	return c.buildMessageOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), prepareMessageInput(input)))
+}
+
+// ListMessages implements Client.ListMessages
+func (c *PlatformOpenAIClient) ListMessages(threadId string, opts ...Option) *ListMessagesOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(assistantsPath("threads", threadId, "messages"))
+	return c.buildListMessagesOutput(c.getJson(apiUrl, withAssistantsBeta(c.buildRequestHeaders())))
+}
+
+// CreateRun implements Client.CreateRun
+func (c *PlatformOpenAIClient) CreateRun(threadId string, input *RunInput, opts ...Option) *RunOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(assistantsPath("threads", threadId, "runs"))
+	return c.buildRunOutput(c.postJsonOnce(apiUrl, withAssistantsBeta(c.buildRequestHeaders()), input))
+}
+
+// RetrieveRun implements Client.RetrieveRun
+func (c *PlatformOpenAIClient) RetrieveRun(threadId, runId string, opts ...Option) *RunOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlAssistants(assistantsPath("threads", threadId, "runs", runId))
+	return c.buildRunOutput(c.getJson(apiUrl, withAssistantsBeta(c.buildRequestHeaders())))
+}
@@ -0,0 +1,134 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_Assistants(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_Assistants"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("OpenAI-Beta") != "assistants=v2" {
+			t.Errorf("%s failed: expected header OpenAI-Beta but received %#v", testName, r.Header)
+		}
+		body := map[string]interface{}{}
+		if r.Method == http.MethodPost {
+			_ = json.NewDecoder(r.Body).Decode(&body)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /assistants":
+			tools, _ := body["tools"].([]interface{})
+			if body["model"] != "gpt-4o" || body["instructions"] != "You are a math tutor." || len(tools) != 2 {
+				t.Errorf("%s failed: unexpected request body %#v", testName, body)
+			}
+			_, _ = w.Write([]byte(`{"id":"asst_abc123","object":"assistant","created_at":1698984975,"name":"Math Tutor","model":"gpt-4o",` +
+				`"instructions":"You are a math tutor.","tools":[{"type":"code_interpreter"},{"type":"function","function":{"name":"get_weather"}}]}`))
+		case "GET /assistants":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"asst_abc123","object":"assistant"}],"first_id":"asst_abc123","last_id":"asst_abc123","has_more":false}`))
+		case "POST /threads":
+			_, _ = w.Write([]byte(`{"id":"thread_abc123","object":"thread","created_at":1699012949}`))
+		case "POST /threads/thread_abc123/messages":
+			if body["role"] != "user" || body["content"] != "Solve 3x + 11 = 14" {
+				t.Errorf("%s failed: unexpected request body %#v", testName, body)
+			}
+			_, _ = w.Write([]byte(`{"id":"msg_abc123","object":"thread.message","thread_id":"thread_abc123","role":"user",` +
+				`"content":[{"type":"text","text":{"value":"Solve 3x + 11 = 14","annotations":[]}}]}`))
+		case "POST /threads/thread_abc123/runs":
+			if body["assistant_id"] != "asst_abc123" {
+				t.Errorf("%s failed: unexpected request body %#v", testName, body)
+			}
+			_, _ = w.Write([]byte(`{"id":"run_abc123","object":"thread.run","thread_id":"thread_abc123","assistant_id":"asst_abc123","status":"queued"}`))
+		case "GET /threads/thread_abc123/runs/run_abc123":
+			_, _ = w.Write([]byte(`{"id":"run_abc123","object":"thread.run","thread_id":"thread_abc123","status":"completed",` +
+				`"usage":{"prompt_tokens":123,"completion_tokens":45,"total_tokens":168}}`))
+		case "GET /threads/thread_abc123/messages":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"msg_def456","object":"thread.message","role":"assistant","run_id":"run_abc123",` +
+				`"content":[{"type":"text","text":{"value":"x = 1","annotations":[]}}]}],"has_more":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"No thread found","type":"invalid_request_error","code":null}}`))
+		}
+	})
+	defer server.Close()
+
+	assistant := client.CreateAssistant(&AssistantInput{Model: "gpt-4o", Name: "Math Tutor", Instructions: "You are a math tutor.",
+		Tools: []AssistantTool{{Type: "code_interpreter"}, AssistantFunctionTool("get_weather", "", nil)}})
+	if assistant.Error != nil || assistant.Id != "asst_abc123" || len(assistant.Tools) != 2 || assistant.Tools[1].Function.Name != "get_weather" {
+		t.Fatalf("%s failed: unexpected assistant output %#v / %#v", testName, assistant.Error, assistant.AssistantInfo)
+	}
+	if assistants := client.ListAssistants(); assistants.Error != nil || len(assistants.Data) != 1 {
+		t.Fatalf("%s failed: unexpected assistants output %#v / %#v", testName, assistants.Error, assistants)
+	}
+	thread := client.CreateThread(nil)
+	if thread.Error != nil || thread.Id != "thread_abc123" {
+		t.Fatalf("%s failed: unexpected thread output %#v / %#v", testName, thread.Error, thread.ThreadInfo)
+	}
+	message := client.CreateMessage(thread.Id, &MessageInput{Content: "Solve 3x + 11 = 14"})
+	if message.Error != nil || message.Text() != "Solve 3x + 11 = 14" {
+		t.Fatalf("%s failed: unexpected message output %#v / %#v", testName, message.Error, message.MessageInfo)
+	}
+	run := client.CreateRun(thread.Id, &RunInput{AssistantId: assistant.Id})
+	if run.Error != nil || run.Status != RunStatusQueued || run.IsFinished() {
+		t.Fatalf("%s failed: unexpected run output %#v / %#v", testName, run.Error, run.RunInfo)
+	}
+	run = client.RetrieveRun(thread.Id, run.Id)
+	if run.Error != nil || !run.IsFinished() || run.Usage == nil || run.Usage.TotalTokens != 168 {
+		t.Fatalf("%s failed: unexpected run output %#v / %#v", testName, run.Error, run.RunInfo)
+	}
+	messages := client.ListMessages(thread.Id)
+	if messages.Error != nil || len(messages.Data) != 1 || messages.Data[0].Text() != "x = 1" {
+		t.Fatalf("%s failed: unexpected messages output %#v / %#v", testName, messages.Error, messages)
+	}
+	if run = client.RetrieveRun("unknown", "run_abc123"); run.StatusCode != 404 || run.Error == nil {
+		t.Fatalf("%s failed: expected error for unknown thread but received %#v / %#v", testName, run.StatusCode, run.Error)
+	}
+}
+
+func TestAzureOpenAIClient_Assistants(t *testing.T) {
+	testName := "TestAzureOpenAIClient_Assistants"
+	var requests []string
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		if r.Header.Get("OpenAI-Beta") != "assistants=v2" || r.Header.Get("api-key") != "azure-key" {
+			t.Errorf("%s failed: unexpected headers %#v", testName, r.Header)
+		}
+		var received map[string]interface{}
+		if json.NewDecoder(r.Body).Decode(&received) == nil {
+			models = append(models, fmt.Sprint(received["model"]))
+		}
+		_, _ = w.Write([]byte(`{"id":"run_abc123","object":"thread.run","status":"in_progress"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"},
+		Option{Key: OptAzureApiVersion, Value: "2024-05-01-preview"}, Option{Key: OptAzureDeployments, Value: map[string]string{"gpt-4o": "gpt-4o-prod"}})
+
+	if run := client.RetrieveRun("thread_abc123", "run_abc123"); run.Error != nil || run.Status != RunStatusInProgress {
+		t.Fatalf("%s failed: unexpected run output %#v / %#v", testName, run.Error, run.RunInfo)
+	}
+	assistantInput := &AssistantInput{Model: "gpt-4o", Name: "Math Tutor"}
+	client.CreateAssistant(assistantInput)
+	client.CreateRun("thread_abc123", &RunInput{AssistantId: "asst_abc123", Model: "gpt-4o"})
+	if !reflect.DeepEqual(models, []string{"gpt-4o-prod", "gpt-4o-prod"}) || assistantInput.Model != "gpt-4o" {
+		t.Fatalf("%s failed: expected models to be mapped to their deployment but received %#v", testName, models)
+	}
+	expected := []string{"GET /openai/threads/thread_abc123/runs/run_abc123?api-version=2024-05-01-preview",
+		"POST /openai/assistants?api-version=2024-05-01-preview", "POST /openai/threads/thread_abc123/runs?api-version=2024-05-01-preview"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("%s failed: expected requests %#v but received %#v", testName, expected, requests)
+	}
+}
+
+func TestAssistants_NotReplayed(t *testing.T) {
+	assertNotReplayed(t, "TestAssistants_NotReplayed", map[string]func(client Client){
+		"assistant": func(client Client) { client.CreateAssistant(&AssistantInput{Model: "gpt-4o", Name: "Math Tutor"}) },
+		"thread":    func(client Client) { client.CreateThread(nil) },
+		"message":   func(client Client) { client.CreateMessage("thread_abc123", &MessageInput{Role: "user", Content: "Hi"}) },
+		"run":       func(client Client) { client.CreateRun("thread_abc123", &RunInput{AssistantId: "asst_abc123"}) },
+	})
+}
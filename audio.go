@@ -0,0 +1,246 @@
+package oaiaux
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// AudioTranslationsInput is the input of an 'audio-translations' API call.
+//
+// The audio is sent as a multipart/form-data upload. FileName should carry the extension of the audio format
+// (e.g. "speech.mp3"), which the API uses to detect the format.
+type AudioTranslationsInput struct {
+	Model    string
+	File     []byte
+	FileName string
+	// Prompt is an optional text, in English, to guide the model's style or continue a previous audio segment.
+	Prompt string
+	// ResponseFormat is one of "json" (default), "text", "srt", "verbose_json" or "vtt".
+	ResponseFormat string
+	Temperature    float64
+}
+
+// AudioTranslationsOutput captures the output of an 'audio-translations' API call.
+//
+// For non-JSON response formats ("text", "srt" and "vtt"), Text is the raw response body.
+type AudioTranslationsOutput struct {
+	BaseResponse   `json:"-"`
+	Text           string `json:"text"`
+	ModelRequested string `json:"-"`
+}
+
+// multipartForm is the payload of a multipart/form-data request.
+type multipartForm struct {
+	Fields   map[string]string
+	FileName string
+	File     []byte
+}
+
+// encode builds the request's body and content type.
+func (form *multipartForm) encode() ([]byte, string, error) {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+	for key, value := range form.Fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, "", err
+		}
+	}
+	part, err := writer.CreateFormFile("file", form.FileName)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = part.Write(form.File); err != nil {
+		return nil, "", err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// postMultipart sends a multipart/form-data POST request (see multipartForm.encode), applying client-wide policies
+// such as rate limiting and retries.
+func (bc *BaseClient) postMultipart(apiUrl string, header http.Header, body []byte, contentType string) *gjrc.GjrcResponse {
+	return bc.sendWithRetries(nil, isRetryableResponse, func() *gjrc.GjrcResponse {
+		return bc.gjrc.Post(apiUrl, contentType, bytes.NewReader(body), gjrc.RequestMeta{Header: header})
+	})
+}
+
+// postMultipartOnce is the form of postMultipart for non-idempotent requests (e.g. uploading a file), see postJsonOnce.
+func (bc *BaseClient) postMultipartOnce(apiUrl string, header http.Header, body []byte, contentType string) *gjrc.GjrcResponse {
+	return bc.sendWithRetries(nil, isRateLimitedResponse, func() *gjrc.GjrcResponse {
+		return bc.gjrc.Post(apiUrl, contentType, bytes.NewReader(body), gjrc.RequestMeta{Header: header})
+	})
+}
+
+func (input *AudioTranslationsInput) toMultipartForm() *multipartForm {
+	fields := map[string]string{
+		"model":           input.Model,
+		"prompt":          input.Prompt,
+		"response_format": input.ResponseFormat,
+	}
+	if input.Temperature != 0 {
+		fields["temperature"] = strconv.FormatFloat(input.Temperature, 'f', -1, 64)
+	}
+	return &multipartForm{Fields: fields, FileName: input.FileName, File: input.File}
+}
+
+// isJsonAudioResponseFormat returns true if the audio API responds with JSON for the response format.
+func isJsonAudioResponseFormat(responseFormat string) bool {
+	return responseFormat == "" || responseFormat == "json" || responseFormat == "verbose_json"
+}
+
+func (bc *BaseClient) buildAudioTranslationsOutput(resp *gjrc.GjrcResponse, input *AudioTranslationsInput) *AudioTranslationsOutput {
+	translations := &AudioTranslationsOutput{BaseResponse: bc.buildBaseResponse(resp), ModelRequested: input.Model}
+	if translations.Error == nil {
+		if isJsonAudioResponseFormat(input.ResponseFormat) || translations.StatusCode >= 300 {
+			translations.Error = bc.unmarshalResponse(resp, translations)
+		} else {
+			body, _ := resp.Body() // not a JSON body, the parsing error is irrelevant
+			translations.Text = string(body)
+		}
+	}
+	return translations
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *AzureOpenAIClient) buildUrlAudioTranslations(resourceName string, input *AudioTranslationsInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/audio/translations?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(input.Model))
+	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
+	return url
+}
+
+// AudioTranslations implements Client.AudioTranslations
+func (c *AzureOpenAIClient) AudioTranslations(input *AudioTranslationsInput, opts ...Option) *AudioTranslationsOutput {
+	c = c.forCall(opts)
+	body, contentType, err := input.toMultipartForm().encode()
+	if err != nil {
+		return &AudioTranslationsOutput{BaseResponse: BaseResponse{Error: err}, ModelRequested: input.Model}
+	}
+	resp := c.sendWithFailover(func(resourceName string) string {
+		return c.buildUrlAudioTranslations(resourceName, input)
+	}, func(apiUrl string, header http.Header) *gjrc.GjrcResponse {
+		return c.postMultipart(apiUrl, header, body, contentType)
+	})
+	return c.buildAudioTranslationsOutput(resp, input)
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *PlatformOpenAIClient) buildUrlAudioTranslations(input *AudioTranslationsInput) string {
+	url := c.baseUrl + "/audio/translations"
+	return url
+}
+
+// AudioTranslations implements Client.AudioTranslations
+func (c *PlatformOpenAIClient) AudioTranslations(input *AudioTranslationsInput, opts ...Option) *AudioTranslationsOutput {
+	c = c.forCall(opts)
+	body, contentType, err := input.toMultipartForm().encode()
+	if err != nil {
+		return &AudioTranslationsOutput{BaseResponse: BaseResponse{Error: err}, ModelRequested: input.Model}
+	}
+	apiUrl := c.buildUrlAudioTranslations(input)
+	header := c.buildRequestHeaders()
+	resp := c.postMultipart(apiUrl, header, body, contentType)
+	return c.buildAudioTranslationsOutput(resp, input)
+}
+
+/*----------------------------------------------------------------------*/
+
+type SpeechInput struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+	// Voice is the voice used to generate the audio, e.g. "alloy", "echo", "fable", "onyx", "nova" or "shimmer".
+	Voice string `json:"voice"`
+	// ResponseFormat is the audio format, one of "mp3" (default), "opus", "aac", "flac", "wav" or "pcm".
+	ResponseFormat string `json:"response_format,omitempty"`
+	// Speed is the speed of the generated audio, from 0.25 to 4.0 (default 1.0).
+	Speed float64 `json:"speed,omitempty"`
+}
+
+// SpeechOutput captures the output of an 'audio-speech' API call.
+//
+// Audio streams the generated audio as it is received (e.g. to pipe it to a speaker or a file), and must be closed
+// by the caller. Alternatively, use Bytes to read the whole audio. Audio is nil if the call fails.
+type SpeechOutput struct {
+	BaseResponse
+	ContentType    string
+	ModelRequested string
+	Audio          io.ReadCloser
+}
+
+// Bytes reads the whole generated audio and closes the stream.
+func (o *SpeechOutput) Bytes() ([]byte, error) {
+	if o.Audio == nil {
+		return nil, errors.New("no audio data")
+	}
+	defer func() { _ = o.Audio.Close() }()
+	return io.ReadAll(o.Audio)
+}
+
+func (bc *BaseClient) speech(apiUrl string, header http.Header, input *SpeechInput) *SpeechOutput {
+	output := &SpeechOutput{ModelRequested: input.Model}
+	header = header.Clone()
+	header.Set("Accept", "*/*")
+	resp, err := bc.openStream(apiUrl, header, input)
+	if err != nil {
+		output.Error = err
+		return output
+	}
+	output.StatusCode, output.Headers, output.RequestId = resp.StatusCode, resp.Header, requestId(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if apiErr := parseAPIErrorBody(body); apiErr != nil {
+			output.Error = apiErr
+		}
+		return output
+	}
+	output.ContentType = resp.Header.Get("Content-Type")
+	output.Audio = resp.Body
+	return output
+}
+
+func (c *AzureOpenAIClient) buildUrlSpeech(resourceName string, input *SpeechInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/audio/speech?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(input.Model))
+	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
+	return url
+}
+
+// Speech implements Client.Speech
+//
+// Note: speech calls are sent to the primary resource only (no failover).
+func (c *AzureOpenAIClient) Speech(input *SpeechInput, opts ...Option) *SpeechOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlSpeech(c.resourceName, input)
+	header := c.buildRequestHeaders()
+	return c.speech(apiUrl, header, input)
+}
+
+func (c *PlatformOpenAIClient) buildUrlSpeech(input *SpeechInput) string {
+	url := c.baseUrl + "/audio/speech"
+	return url
+}
+
+// Speech implements Client.Speech
+func (c *PlatformOpenAIClient) Speech(input *SpeechInput, opts ...Option) *SpeechOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlSpeech(input)
+	header := c.buildRequestHeaders()
+	return c.speech(apiUrl, header, input)
+}
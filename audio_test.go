@@ -0,0 +1,89 @@
+package oaiaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_AudioTranslations(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_AudioTranslations"
+	testData := []struct {
+		name           string
+		responseFormat string
+		responseBody   string
+		expected       string
+	}{
+		{name: "json", responseBody: `{"text":"Hello world"}`, expected: "Hello world"},
+		{name: "text", responseFormat: "text", responseBody: "Hello world\n", expected: "Hello world\n"},
+		{name: "srt", responseFormat: "srt", responseBody: "1\n00:00:00,000 --> 00:00:01,000\nHello world\n", expected: "1\n00:00:00,000 --> 00:00:01,000\nHello world\n"},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/audio/translations" {
+					t.Errorf("%s failed: unexpected path %#v", testName, r.URL.Path)
+				}
+				file, header, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("%s failed: %s", testName, err)
+					return
+				}
+				data, _ := io.ReadAll(file)
+				if header.Filename != "speech.mp3" || string(data) != "audio-data" || r.FormValue("model") != "whisper-1" ||
+					r.FormValue("response_format") != testCase.responseFormat || r.FormValue("temperature") != "0.2" {
+					t.Errorf("%s failed: unexpected form %#v / %#v", testName, header.Filename, r.MultipartForm.Value)
+				}
+				_, _ = w.Write([]byte(testCase.responseBody))
+			})
+			defer server.Close()
+
+			output := client.AudioTranslations(&AudioTranslationsInput{Model: "whisper-1", File: []byte("audio-data"), FileName: "speech.mp3",
+				ResponseFormat: testCase.responseFormat, Temperature: 0.2})
+			if output.Error != nil || output.StatusCode != 200 || output.Text != testCase.expected {
+				t.Fatalf("%s failed: expected %#v but received %#v / %#v / %#v", testName+"/"+testCase.name,
+					testCase.expected, output.Text, output.Error, output.StatusCode)
+			}
+		})
+	}
+}
+
+func TestPlatformOpenAIClient_Speech(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_Speech"
+	audio := []byte{0xff, 0xfb, 0x90, 0x00, 0x01, 0x02}
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/speech" {
+			t.Errorf("%s failed: unexpected path %#v", testName, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		if received["voice"] == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Invalid voice.","type":"invalid_request_error","param":"voice"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		_, _ = w.Write(audio)
+	})
+	defer server.Close()
+
+	output := client.Speech(&SpeechInput{Model: "tts-1", Input: "Hello world", Voice: "alloy", Speed: 1.5})
+	if output.Error != nil || output.StatusCode != 200 || output.ContentType != "audio/mpeg" {
+		t.Fatalf("%s failed: %#v / %#v / %#v", testName, output.Error, output.StatusCode, output.ContentType)
+	}
+	if received["input"] != "Hello world" || received["speed"] != 1.5 {
+		t.Fatalf("%s failed: unexpected request body %#v", testName, received)
+	}
+	if data, err := output.Bytes(); err != nil || !bytes.Equal(data, audio) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %s", testName, audio, data, err)
+	}
+
+	output = client.Speech(&SpeechInput{Model: "tts-1", Input: "Hello world", Voice: "unknown"})
+	var apiErr *APIError
+	if output.StatusCode != 400 || output.Audio != nil || !errors.As(output.Error, &apiErr) || apiErr.Param != "voice" {
+		t.Fatalf("%s failed: expected APIError but received %#v / %#v", testName, output.StatusCode, output.Error)
+	}
+}
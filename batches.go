@@ -0,0 +1,203 @@
+package oaiaux
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+const (
+	// BatchCompletionWindow24h is the completion window of a batch (currently the only one supported by the API).
+	BatchCompletionWindow24h = "24h"
+)
+
+// Statuses of a batch (see BatchInfo.Status).
+const (
+	BatchStatusValidating = "validating"
+	BatchStatusFailed     = "failed"
+	BatchStatusInProgress = "in_progress"
+	BatchStatusFinalizing = "finalizing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusExpired    = "expired"
+	BatchStatusCancelling = "cancelling"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// BatchRequestCounts captures the number of requests of a batch, per status.
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchError is an error found while validating or processing a batch's input file.
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param"`
+	Line    int    `json:"line"`
+}
+
+// BatchInfo describes a batch (see Client.CreateBatch).
+//
+// Once the batch is completed (see IsFinished), the results can be downloaded from OutputFileId, and the failed
+// requests from ErrorFileId (see Client.DownloadFileContent). Timestamps are Unix seconds, zero if not reached yet.
+type BatchInfo struct {
+	Id               string `json:"id"`
+	Object           string `json:"object"`
+	Endpoint         string `json:"endpoint"`
+	InputFileId      string `json:"input_file_id"`
+	CompletionWindow string `json:"completion_window"`
+	Status           string `json:"status"`
+	OutputFileId     string `json:"output_file_id"`
+	ErrorFileId      string `json:"error_file_id"`
+	Errors           *struct {
+		Object string       `json:"object"`
+		Data   []BatchError `json:"data"`
+	} `json:"errors"`
+	CreatedAt     int64              `json:"created_at"`
+	InProgressAt  int64              `json:"in_progress_at"`
+	ExpiresAt     int64              `json:"expires_at"`
+	FinalizingAt  int64              `json:"finalizing_at"`
+	CompletedAt   int64              `json:"completed_at"`
+	FailedAt      int64              `json:"failed_at"`
+	ExpiredAt     int64              `json:"expired_at"`
+	CancellingAt  int64              `json:"cancelling_at"`
+	CancelledAt   int64              `json:"cancelled_at"`
+	RequestCounts BatchRequestCounts `json:"request_counts"`
+	Metadata      map[string]string  `json:"metadata"`
+}
+
+// IsFinished returns true if the batch reached a final status (completed, failed, expired or cancelled), i.e. polling
+// it can stop.
+func (b *BatchInfo) IsFinished() bool {
+	switch b.Status {
+	case BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchOutput captures the output of a 'batches' creation, retrieval or cancellation API call.
+type BatchOutput struct {
+	BaseResponse `json:"-"`
+	BatchInfo
+}
+
+// ListBatchesOutput captures the output of a 'batches' listing API call.
+type ListBatchesOutput struct {
+	BaseResponse `json:"-"`
+	Object       string      `json:"object"`
+	Data         []BatchInfo `json:"data"`
+	FirstId      string      `json:"first_id"`
+	LastId       string      `json:"last_id"`
+	HasMore      bool        `json:"has_more"`
+}
+
+type createBatchInput struct {
+	InputFileId      string `json:"input_file_id"`
+	Endpoint         string `json:"endpoint"`
+	CompletionWindow string `json:"completion_window"`
+}
+
+func newCreateBatchInput(inputFileId, endpoint, completionWindow string) *createBatchInput {
+	if completionWindow == "" {
+		completionWindow = BatchCompletionWindow24h
+	}
+	return &createBatchInput{InputFileId: inputFileId, Endpoint: endpoint, CompletionWindow: completionWindow}
+}
+
+func (bc *BaseClient) buildBatchOutput(resp *gjrc.GjrcResponse) *BatchOutput {
+	batch := &BatchOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if batch.Error == nil {
+		batch.Error = bc.unmarshalResponse(resp, batch)
+	}
+	return batch
+}
+
+func (bc *BaseClient) buildListBatchesOutput(resp *gjrc.GjrcResponse) *ListBatchesOutput {
+	batches := &ListBatchesOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if batches.Error == nil {
+		batches.Error = bc.unmarshalResponse(resp, batches)
+	}
+	return batches
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlBatches builds the url of the 'batches' API: suffix is appended to the batch's path (e.g. "/cancel").
+func (c *AzureOpenAIClient) buildUrlBatches(resourceName, id, suffix string) string {
+	apiUrl := "{azure-base-url}/openai/batches{id}?api-version={azure-api-version}"
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	if id != "" {
+		id = "/" + url.PathEscape(id) + suffix
+	}
+	apiUrl = strings.ReplaceAll(apiUrl, "{id}", id)
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-api-version}", c.apiVersion)
+	return apiUrl
+}
+
+// CreateBatch implements Client.CreateBatch
+//
+// Note: like files, batches belong to a resource, hence all 'batches' calls are sent to the primary resource only
+// (no failover).
+func (c *AzureOpenAIClient) CreateBatch(inputFileId, endpoint, completionWindow string, opts ...Option) *BatchOutput {
+	c = c.forCall(opts)
+	input := newCreateBatchInput(inputFileId, endpoint, completionWindow)
+	return c.buildBatchOutput(c.postJsonOnce(c.buildUrlBatches(c.resourceName, "", ""), c.buildRequestHeaders(), input))
+}
+
+// RetrieveBatch implements Client.RetrieveBatch
+func (c *AzureOpenAIClient) RetrieveBatch(id string, opts ...Option) *BatchOutput {
+	c = c.forCall(opts)
+	return c.buildBatchOutput(c.getJson(c.buildUrlBatches(c.resourceName, id, ""), c.buildRequestHeaders()))
+}
+
+// ListBatches implements Client.ListBatches
+func (c *AzureOpenAIClient) ListBatches(opts ...Option) *ListBatchesOutput {
+	c = c.forCall(opts)
+	return c.buildListBatchesOutput(c.getJson(c.buildUrlBatches(c.resourceName, "", ""), c.buildRequestHeaders()))
+}
+
+// CancelBatch implements Client.CancelBatch
+func (c *AzureOpenAIClient) CancelBatch(id string, opts ...Option) *BatchOutput {
+	c = c.forCall(opts)
+	return c.buildBatchOutput(c.postJsonOnce(c.buildUrlBatches(c.resourceName, id, "/cancel"), c.buildRequestHeaders(), nil))
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlBatches builds the url of the 'batches' API: suffix is appended to the batch's path (e.g. "/cancel").
+func (c *PlatformOpenAIClient) buildUrlBatches(id, suffix string) string {
+	apiUrl := c.baseUrl + "/batches"
+	if id != "" {
+		apiUrl += "/" + url.PathEscape(id) + suffix
+	}
+	return apiUrl
+}
+
+// CreateBatch implements Client.CreateBatch
+func (c *PlatformOpenAIClient) CreateBatch(inputFileId, endpoint, completionWindow string, opts ...Option) *BatchOutput {
+	c = c.forCall(opts)
+	input := newCreateBatchInput(inputFileId, endpoint, completionWindow)
+	return c.buildBatchOutput(c.postJsonOnce(c.buildUrlBatches("", ""), c.buildRequestHeaders(), input))
+}
+
+// RetrieveBatch implements Client.RetrieveBatch
+func (c *PlatformOpenAIClient) RetrieveBatch(id string, opts ...Option) *BatchOutput {
+	c = c.forCall(opts)
+	return c.buildBatchOutput(c.getJson(c.buildUrlBatches(id, ""), c.buildRequestHeaders()))
+}
+
+// ListBatches implements Client.ListBatches
+func (c *PlatformOpenAIClient) ListBatches(opts ...Option) *ListBatchesOutput {
+	c = c.forCall(opts)
+	return c.buildListBatchesOutput(c.getJson(c.buildUrlBatches("", ""), c.buildRequestHeaders()))
+}
+
+// CancelBatch implements Client.CancelBatch
+func (c *PlatformOpenAIClient) CancelBatch(id string, opts ...Option) *BatchOutput {
+	c = c.forCall(opts)
+	return c.buildBatchOutput(c.postJsonOnce(c.buildUrlBatches(id, "/cancel"), c.buildRequestHeaders(), nil))
+}
@@ -0,0 +1,65 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_Batches(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_Batches"
+	batchJson := func(status string) string {
+		return `{"id":"batch_abc123","object":"batch","endpoint":"/v1/chat/completions","input_file_id":"file-abc123",` +
+			`"completion_window":"24h","status":"` + status + `","output_file_id":"file-out456","error_file_id":null,` +
+			`"created_at":1711471533,"request_counts":{"total":100,"completed":95,"failed":5}}`
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /batches":
+			input := map[string]string{}
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input["input_file_id"] != "file-abc123" ||
+				input["endpoint"] != "/v1/chat/completions" || input["completion_window"] != "24h" {
+				t.Errorf("%s failed: unexpected request body %#v / %#v", testName, err, input)
+			}
+			_, _ = w.Write([]byte(batchJson(BatchStatusValidating)))
+		case "GET /batches/batch_abc123":
+			_, _ = w.Write([]byte(batchJson(BatchStatusCompleted)))
+		case "POST /batches/batch_abc123/cancel":
+			_, _ = w.Write([]byte(batchJson(BatchStatusCancelling)))
+		case "GET /batches":
+			_, _ = w.Write([]byte(`{"object":"list","data":[` + batchJson(BatchStatusCompleted) + `],` +
+				`"first_id":"batch_abc123","last_id":"batch_abc123","has_more":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"No batch found","type":"invalid_request_error","code":null}}`))
+		}
+	})
+	defer server.Close()
+
+	batch := client.CreateBatch("file-abc123", "/v1/chat/completions", "")
+	if batch.Error != nil || batch.Id != "batch_abc123" || batch.Status != BatchStatusValidating || batch.IsFinished() {
+		t.Fatalf("%s failed: unexpected create output %#v / %#v", testName, batch.Error, batch.BatchInfo)
+	}
+	batch = client.RetrieveBatch("batch_abc123")
+	if batch.Error != nil || !batch.IsFinished() || batch.OutputFileId != "file-out456" || batch.ErrorFileId != "" ||
+		batch.RequestCounts != (BatchRequestCounts{Total: 100, Completed: 95, Failed: 5}) {
+		t.Fatalf("%s failed: unexpected retrieve output %#v / %#v", testName, batch.Error, batch.BatchInfo)
+	}
+	if batch = client.CancelBatch("batch_abc123"); batch.Error != nil || batch.Status != BatchStatusCancelling {
+		t.Fatalf("%s failed: unexpected cancel output %#v / %#v", testName, batch.Error, batch.BatchInfo)
+	}
+	batches := client.ListBatches()
+	if batches.Error != nil || len(batches.Data) != 1 || batches.HasMore || batches.LastId != "batch_abc123" {
+		t.Fatalf("%s failed: unexpected list output %#v / %#v", testName, batches.Error, batches)
+	}
+	if batch = client.RetrieveBatch("unknown"); batch.StatusCode != 404 || batch.Error == nil {
+		t.Fatalf("%s failed: expected error for unknown batch but received %#v / %#v", testName, batch.StatusCode, batch.Error)
+	}
+}
+
+func TestBatches_NotReplayed(t *testing.T) {
+	assertNotReplayed(t, "TestBatches_NotReplayed", map[string]func(client Client){
+		"create": func(client Client) { client.CreateBatch("file-abc123", "/v1/chat/completions", "24h") },
+		"cancel": func(client Client) { client.CancelBatch("batch_abc123") },
+	})
+}
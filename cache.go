@@ -0,0 +1,158 @@
+package oaiaux
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EmbeddingsStore is a key-value store used to cache embeddings vectors (see OptEmbeddingsCache).
+//
+// Implementations must be safe for concurrent use, and must not share vectors with their callers (i.e. copy them on
+// Get and Set), as callers are free to modify them. Custom implementations can be backed by Redis, SQLite, etc.
+type EmbeddingsStore interface {
+	// Get returns the vector associated with the key, if any.
+	Get(key string) (Vector, bool)
+
+	// Set associates a vector with the key.
+	Set(key string, v Vector)
+}
+
+// MemoryEmbeddingsStore is an in-memory implementation of EmbeddingsStore.
+type MemoryEmbeddingsStore struct {
+	lock sync.RWMutex
+	data map[string]Vector
+}
+
+// NewMemoryEmbeddingsStore creates a new, empty MemoryEmbeddingsStore instance.
+func NewMemoryEmbeddingsStore() *MemoryEmbeddingsStore {
+	return &MemoryEmbeddingsStore{data: make(map[string]Vector)}
+}
+
+// Get implements EmbeddingsStore.Get
+func (s *MemoryEmbeddingsStore) Get(key string) (Vector, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	v, ok := s.data[key]
+	if !ok {
+		return nil, false
+	}
+	return append(Vector(nil), v...), true
+}
+
+// Set implements EmbeddingsStore.Set
+func (s *MemoryEmbeddingsStore) Set(key string, v Vector) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.data[key] = append(Vector(nil), v...)
+}
+
+// FileEmbeddingsStore is an EmbeddingsStore backed by a JSON file.
+//
+// Cached vectors are loaded from the file when the store is created, and kept in memory. Call Flush to persist
+// the cached vectors periodically, and Close when the store is no longer used.
+type FileEmbeddingsStore struct {
+	*MemoryEmbeddingsStore
+	path  string
+	dirty bool
+}
+
+// NewFileEmbeddingsStore creates a new FileEmbeddingsStore instance, loading cached vectors from the file if it exists.
+func NewFileEmbeddingsStore(path string) (*FileEmbeddingsStore, error) {
+	store := &FileEmbeddingsStore{MemoryEmbeddingsStore: NewMemoryEmbeddingsStore(), path: path}
+	js, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(js) > 0 {
+		if err = json.Unmarshal(js, &store.data); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// Set implements EmbeddingsStore.Set
+func (s *FileEmbeddingsStore) Set(key string, v Vector) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.data[key] = append(Vector(nil), v...)
+	s.dirty = true
+}
+
+// Flush persists the cached vectors to the file (if there are changes since last flush).
+func (s *FileEmbeddingsStore) Flush() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	js, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	if _, err = tmpFile.Write(js); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile.Name(), s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Close persists the cached vectors to the file.
+func (s *FileEmbeddingsStore) Close() error {
+	return s.Flush()
+}
+
+/*----------------------------------------------------------------------*/
+
+// embeddingsCacheKey builds the cache key of an embeddings input. The User field does not affect the vector and is ignored.
+func embeddingsCacheKey(input *EmbeddingsInput) string {
+	clone := *input
+	clone.User = ""
+	js, _ := json.Marshal(clone)
+	hash := sha256.Sum256(js)
+	return hex.EncodeToString(hash[:])
+}
+
+func (bc *BaseClient) lookupEmbeddingsCache(input *EmbeddingsInput) *EmbeddingsOutput {
+	if bc.embeddingsCache == nil {
+		return nil
+	}
+	v, ok := bc.embeddingsCache.Get(embeddingsCacheKey(input))
+	if !ok {
+		return nil
+	}
+	return &EmbeddingsOutput{
+		BaseResponse:   BaseResponse{StatusCode: 200},
+		Object:         "list",
+		Model:          input.Model,
+		ModelRequested: input.Model,
+		Data:           []EmbeddingsData{{Index: 0, Object: "embedding", Embedding: v}},
+	}
+}
+
+func (bc *BaseClient) updateEmbeddingsCache(input *EmbeddingsInput, output *EmbeddingsOutput) *EmbeddingsOutput {
+	if bc.embeddingsCache != nil && output.Error == nil && output.StatusCode == 200 && len(output.Data) == 1 {
+		bc.embeddingsCache.Set(embeddingsCacheKey(input), output.Data[0].Embedding)
+	}
+	return output
+}
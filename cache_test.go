@@ -0,0 +1,112 @@
+package oaiaux
+
+import (
+	"math"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFileEmbeddingsStore(t *testing.T) {
+	testName := "TestFileEmbeddingsStore"
+	var numCalls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&numCalls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-ada-002","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	input := &EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"}
+
+	for run := 0; run < 2; run++ {
+		// each run simulates a restart: new store loaded from the same file, new client
+		store, err := NewFileEmbeddingsStore(path)
+		if err != nil {
+			t.Fatalf("%s failed: %s", testName, err)
+		}
+		client, server := newTestPlatformClient(t, handler, Option{Key: OptEmbeddingsCache, Value: store})
+		for i := 0; i < 2; i++ {
+			output := client.Embeddings(input)
+			if output.Error != nil || output.StatusCode != 200 || len(output.Data) != 1 || len(output.Data[0].Embedding) != 2 {
+				t.Fatalf("%s failed: unexpected output %#v", testName, output)
+			}
+		}
+		server.Close()
+		if err = store.Close(); err != nil {
+			t.Fatalf("%s failed: %s", testName, err)
+		}
+	}
+	if numCalls != 1 {
+		t.Fatalf("%s failed: expected 1 API call but received %#v", testName, numCalls)
+	}
+}
+
+func TestEmbeddingsStore_Copies(t *testing.T) {
+	testName := "TestEmbeddingsStore_Copies"
+	fileStore, err := NewFileEmbeddingsStore(filepath.Join(t.TempDir(), "embeddings.json"))
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	for name, store := range map[string]EmbeddingsStore{"memory": NewMemoryEmbeddingsStore(), "file": fileStore} {
+		v := Vector{0.6, 0.8}
+		store.Set("key", v)
+		v[0] = 0
+		cached, _ := store.Get("key")
+		if cached[0] != 0.6 {
+			t.Fatalf("%s failed: the stored vector was modified by the caller", testName+"/"+name)
+		}
+		cached.NormalizeInPlace()
+		cached[1] = 0
+		if cached, _ = store.Get("key"); cached[1] != 0.8 {
+			t.Fatalf("%s failed: the stored vector was modified by the caller", testName+"/"+name)
+		}
+	}
+}
+
+func TestClose_FlushesEmbeddingsCache(t *testing.T) {
+	testName := "TestClose_FlushesEmbeddingsCache"
+	path := filepath.Join(t.TempDir(), "embeddings.json")
+	store, _ := NewFileEmbeddingsStore(path)
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","model":"text-embedding-ada-002","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}, Option{Key: OptEmbeddingsCache, Value: store})
+	defer server.Close()
+
+	client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+	if err := client.Close(); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	if reloaded, err := NewFileEmbeddingsStore(path); err != nil || len(reloaded.data) != 1 {
+		t.Fatalf("%s failed: expected the cache to be flushed but received %#v / %v", testName, reloaded, err)
+	}
+}
+
+func TestEmbeddingsCache_Normalize(t *testing.T) {
+	testName := "TestEmbeddingsCache_Normalize"
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[3.0,4.0]}]}`))
+	}
+	store := NewMemoryEmbeddingsStore()
+	input := &EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"}
+	for _, normalize := range []bool{true, false, true} {
+		client, server := newTestPlatformClient(t, handler, Option{Key: OptEmbeddingsCache, Value: store}, Option{Key: OptNormalizeEmbeddings, Value: normalize})
+		output := client.Embeddings(input)
+		server.Close()
+		if output.Error != nil || len(output.Data) != 1 {
+			t.Fatalf("%s failed: unexpected output %#v", testName, output)
+		}
+		expected := Vector{3.0, 4.0}
+		if normalize {
+			expected = Vector{0.6, 0.8}
+		}
+		if v := output.Data[0].Embedding; math.Abs(v[0]-expected[0]) > 1e-9 || math.Abs(v[1]-expected[1]) > 1e-9 {
+			t.Fatalf("%s failed: expected %#v but received %#v (normalize: %v)", testName, expected, v, normalize)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("%s failed: expected 1 API call but received %#v", testName, calls)
+	}
+}
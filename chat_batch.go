@@ -0,0 +1,92 @@
+package oaiaux
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// OptBatchConcurrency specifies the maximum number of in-flight requests of a batch call (default 4).
+	OptBatchConcurrency = "batch-concurrency"
+	// OptBatchInterval specifies the minimum delay between dispatching two requests of a batch call (default 0).
+	OptBatchInterval = "batch-interval"
+	// OptBatchFailFast, if true, stops dispatching the remaining requests of a batch call once a request fails (default false).
+	OptBatchFailFast = "batch-fail-fast"
+)
+
+var (
+	ErrBatchAborted = errors.New("batch aborted due to previous failure")
+)
+
+// batchPacer spaces out requests dispatched by concurrent workers.
+type batchPacer struct {
+	lock     sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the next request is allowed to be dispatched.
+func (p *batchPacer) wait() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	now := time.Now()
+	if p.next.After(now) {
+		time.Sleep(p.next.Sub(now))
+		now = p.next
+	}
+	p.next = now.Add(p.interval)
+}
+
+func isFailedOutput(resp BaseResponse) bool {
+	return resp.Error != nil || resp.StatusCode < 200 || resp.StatusCode >= 300
+}
+
+// chatCompletionsBatch makes 'chat-completions' API calls for independent prompts concurrently.
+//
+// Available options: OptBatchConcurrency, OptBatchInterval and OptBatchFailFast.
+// Per-call settings (see Client) are applied to each call; failed calls are retried as per OptMaxRetries.
+// Outputs are returned in the same order as prompts, each carrying its own error and status code.
+func chatCompletionsBatch(client Client, prompts []*ChatPromptInput, opts ...Option) []*ChatCompletionsOutput {
+	var optList OptionList = opts
+	concurrency, err := optList.GetInt(OptBatchConcurrency)
+	if err != nil || concurrency < 1 {
+		concurrency = 4
+	}
+	interval, _ := optList.GetDuration(OptBatchInterval)
+	failFast, _ := optList.GetBool(OptBatchFailFast)
+
+	results := make([]*ChatCompletionsOutput, len(prompts))
+	pacer := &batchPacer{interval: interval}
+	var aborted int32
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if failFast && atomic.LoadInt32(&aborted) != 0 {
+					results[i] = &ChatCompletionsOutput{BaseResponse: BaseResponse{Error: ErrBatchAborted}}
+					continue
+				}
+				if prompts[i] == nil {
+					results[i] = &ChatCompletionsOutput{BaseResponse: BaseResponse{Error: errors.New("nil prompt")}}
+				} else {
+					pacer.wait()
+					results[i] = client.ChatCompletions(prompts[i], opts...)
+				}
+				if failFast && isFailedOutput(results[i].BaseResponse) {
+					atomic.StoreInt32(&aborted, 1)
+				}
+			}
+		}()
+	}
+	for i := range prompts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,58 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChatCompletionsBatch(t *testing.T) {
+	testName := "TestChatCompletionsBatch"
+	var inFlight, maxInFlight, numRateLimited int32
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		prompt := &ChatPromptInput{}
+		_ = json.NewDecoder(r.Body).Decode(prompt)
+		if prompt.Messages[0].Content == "prompt-3" && atomic.AddInt32(&numRateLimited, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"rate limited"}}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"echo %s"}}]}`, prompt.Messages[0].Content)
+	}, Option{Key: OptMaxRetries, Value: 3}, Option{Key: OptRetryBaseDelay, Value: 10 * time.Millisecond})
+	defer server.Close()
+
+	prompts := make([]*ChatPromptInput, 10)
+	for i := range prompts {
+		prompts[i] = &ChatPromptInput{Model: "gpt-3.5-turbo", Messages: []ChatMessage{{Role: "user", Content: fmt.Sprintf("prompt-%d", i)}}}
+	}
+	outputs := client.ChatCompletionsBatch(prompts, Option{Key: OptBatchConcurrency, Value: 3})
+	if len(outputs) != len(prompts) {
+		t.Fatalf("%s failed: expected %#v outputs but received %#v", testName, len(prompts), len(outputs))
+	}
+	for i, output := range outputs {
+		if output.Error != nil || output.StatusCode != 200 {
+			t.Fatalf("%s failed: output %#v: %#v / %s", testName, i, output.StatusCode, output.Error)
+		}
+		if expected := fmt.Sprintf("echo prompt-%d", i); output.Choices[0].Message.Content != expected {
+			t.Fatalf("%s failed: expected %#v but received %#v", testName, expected, output.Choices[0].Message.Content)
+		}
+	}
+	if numRateLimited != 2 {
+		t.Fatalf("%s failed: expected the rate-limited request to be retried once but received %#v attempts", testName, numRateLimited)
+	}
+	if maxInFlight > 3 {
+		t.Fatalf("%s failed: expected at most 3 in-flight requests but received %#v", testName, maxInFlight)
+	}
+}
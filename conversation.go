@@ -0,0 +1,223 @@
+package oaiaux
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// OptSummarizeModel specifies the model used to summarize old conversation turns (default "gpt-4o-mini").
+	// For Azure OpenAI, supply the model deployment name.
+	OptSummarizeModel = "summarize-model"
+	// OptSummarizeThreshold specifies the number of tokens above which a conversation is summarized (default 3000).
+	OptSummarizeThreshold = "summarize-threshold"
+	// OptSummarizeTurns specifies the number of oldest messages to be replaced by the summary
+	// (default: all messages but the 4 most recent ones). Leading "system" messages are always kept as-is, and the cut is
+	// moved back if it would separate an "assistant" message requesting tool calls from its "tool" replies.
+	OptSummarizeTurns = "summarize-turns"
+	// OptSummarizePrompt specifies the instruction sent to the summarization model.
+	OptSummarizePrompt = "summarize-prompt"
+)
+
+const (
+	defaultSummarizeModel     = "gpt-4o-mini"
+	defaultSummarizeThreshold = 3000
+	defaultSummarizeKeep      = 4
+	defaultSummarizePrompt    = "Summarize the following conversation concisely. Preserve facts, decisions, names and open questions that may be needed to continue the conversation."
+)
+
+// SummarizeConversation keeps a conversation under a token threshold by replacing its oldest turns with a summary.
+//
+// If the conversation exceeds the threshold (see OptSummarizeThreshold), the oldest turns (see OptSummarizeTurns) are
+// summarized by a chat-completions call (see OptSummarizeModel and OptSummarizePrompt) and replaced by a single "system"
+// message carrying the summary. Otherwise, messages are returned unchanged. The supplied slice is never modified.
+func SummarizeConversation(client Client, messages []ChatMessage, opts ...Option) ([]ChatMessage, error) {
+	var optList OptionList = opts
+	model, _ := optList.GetString(OptSummarizeModel)
+	if model == "" {
+		model = defaultSummarizeModel
+	}
+	threshold, err := optList.GetInt(OptSummarizeThreshold)
+	if err != nil || threshold <= 0 {
+		threshold = defaultSummarizeThreshold
+	}
+	instruction, _ := optList.GetString(OptSummarizePrompt)
+	if instruction == "" {
+		instruction = defaultSummarizePrompt
+	}
+
+	if CountChatTokens(messages, Option{Key: "model", Value: model}) <= threshold {
+		return messages, nil
+	}
+
+	start := 0
+	for start < len(messages) && messages[start].Role == "system" {
+		start++
+	}
+	turns, err := optList.GetInt(OptSummarizeTurns)
+	if err != nil || turns <= 0 {
+		turns = len(messages) - start - defaultSummarizeKeep
+	}
+	if turns > len(messages)-start {
+		turns = len(messages) - start
+	}
+	// move the cut back so that an "assistant" message requesting tool calls is never separated from its "tool" replies
+	for turns > 0 && start+turns < len(messages) && messages[start+turns].Role == "tool" {
+		turns--
+	}
+	if turns > 0 && start+turns < len(messages) && len(messages[start+turns-1].ToolCalls) > 0 {
+		turns--
+	}
+	if turns <= 0 {
+		return messages, nil
+	}
+
+	transcript := strings.Builder{}
+	for _, msg := range messages[start : start+turns] {
+		transcript.WriteString(transcriptLine(msg) + "\n")
+	}
+	output := client.ChatCompletions(&ChatPromptInput{
+		Model: model,
+		Messages: []ChatMessage{
+			{Role: "system", Content: instruction},
+			{Role: "user", Content: transcript.String()},
+		},
+	})
+	if output.Error != nil {
+		return messages, fmt.Errorf("cannot summarize conversation: %w", output.Error)
+	}
+	if output.StatusCode != 200 || len(output.Choices) == 0 {
+		return messages, fmt.Errorf("cannot summarize conversation: status %d", output.StatusCode)
+	}
+
+	result := make([]ChatMessage, 0, len(messages)-turns+1)
+	result = append(result, messages[:start]...)
+	result = append(result, ChatMessage{Role: "system", Content: "Summary of the earlier conversation: " + output.Choices[0].Message.Content})
+	result = append(result, messages[start+turns:]...)
+	return result, nil
+}
+
+// transcriptLine renders a message as a line of the transcript sent to the summarization model: image parts, tool
+// calls and tool replies are marked so that the model knows about them.
+func transcriptLine(msg ChatMessage) string {
+	line := msg.Role
+	if msg.ToolCallId != "" {
+		line += " (" + msg.ToolCallId + ")"
+	}
+	content := msg.Content
+	if len(msg.ContentParts) > 0 {
+		parts := make([]string, 0, len(msg.ContentParts))
+		for _, part := range msg.ContentParts {
+			if part.Type == "text" {
+				parts = append(parts, part.Text)
+			} else {
+				parts = append(parts, "["+part.Type+"]")
+			}
+		}
+		content = strings.Join(parts, " ")
+	}
+	line += ": " + content
+	for _, toolCall := range msg.ToolCalls {
+		line += fmt.Sprintf(" [tool call %s: %s(%s)]", toolCall.Id, toolCall.Function.Name, toolCall.Function.Arguments)
+	}
+	if msg.FunctionCall != nil {
+		line += fmt.Sprintf(" [function call: %s(%s)]", msg.FunctionCall.Name, msg.FunctionCall.Arguments)
+	}
+	return line
+}
+
+// PromptCompressor transforms the messages of a chat prompt before it is sent (see OptPromptCompressor), for example to
+// remove redundant content and save tokens. It runs before any token counting, so budgets reflect the compressed prompt.
+type PromptCompressor func(messages []ChatMessage) []ChatMessage
+
+// CompressMessages is a built-in PromptCompressor: it strips trailing whitespaces, collapses runs of blank lines,
+// removes duplicate paragraphs within each message and drops messages repeating the previous one. Leading whitespaces
+// (e.g. indented code) and the content of fenced code blocks are preserved. The supplied slice is not modified.
+func CompressMessages(messages []ChatMessage) []ChatMessage {
+	result := make([]ChatMessage, 0, len(messages))
+	for _, msg := range messages {
+		msg.Content = compressContent(msg.Content)
+		if n := len(result); n > 0 && isRepeatedMessage(result[n-1], msg) {
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// compressContent compresses the content of a message, see CompressMessages.
+func compressContent(content string) string {
+	var sb strings.Builder
+	var paragraph []string
+	seen := make(map[string]bool)
+	blank := false // a blank line separates the next block from the previous one
+	write := func(block string) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+			if blank {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString(block)
+		blank = false
+	}
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		p := strings.Join(paragraph, "\n")
+		paragraph = paragraph[:0]
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		write(p)
+	}
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		if fence := codeFence(line); fence != "" {
+			// fenced code blocks are kept verbatim, up to the closing fence (or the end of the content)
+			flush()
+			block := []string{line}
+			for i++; i < len(lines); i++ {
+				block = append(block, lines[i])
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+			}
+			write(strings.Join(block, "\n"))
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			blank = sb.Len() > 0
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return sb.String()
+}
+
+// codeFence returns the fence ("```" or "~~~") opening a fenced code block if line starts one, "" otherwise.
+func codeFence(line string) string {
+	trimmed := strings.TrimSpace(line)
+	for _, fence := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, fence) {
+			return fence
+		}
+	}
+	return ""
+}
+
+// isRepeatedMessage returns true if msg repeats prev. Messages involving tool calls or content parts are never
+// considered repeated.
+func isRepeatedMessage(prev, msg ChatMessage) bool {
+	if len(prev.ToolCalls) > 0 || len(msg.ToolCalls) > 0 || prev.FunctionCall != nil || msg.FunctionCall != nil ||
+		len(prev.ContentParts) > 0 || len(msg.ContentParts) > 0 {
+		return false
+	}
+	return prev.Role == msg.Role && prev.Name == msg.Name && prev.ToolCallId == msg.ToolCallId && prev.Content == msg.Content
+}
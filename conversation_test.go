@@ -0,0 +1,178 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeConversation(t *testing.T) {
+	testName := "TestSummarizeConversation"
+	var summarizerInput *ChatPromptInput
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		summarizerInput = &ChatPromptInput{}
+		_ = json.NewDecoder(r.Body).Decode(summarizerInput)
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"User asked about GPT."}}]}`))
+	})
+	defer server.Close()
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a friendly assistant."},
+		{Role: "user", Content: "What is GPT? " + strings.Repeat("Please explain in detail. ", 20)},
+		{Role: "assistant", Content: "GPT is a generative pre-trained transformer. " + strings.Repeat("It is a language model. ", 20)},
+		{Role: "user", Content: "Who created it?"},
+		{Role: "assistant", Content: "OpenAI."},
+	}
+
+	// under threshold: unchanged
+	result, err := SummarizeConversation(client, messages, Option{Key: OptSummarizeThreshold, Value: 10000})
+	if err != nil || len(result) != len(messages) || summarizerInput != nil {
+		t.Fatalf("%s failed: conversation under threshold should not be summarized", testName)
+	}
+
+	// over threshold: the 2 oldest turns are replaced by a summary
+	result, err = SummarizeConversation(client, messages,
+		Option{Key: OptSummarizeThreshold, Value: 100},
+		Option{Key: OptSummarizeTurns, Value: 2},
+		Option{Key: OptSummarizeModel, Value: "gpt-3.5-turbo"})
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	if summarizerInput == nil || summarizerInput.Model != "gpt-3.5-turbo" || !strings.Contains(summarizerInput.Messages[1].Content, "What is GPT?") {
+		t.Fatalf("%s failed: unexpected summarizer input %#v", testName, summarizerInput)
+	}
+	if len(result) != 4 || !reflect.DeepEqual(result[0], messages[0]) || !reflect.DeepEqual(result[2:], messages[3:]) {
+		t.Fatalf("%s failed: unexpected result %#v", testName, result)
+	}
+	if result[1].Role != "system" || !strings.Contains(result[1].Content, "User asked about GPT.") {
+		t.Fatalf("%s failed: unexpected summary message %#v", testName, result[1])
+	}
+}
+
+func TestCompressMessages(t *testing.T) {
+	testName := "TestCompressMessages"
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a helpful assistant. \t\n\n"},
+		{Role: "user", Content: "Context:\n\nParis is the capital of France.\n\n \n\nParis is the capital of France.  \n\nQuestion: what is the capital of France?"},
+		{Role: "user", Content: "Context:\n\nParis is the capital of France.\n\nQuestion: what is the capital of France?"},
+	}
+	result := CompressMessages(messages)
+	if len(result) != 2 {
+		t.Fatalf("%s failed: expected 2 messages but received %#v", testName, result)
+	}
+	if result[0].Content != "You are a helpful assistant." {
+		t.Fatalf("%s failed: unexpected content %#v", testName, result[0].Content)
+	}
+	if expected := "Context:\n\nParis is the capital of France.\n\nQuestion: what is the capital of France?"; result[1].Content != expected {
+		t.Fatalf("%s failed: expected %#v but received %#v", testName, expected, result[1].Content)
+	}
+}
+
+func TestCompressMessages_Code(t *testing.T) {
+	testName := "TestCompressMessages_Code"
+	testData := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "indented", content: "Fix this:  \n\n\n    if x {\n        return y\n    }\n\nThanks",
+			expected: "Fix this:\n\n    if x {\n        return y\n    }\n\nThanks"},
+		{name: "fenced", content: "Fix this:\n```go\nfunc f() {  \n\n\n\treturn\n}\n```\n\n\n```go\nfunc f() {  \n\n\n\treturn\n}\n```",
+			expected: "Fix this:\n```go\nfunc f() {  \n\n\n\treturn\n}\n```\n\n```go\nfunc f() {  \n\n\n\treturn\n}\n```"},
+		{name: "unterminated", content: "~~~\n  a  \n\n\n  b", expected: "~~~\n  a  \n\n\n  b"},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			result := CompressMessages([]ChatMessage{{Role: "user", Content: testCase.content}})
+			if len(result) != 1 || result[0].Content != testCase.expected {
+				t.Fatalf("%s failed: expected %#v but received %#v", testName+"/"+testCase.name, testCase.expected, result)
+			}
+		})
+	}
+}
+
+func TestOptPromptCompressor(t *testing.T) {
+	testName := "TestOptPromptCompressor"
+	var received *ChatPromptInput
+	compressor := func(messages []ChatMessage) []ChatMessage {
+		return messages[len(messages)/2:]
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = &ChatPromptInput{}
+		_ = json.NewDecoder(r.Body).Decode(received)
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`))
+	}, Option{Key: OptPromptCompressor, Value: compressor})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "1"}, {Role: "assistant", Content: "2"}, {Role: "user", Content: "3"}, {Role: "user", Content: "4"}}
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-3.5-turbo", Messages: messages})
+	if output.Error != nil || received == nil {
+		t.Fatalf("%s failed: %s", testName, output.Error)
+	}
+	if len(received.Messages) != 2 || received.Messages[0].Content != "3" {
+		t.Fatalf("%s failed: expected compressed messages but received %#v", testName, received.Messages)
+	}
+}
+
+func TestSummarizeConversation_ToolCallGroup(t *testing.T) {
+	testName := "TestSummarizeConversation_ToolCallGroup"
+	var summarizerInput *ChatPromptInput
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		summarizerInput = &ChatPromptInput{}
+		_ = json.NewDecoder(r.Body).Decode(summarizerInput)
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"User greeted."}}]}`))
+	})
+	defer server.Close()
+
+	messages := []ChatMessage{
+		{Role: "user", Content: "Hello! " + strings.Repeat("Nice to meet you. ", 20), ContentParts: []ChatContentPart{TextPart("Hello!"), ImageUrlPart("https://example.com/cat.png", "")}},
+		{Role: "assistant", Content: "Hi! " + strings.Repeat("How can I help? ", 20)},
+		{Role: "user", Content: "What is the weather in Paris and London?"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{Id: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			{Id: "call_2", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"London"}`}},
+		}},
+		{Role: "tool", ToolCallId: "call_1", Content: "sunny"},
+		{Role: "tool", ToolCallId: "call_2", Content: "rainy"},
+		{Role: "assistant", Content: "Paris is sunny, London is rainy."},
+	}
+
+	testCases := []struct {
+		name  string
+		turns int
+	}{
+		{name: "cut_before_tool_replies", turns: 4},
+		{name: "cut_between_tool_replies", turns: 5},
+	}
+	for _, testCase := range testCases {
+		summarizerInput = nil
+		result, err := SummarizeConversation(client, messages,
+			Option{Key: OptSummarizeThreshold, Value: 100},
+			Option{Key: OptSummarizeTurns, Value: testCase.turns})
+		if err != nil {
+			t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+		}
+		if len(result) != 5 || !reflect.DeepEqual(result[1:], messages[3:]) {
+			t.Fatalf("%s failed: tool-call group should be kept intact, got %#v", testName+"/"+testCase.name, result)
+		}
+		if summarizerInput == nil || !strings.Contains(summarizerInput.Messages[1].Content, "user: Hello! [image_url]\n") {
+			t.Fatalf("%s failed: unexpected summarizer input %#v", testName+"/"+testCase.name, summarizerInput)
+		}
+	}
+
+	// all turns: tool calls and replies are marked in the transcript
+	summarizerInput = nil
+	if _, err := SummarizeConversation(client, messages,
+		Option{Key: OptSummarizeThreshold, Value: 100},
+		Option{Key: OptSummarizeTurns, Value: 6}); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	transcript := summarizerInput.Messages[1].Content
+	for _, expected := range []string{`[tool call call_1: get_weather({"city":"Paris"})]`, "tool (call_2): rainy\n"} {
+		if !strings.Contains(transcript, expected) {
+			t.Fatalf("%s failed: expected %q in transcript %q", testName, expected, transcript)
+		}
+	}
+}
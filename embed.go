@@ -0,0 +1,202 @@
+package oaiaux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EmbedBatchError is the error of a failed batch of an EmbedTexts call: texts[Offset:Offset+Count] are not embedded.
+type EmbedBatchError struct {
+	Offset int
+	Count  int
+	Err    error
+}
+
+// EmbedTextsError aggregates the errors of the failed batches of an EmbedTexts call, ordered by offset.
+//
+// errors.Is and errors.As match any of the batches' errors (e.g. context.Canceled).
+type EmbedTextsError struct {
+	Batches []EmbedBatchError
+}
+
+// Error implements error.Error
+func (e *EmbedTextsError) Error() string {
+	messages := make([]string, 0, len(e.Batches))
+	for _, b := range e.Batches {
+		messages = append(messages, fmt.Sprintf("texts [%d, %d): %s", b.Offset, b.Offset+b.Count, b.Err))
+	}
+	return fmt.Sprintf("cannot embed texts, %d batch(es) failed: %s", len(e.Batches), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the error of the first failed batch.
+func (e *EmbedTextsError) Unwrap() error {
+	if len(e.Batches) == 0 {
+		return nil
+	}
+	return e.Batches[0].Err
+}
+
+// Is reports whether any of the batches' errors matches target (see errors.Is).
+func (e *EmbedTextsError) Is(target error) bool {
+	for _, b := range e.Batches {
+		if errors.Is(b.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the batches' errors matching target (see errors.As).
+func (e *EmbedTextsError) As(target interface{}) bool {
+	for _, b := range e.Batches {
+		if errors.As(b.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	defaultEmbedConcurrency = 4
+	defaultEmbedBatchSize   = 100
+)
+
+// embedText embeds a single text via the embeddings function (the client's Embeddings method), see Client.EmbedText.
+func (bc *BaseClient) embedText(embeddings func(*EmbeddingsInput, ...Option) *EmbeddingsOutput, text string, opts []Option) (Vector, error) {
+	model, err := bc.embeddingsModel(opts)
+	if err != nil {
+		return nil, err
+	}
+	output := embeddings(&EmbeddingsInput{Model: model, Input: text}, opts...)
+	if output.Error != nil {
+		return nil, fmt.Errorf("cannot embed text: %w", output.Error)
+	}
+	if output.StatusCode != 200 {
+		return nil, fmt.Errorf("cannot embed text: status %d", output.StatusCode)
+	}
+	vectors := output.Vectors()
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("cannot embed text: %w", ErrNoVectors)
+	}
+	return vectors[0], nil
+}
+
+// embeddingsModel returns the model specified by the "model" option. An error is returned if neither the option nor
+// OptDefaultEmbeddingsModel is specified.
+func (bc *BaseClient) embeddingsModel(opts []Option) (string, error) {
+	var optList OptionList = opts
+	model, _ := optList.GetString("model")
+	if model == "" && bc.defaultEmbeddingsModel == "" {
+		return "", fmt.Errorf("%w: no embeddings model, supply the \"model\" option or OptDefaultEmbeddingsModel", ErrInvalidParameter)
+	}
+	return model, nil
+}
+
+// embedTexts embeds texts in batches via the embeddings function (the client's Embeddings method), see
+// Client.EmbedTexts.
+func (bc *BaseClient) embedTexts(ctx context.Context, embeddings func(*EmbeddingsInput, ...Option) *EmbeddingsOutput, texts []string,
+	concurrency, batchSize int, opts []Option) ([]Vector, error) {
+	model, err := bc.embeddingsModel(opts)
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if concurrency < 1 {
+		concurrency = defaultEmbedConcurrency
+	}
+	if batchSize < 1 {
+		batchSize = defaultEmbedBatchSize
+	}
+	callOpts := append(append([]Option{}, opts...), Option{Key: OptContext, Value: ctx})
+	results := make([]Vector, len(texts))
+	failures := make([]*EmbedBatchError, (len(texts)+batchSize-1)/batchSize)
+	embedBatch := func(offset int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := texts[offset:]
+		if len(chunk) > batchSize {
+			chunk = chunk[:batchSize]
+		}
+		output := embeddings(&EmbeddingsInput{Model: model, Inputs: chunk}, callOpts...)
+		if output.Error != nil {
+			return output.Error
+		}
+		if output.StatusCode != 200 {
+			return fmt.Errorf("status %d", output.StatusCode)
+		}
+		vectors := make([]Vector, len(chunk))
+		for i := range chunk {
+			v, ok := output.VectorByIndex(i)
+			if !ok {
+				return fmt.Errorf("%w: missing vector at index %d", ErrNoVectors, i)
+			}
+			vectors[i] = v
+		}
+		copy(results[offset:], vectors)
+		return nil
+	}
+
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range jobs {
+				if err := embedBatch(offset); err != nil {
+					count := len(texts) - offset
+					if count > batchSize {
+						count = batchSize
+					}
+					failures[offset/batchSize] = &EmbedBatchError{Offset: offset, Count: count, Err: err}
+				}
+			}
+		}()
+	}
+	for offset := 0; offset < len(texts); offset += batchSize {
+		jobs <- offset
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failed []EmbedBatchError
+	for _, f := range failures {
+		if f != nil {
+			failed = append(failed, *f)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &EmbedTextsError{Batches: failed}
+	}
+	return results, nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// EmbedText implements Client.EmbedText
+func (c *AzureOpenAIClient) EmbedText(text string, opts ...Option) (Vector, error) {
+	return c.embedText(c.Embeddings, text, opts)
+}
+
+// EmbedTexts implements Client.EmbedTexts
+func (c *AzureOpenAIClient) EmbedTexts(ctx context.Context, texts []string, concurrency, batchSize int, opts ...Option) ([]Vector, error) {
+	return c.embedTexts(ctx, c.Embeddings, texts, concurrency, batchSize, opts)
+}
+
+/*----------------------------------------------------------------------*/
+
+// EmbedText implements Client.EmbedText
+func (c *PlatformOpenAIClient) EmbedText(text string, opts ...Option) (Vector, error) {
+	return c.embedText(c.Embeddings, text, opts)
+}
+
+// EmbedTexts implements Client.EmbedTexts
+func (c *PlatformOpenAIClient) EmbedTexts(ctx context.Context, texts []string, concurrency, batchSize int, opts ...Option) ([]Vector, error) {
+	return c.embedTexts(ctx, c.Embeddings, texts, concurrency, batchSize, opts)
+}
@@ -0,0 +1,121 @@
+package oaiaux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEmbedText(t *testing.T) {
+	testName := "TestEmbedText"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		switch received["input"] {
+		case "fail":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Invalid input","type":"invalid_request_error"}}`))
+		case "empty":
+			_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+		default:
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+		}
+	}, Option{Key: OptDefaultEmbeddingsModel, Value: "text-embedding-3-small"})
+	defer server.Close()
+
+	v, err := client.EmbedText("Hello world")
+	if err != nil || len(v) != 2 || v[0] != 0.6 || received["model"] != "text-embedding-3-small" {
+		t.Fatalf("%s failed: %#v / %#v / %#v", testName, err, v, received)
+	}
+	if _, err = client.EmbedText("Hello world", Option{Key: "model", Value: "text-embedding-3-large"}); err != nil || received["model"] != "text-embedding-3-large" {
+		t.Fatalf("%s failed: expected model option to be used but received %#v / %#v", testName, err, received["model"])
+	}
+	var apiErr *APIError
+	if _, err = client.EmbedText("fail"); !errors.As(err, &apiErr) {
+		t.Fatalf("%s failed: expected APIError but received %#v", testName, err)
+	}
+	if _, err = client.EmbedText("empty"); !errors.Is(err, ErrNoVectors) {
+		t.Fatalf("%s failed: expected ErrNoVectors but received %#v", testName, err)
+	}
+
+	noModelClient, _ := NewClient(PlatformOpenAI, Option{Key: OptOpenAIApiKey, Value: "test-key"})
+	if _, err = noModelClient.EmbedText("Hello world"); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("%s failed: expected ErrInvalidParameter but received %#v", testName, err)
+	}
+}
+
+func TestEmbedTexts(t *testing.T) {
+	testName := "TestEmbedTexts"
+	var inFlight, maxInFlight, calls int32
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		atomic.AddInt32(&calls, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		var input struct {
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&input)
+		data := make([]string, 0, len(input.Input))
+		for i := len(input.Input) - 1; i >= 0; i-- {
+			if input.Input[i] == "fail" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"message":"Invalid input","type":"invalid_request_error"}}`))
+				return
+			}
+			// vectors are returned in reverse order, correlated by index
+			value, _ := strconv.Atoi(input.Input[i])
+			data = append(data, fmt.Sprintf(`{"index":%d,"object":"embedding","embedding":[%d]}`, i, value))
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[` + strings.Join(data, ",") + `]}`))
+	}, Option{Key: OptDefaultEmbeddingsModel, Value: "text-embedding-3-small"})
+	defer server.Close()
+
+	texts := make([]string, 25)
+	for i := range texts {
+		texts[i] = strconv.Itoa(i)
+	}
+	vectors, err := client.EmbedTexts(context.Background(), texts, 2, 4)
+	if err != nil || len(vectors) != len(texts) || calls != 7 {
+		t.Fatalf("%s failed: %#v / %d vectors after %d calls", testName, err, len(vectors), calls)
+	}
+	for i, v := range vectors {
+		if len(v) != 1 || v[0] != float64(i) {
+			t.Fatalf("%s failed: unexpected vector at %d: %#v", testName, i, v)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Fatalf("%s failed: expected at most 2 calls in flight but received %d", testName, maxInFlight)
+	}
+
+	texts[5], texts[21] = "fail", "fail"
+	vectors, err = client.EmbedTexts(context.Background(), texts, 3, 4)
+	var embedErr *EmbedTextsError
+	var apiErr *APIError
+	if !errors.As(err, &embedErr) || len(embedErr.Batches) != 2 || embedErr.Batches[0].Offset != 4 || embedErr.Batches[1].Offset != 20 ||
+		embedErr.Batches[1].Count != 4 || !errors.As(err, &apiErr) {
+		t.Fatalf("%s failed: unexpected error %#v", testName, err)
+	}
+	if vectors[3] == nil || vectors[5] != nil || vectors[23] != nil || vectors[24] == nil {
+		t.Fatalf("%s failed: expected vectors of successful batches only but received %#v", testName, vectors)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = client.EmbedTexts(ctx, texts, 2, 4); !errors.Is(err, context.Canceled) {
+		t.Fatalf("%s failed: expected context.Canceled but received %#v", testName, err)
+	}
+}
@@ -0,0 +1,73 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btnguyen2k/consu/gjrc"
+	"github.com/btnguyen2k/consu/reddo"
+)
+
+var (
+	// ErrUnauthorized is returned (wrapped) by Client.Ping when the credentials are rejected (HTTP 401 or 403).
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// APIError is the error returned by OpenAI/Azure OpenAI APIs in the response body, e.g.
+//
+//	{"error": {"message": "...", "type": "invalid_request_error", "param": "messages", "code": "context_length_exceeded"}}
+//
+// When an API call fails with such a body, BaseResponse.Error holds an *APIError, which can be retrieved with errors.As.
+type APIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Param   string `json:"param"`
+	Code    string `json:"code"`
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s (type: %s, code: %s)", e.Message, e.Type, e.Code)
+	}
+	return fmt.Sprintf("%s (type: %s)", e.Message, e.Type)
+}
+
+// parseAPIError extracts the APIError from the response body, returning nil if the body has no "error" object.
+func parseAPIError(resp *gjrc.GjrcResponse) *APIError {
+	body, _ := resp.Body()
+	return parseAPIErrorBody(body)
+}
+
+// parseAPIErrorBody extracts the APIError from a raw response body, returning nil if the body has no "error" object.
+func parseAPIErrorBody(body []byte) *APIError {
+	if len(body) == 0 {
+		return nil
+	}
+	var envelope struct {
+		Error *struct {
+			Message interface{} `json:"message"`
+			Type    interface{} `json:"type"`
+			Param   interface{} `json:"param"`
+			Code    interface{} `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Error == nil {
+		return nil
+	}
+	// some fields may be null or non-string (e.g. Azure returns numeric codes)
+	toString := func(v interface{}) string {
+		if v == nil {
+			return ""
+		}
+		s, _ := reddo.ToString(v)
+		return s
+	}
+	return &APIError{
+		Message: toString(envelope.Error.Message),
+		Type:    toString(envelope.Error.Type),
+		Param:   toString(envelope.Error.Param),
+		Code:    toString(envelope.Error.Code),
+	}
+}
@@ -0,0 +1,55 @@
+package oaiaux
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	testName := "TestAPIError"
+	testData := []struct {
+		name     string
+		status   int
+		body     string
+		expected *APIError
+	}{
+		{name: "context_length_exceeded", status: 400,
+			body:     `{"error":{"message":"This model's maximum context length is 8192 tokens.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`,
+			expected: &APIError{Message: "This model's maximum context length is 8192 tokens.", Type: "invalid_request_error", Param: "messages", Code: "context_length_exceeded"}},
+		{name: "null_fields", status: 401,
+			body:     `{"error":{"message":"Incorrect API key provided.","type":"invalid_request_error","param":null,"code":"invalid_api_key"}}`,
+			expected: &APIError{Message: "Incorrect API key provided.", Type: "invalid_request_error", Code: "invalid_api_key"}},
+		{name: "numeric_code", status: 429,
+			body:     `{"error":{"message":"Rate limit exceeded.","code":429}}`,
+			expected: &APIError{Message: "Rate limit exceeded.", Code: "429"}},
+		{name: "no_error_object", status: 404, body: `{"detail":"not found"}`},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testCase.status)
+				_, _ = w.Write([]byte(testCase.body))
+			})
+			defer server.Close()
+
+			output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}})
+			if output.StatusCode != testCase.status {
+				t.Fatalf("%s failed: expected status %#v but received %#v", testName+"/"+testCase.name, testCase.status, output.StatusCode)
+			}
+			var apiErr *APIError
+			if testCase.expected == nil {
+				if errors.As(output.Error, &apiErr) {
+					t.Fatalf("%s failed: expected no APIError but received %#v", testName+"/"+testCase.name, apiErr)
+				}
+				return
+			}
+			if !errors.As(output.Error, &apiErr) {
+				t.Fatalf("%s failed: expected APIError but received %#v", testName+"/"+testCase.name, output.Error)
+			}
+			if *apiErr != *testCase.expected {
+				t.Fatalf("%s failed: expected %#v but received %#v", testName+"/"+testCase.name, testCase.expected, apiErr)
+			}
+		})
+	}
+}
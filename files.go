@@ -0,0 +1,233 @@
+package oaiaux
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// FileInfo describes an uploaded file (see Client.UploadFile).
+//
+// Status is one of "uploaded", "processed" or "error" (deprecated by OpenAI, but still reported by Azure OpenAI).
+type FileInfo struct {
+	Id            string `json:"id"`
+	Object        string `json:"object"`
+	Bytes         int64  `json:"bytes"`
+	CreatedAt     int64  `json:"created_at"`
+	FileName      string `json:"filename"`
+	Purpose       string `json:"purpose"`
+	Status        string `json:"status,omitempty"`
+	StatusDetails string `json:"status_details,omitempty"`
+}
+
+// FileOutput captures the output of a 'files' upload or retrieval API call.
+type FileOutput struct {
+	BaseResponse `json:"-"`
+	FileInfo
+}
+
+// ListFilesOutput captures the output of a 'files' listing API call.
+type ListFilesOutput struct {
+	BaseResponse `json:"-"`
+	Object       string     `json:"object"`
+	Data         []FileInfo `json:"data"`
+}
+
+// DeleteFileOutput captures the output of a 'files' deletion API call.
+type DeleteFileOutput struct {
+	BaseResponse `json:"-"`
+	Id           string `json:"id"`
+	Object       string `json:"object"`
+	Deleted      bool   `json:"deleted"`
+}
+
+// FileContentOutput captures the output of a 'files' content API call.
+//
+// Content streams the file content as it is received, and must be closed by the caller. Alternatively, use Bytes to
+// read the whole content. Content is nil if the call fails.
+type FileContentOutput struct {
+	BaseResponse
+	ContentType string
+	Content     io.ReadCloser
+}
+
+// Bytes reads the whole file content and closes the stream.
+func (o *FileContentOutput) Bytes() ([]byte, error) {
+	if o.Content == nil {
+		return nil, errors.New("no file content")
+	}
+	defer func() { _ = o.Content.Close() }()
+	return io.ReadAll(o.Content)
+}
+
+// deleteJson sends a DELETE request expecting a JSON response, applying client-wide policies such as rate limiting
+// and retries.
+func (bc *BaseClient) deleteJson(apiUrl string, header http.Header) *gjrc.GjrcResponse {
+	return bc.sendWithRetries(nil, isRetryableResponse, func() *gjrc.GjrcResponse {
+		return bc.gjrc.DeleteJson(apiUrl, nil, gjrc.RequestMeta{Header: header})
+	})
+}
+
+// getStream sends a GET request expecting a streamed response. The caller is responsible for closing the response body.
+func (bc *BaseClient) getStream(apiUrl string, header http.Header) (*http.Response, error) {
+	if err := bc.waitRateLimiter(nil, nil); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	return bc.httpClient.Do(req)
+}
+
+func (bc *BaseClient) uploadFile(apiUrl string, header http.Header, reader io.Reader, fileName, purpose string) *FileOutput {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return &FileOutput{BaseResponse: BaseResponse{Error: err}}
+	}
+	form := &multipartForm{Fields: map[string]string{"purpose": purpose}, FileName: fileName, File: data}
+	body, contentType, err := form.encode()
+	if err != nil {
+		return &FileOutput{BaseResponse: BaseResponse{Error: err}}
+	}
+	return bc.buildFileOutput(bc.postMultipartOnce(apiUrl, header, body, contentType))
+}
+
+func (bc *BaseClient) buildFileOutput(resp *gjrc.GjrcResponse) *FileOutput {
+	file := &FileOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if file.Error == nil {
+		file.Error = bc.unmarshalResponse(resp, file)
+	}
+	return file
+}
+
+func (bc *BaseClient) buildListFilesOutput(resp *gjrc.GjrcResponse) *ListFilesOutput {
+	files := &ListFilesOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if files.Error == nil {
+		files.Error = bc.unmarshalResponse(resp, files)
+	}
+	return files
+}
+
+func (bc *BaseClient) buildDeleteFileOutput(resp *gjrc.GjrcResponse) *DeleteFileOutput {
+	deleted := &DeleteFileOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if deleted.Error == nil {
+		deleted.Error = bc.unmarshalResponse(resp, deleted)
+	}
+	return deleted
+}
+
+func (bc *BaseClient) downloadFileContent(apiUrl string, header http.Header) *FileContentOutput {
+	output := &FileContentOutput{}
+	resp, err := bc.getStream(apiUrl, header)
+	if err != nil {
+		output.Error = err
+		return output
+	}
+	output.StatusCode, output.Headers, output.RequestId = resp.StatusCode, resp.Header, requestId(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if apiErr := parseAPIErrorBody(body); apiErr != nil {
+			output.Error = apiErr
+		}
+		return output
+	}
+	output.ContentType = resp.Header.Get("Content-Type")
+	output.Content = resp.Body
+	return output
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlFiles builds the url of the 'files' API: suffix is appended to the file's path (e.g. "/content").
+func (c *AzureOpenAIClient) buildUrlFiles(resourceName, id, suffix string) string {
+	apiUrl := "{azure-base-url}/openai/files{id}?api-version={azure-api-version}"
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	if id != "" {
+		id = "/" + url.PathEscape(id) + suffix
+	}
+	apiUrl = strings.ReplaceAll(apiUrl, "{id}", id)
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-api-version}", c.apiVersion)
+	return apiUrl
+}
+
+// UploadFile implements Client.UploadFile
+//
+// Note: files belong to a resource, hence all 'files' calls are sent to the primary resource only (no failover).
+func (c *AzureOpenAIClient) UploadFile(reader io.Reader, fileName, purpose string, opts ...Option) *FileOutput {
+	c = c.forCall(opts)
+	return c.uploadFile(c.buildUrlFiles(c.resourceName, "", ""), c.buildRequestHeaders(), reader, fileName, purpose)
+}
+
+// ListFiles implements Client.ListFiles
+func (c *AzureOpenAIClient) ListFiles(opts ...Option) *ListFilesOutput {
+	c = c.forCall(opts)
+	return c.buildListFilesOutput(c.getJson(c.buildUrlFiles(c.resourceName, "", ""), c.buildRequestHeaders()))
+}
+
+// RetrieveFile implements Client.RetrieveFile
+func (c *AzureOpenAIClient) RetrieveFile(id string, opts ...Option) *FileOutput {
+	c = c.forCall(opts)
+	return c.buildFileOutput(c.getJson(c.buildUrlFiles(c.resourceName, id, ""), c.buildRequestHeaders()))
+}
+
+// DeleteFile implements Client.DeleteFile
+func (c *AzureOpenAIClient) DeleteFile(id string, opts ...Option) *DeleteFileOutput {
+	c = c.forCall(opts)
+	return c.buildDeleteFileOutput(c.deleteJson(c.buildUrlFiles(c.resourceName, id, ""), c.buildRequestHeaders()))
+}
+
+// DownloadFileContent implements Client.DownloadFileContent
+func (c *AzureOpenAIClient) DownloadFileContent(id string, opts ...Option) *FileContentOutput {
+	c = c.forCall(opts)
+	return c.downloadFileContent(c.buildUrlFiles(c.resourceName, id, "/content"), c.buildRequestHeaders())
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlFiles builds the url of the 'files' API: suffix is appended to the file's path (e.g. "/content").
+func (c *PlatformOpenAIClient) buildUrlFiles(id, suffix string) string {
+	apiUrl := c.baseUrl + "/files"
+	if id != "" {
+		apiUrl += "/" + url.PathEscape(id) + suffix
+	}
+	return apiUrl
+}
+
+// UploadFile implements Client.UploadFile
+func (c *PlatformOpenAIClient) UploadFile(reader io.Reader, fileName, purpose string, opts ...Option) *FileOutput {
+	c = c.forCall(opts)
+	return c.uploadFile(c.buildUrlFiles("", ""), c.buildRequestHeaders(), reader, fileName, purpose)
+}
+
+// ListFiles implements Client.ListFiles
+func (c *PlatformOpenAIClient) ListFiles(opts ...Option) *ListFilesOutput {
+	c = c.forCall(opts)
+	return c.buildListFilesOutput(c.getJson(c.buildUrlFiles("", ""), c.buildRequestHeaders()))
+}
+
+// RetrieveFile implements Client.RetrieveFile
+func (c *PlatformOpenAIClient) RetrieveFile(id string, opts ...Option) *FileOutput {
+	c = c.forCall(opts)
+	return c.buildFileOutput(c.getJson(c.buildUrlFiles(id, ""), c.buildRequestHeaders()))
+}
+
+// DeleteFile implements Client.DeleteFile
+func (c *PlatformOpenAIClient) DeleteFile(id string, opts ...Option) *DeleteFileOutput {
+	c = c.forCall(opts)
+	return c.buildDeleteFileOutput(c.deleteJson(c.buildUrlFiles(id, ""), c.buildRequestHeaders()))
+}
+
+// DownloadFileContent implements Client.DownloadFileContent
+func (c *PlatformOpenAIClient) DownloadFileContent(id string, opts ...Option) *FileContentOutput {
+	c = c.forCall(opts)
+	return c.downloadFileContent(c.buildUrlFiles(id, "/content"), c.buildRequestHeaders())
+}
@@ -0,0 +1,110 @@
+package oaiaux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_Files(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_Files"
+	fileJson := `{"id":"file-abc123","object":"file","bytes":24,"created_at":1677610602,"filename":"batch.jsonl","purpose":"batch","status":"processed"}`
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /files":
+			if err := r.ParseMultipartForm(1 << 20); err != nil || r.FormValue("purpose") != "batch" {
+				t.Errorf("%s failed: unexpected upload form %#v / %#v", testName, err, r.MultipartForm)
+			}
+			file, header, err := r.FormFile("file")
+			if err != nil || header.Filename != "batch.jsonl" {
+				t.Errorf("%s failed: unexpected uploaded file %#v / %#v", testName, err, header)
+			} else if data, _ := io.ReadAll(file); string(data) != `{"custom_id":"req-1"}`+"\n" {
+				t.Errorf("%s failed: unexpected uploaded content %q", testName, data)
+			}
+			_, _ = w.Write([]byte(fileJson))
+		case "GET /files":
+			_, _ = w.Write([]byte(`{"object":"list","data":[` + fileJson + `]}`))
+		case "GET /files/file-abc123":
+			_, _ = w.Write([]byte(fileJson))
+		case "DELETE /files/file-abc123":
+			_, _ = w.Write([]byte(`{"id":"file-abc123","object":"file","deleted":true}`))
+		case "GET /files/file-abc123/content":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte(`{"custom_id":"req-1"}` + "\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"No such File object","type":"invalid_request_error","code":null}}`))
+		}
+	})
+	defer server.Close()
+
+	file := client.UploadFile(strings.NewReader(`{"custom_id":"req-1"}`+"\n"), "batch.jsonl", "batch")
+	if file.Error != nil || file.Id != "file-abc123" || file.Bytes != 24 || file.CreatedAt != 1677610602 ||
+		file.FileName != "batch.jsonl" || file.Purpose != "batch" || file.Status != "processed" {
+		t.Fatalf("%s failed: unexpected upload output %#v / %#v", testName, file.Error, file.FileInfo)
+	}
+	files := client.ListFiles()
+	if files.Error != nil || len(files.Data) != 1 || files.Data[0].Id != "file-abc123" {
+		t.Fatalf("%s failed: unexpected list output %#v / %#v", testName, files.Error, files.Data)
+	}
+	if file = client.RetrieveFile("file-abc123"); file.Error != nil || file.FileName != "batch.jsonl" {
+		t.Fatalf("%s failed: unexpected retrieve output %#v / %#v", testName, file.Error, file.FileInfo)
+	}
+	if file = client.RetrieveFile("unknown"); file.StatusCode != 404 || file.Error == nil {
+		t.Fatalf("%s failed: expected error for unknown file but received %#v / %#v", testName, file.StatusCode, file.Error)
+	}
+	content := client.DownloadFileContent("file-abc123")
+	if content.Error != nil || content.ContentType != "application/octet-stream" {
+		t.Fatalf("%s failed: unexpected content output %#v / %#v", testName, content.Error, content.ContentType)
+	}
+	if data, err := content.Bytes(); err != nil || string(data) != `{"custom_id":"req-1"}`+"\n" {
+		t.Fatalf("%s failed: unexpected content %q / %#v", testName, data, err)
+	}
+	if content = client.DownloadFileContent("unknown"); content.Error == nil || content.Content != nil {
+		t.Fatalf("%s failed: expected error for unknown file but received %#v", testName, content.Error)
+	}
+	deleted := client.DeleteFile("file-abc123")
+	if deleted.Error != nil || !deleted.Deleted || deleted.Id != "file-abc123" {
+		t.Fatalf("%s failed: unexpected delete output %#v / %#v", testName, deleted.Error, deleted)
+	}
+}
+
+func TestAzureOpenAIClient_Files(t *testing.T) {
+	testName := "TestAzureOpenAIClient_Files"
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		if r.Header.Get("api-key") != "azure-key" {
+			t.Errorf("%s failed: unexpected headers %#v", testName, r.Header)
+		}
+		if r.Method == http.MethodDelete {
+			_, _ = w.Write([]byte(`{"id":"file-abc123","object":"file","deleted":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"file-abc123","object":"file","bytes":24,"created_at":1677610602,"filename":"train.jsonl","purpose":"fine-tune"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"},
+		Option{Key: OptAzureApiVersion, Value: "2024-10-21"})
+
+	if file := client.UploadFile(strings.NewReader("{}\n"), "train.jsonl", "fine-tune"); file.Error != nil || file.Purpose != "fine-tune" {
+		t.Fatalf("%s failed: unexpected upload output %#v / %#v", testName, file.Error, file.FileInfo)
+	}
+	if deleted := client.DeleteFile("file-abc123"); deleted.Error != nil || !deleted.Deleted {
+		t.Fatalf("%s failed: unexpected delete output %#v / %#v", testName, deleted.Error, deleted)
+	}
+	expected := []string{"POST /openai/files?api-version=2024-10-21", "DELETE /openai/files/file-abc123?api-version=2024-10-21"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("%s failed: expected requests %#v but received %#v", testName, expected, requests)
+	}
+}
+
+func TestUploadFile_NotReplayed(t *testing.T) {
+	assertNotReplayed(t, "TestUploadFile_NotReplayed", map[string]func(client Client){
+		"upload": func(client Client) {
+			client.UploadFile(strings.NewReader(`{"prompt":"Hi"}`), "data.jsonl", "fine-tune")
+		},
+	})
+}
@@ -0,0 +1,224 @@
+package oaiaux
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// Statuses of a fine-tuning job (see FineTuningJobInfo.Status).
+const (
+	FineTuningStatusValidatingFiles = "validating_files"
+	FineTuningStatusQueued          = "queued"
+	FineTuningStatusRunning         = "running"
+	FineTuningStatusSucceeded       = "succeeded"
+	FineTuningStatusFailed          = "failed"
+	FineTuningStatusCancelled       = "cancelled"
+)
+
+// FineTuningHyperparameters are the hyperparameters of a fine-tuning job.
+//
+// Each field is either the string "auto" or a number (int for NEpochs and BatchSize, float64 for
+// LearningRateMultiplier); nil lets the API choose.
+type FineTuningHyperparameters struct {
+	NEpochs                interface{} `json:"n_epochs,omitempty"`
+	BatchSize              interface{} `json:"batch_size,omitempty"`
+	LearningRateMultiplier interface{} `json:"learning_rate_multiplier,omitempty"`
+}
+
+// FineTuningJobError describes why a fine-tuning job failed (see FineTuningJobInfo.JobError).
+type FineTuningJobError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param"`
+}
+
+// FineTuningJobInfo describes a fine-tuning job (see Client.CreateFineTuningJob).
+//
+// Once the job succeeded, FineTunedModel is the name of the model to use in API calls (for Azure OpenAI, the model
+// has to be deployed first). Timestamps are Unix seconds, zero if not reached yet.
+type FineTuningJobInfo struct {
+	Id              string                     `json:"id"`
+	Object          string                     `json:"object"`
+	Model           string                     `json:"model"`
+	FineTunedModel  string                     `json:"fine_tuned_model"`
+	OrganizationId  string                     `json:"organization_id"`
+	Status          string                     `json:"status"`
+	TrainingFile    string                     `json:"training_file"`
+	ValidationFile  string                     `json:"validation_file"`
+	ResultFiles     []string                   `json:"result_files"`
+	TrainedTokens   int                        `json:"trained_tokens"`
+	Hyperparameters *FineTuningHyperparameters `json:"hyperparameters"`
+	JobError        *FineTuningJobError        `json:"error"`
+	CreatedAt       int64                      `json:"created_at"`
+	FinishedAt      int64                      `json:"finished_at"`
+	EstimatedFinish int64                      `json:"estimated_finish"`
+}
+
+// IsFinished returns true if the job reached a final status (succeeded, failed or cancelled), i.e. polling it can stop.
+func (j *FineTuningJobInfo) IsFinished() bool {
+	switch j.Status {
+	case FineTuningStatusSucceeded, FineTuningStatusFailed, FineTuningStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// FineTuningJobOutput captures the output of a 'fine-tuning jobs' creation, retrieval or cancellation API call.
+type FineTuningJobOutput struct {
+	BaseResponse `json:"-"`
+	FineTuningJobInfo
+}
+
+// ListFineTuningJobsOutput captures the output of a 'fine-tuning jobs' listing API call.
+type ListFineTuningJobsOutput struct {
+	BaseResponse `json:"-"`
+	Object       string              `json:"object"`
+	Data         []FineTuningJobInfo `json:"data"`
+	HasMore      bool                `json:"has_more"`
+}
+
+// FineTuningEvent is a status update of a fine-tuning job.
+type FineTuningEvent struct {
+	Id        string `json:"id"`
+	Object    string `json:"object"`
+	CreatedAt int64  `json:"created_at"`
+	// Level is one of "info", "warn" or "error".
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// ListFineTuningEventsOutput captures the output of a 'fine-tuning events' listing API call.
+type ListFineTuningEventsOutput struct {
+	BaseResponse `json:"-"`
+	Object       string            `json:"object"`
+	Data         []FineTuningEvent `json:"data"`
+	HasMore      bool              `json:"has_more"`
+}
+
+type createFineTuningJobInput struct {
+	TrainingFile    string                     `json:"training_file"`
+	Model           string                     `json:"model"`
+	Hyperparameters *FineTuningHyperparameters `json:"hyperparameters,omitempty"`
+}
+
+func (bc *BaseClient) buildFineTuningJobOutput(resp *gjrc.GjrcResponse) *FineTuningJobOutput {
+	job := &FineTuningJobOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if job.Error == nil {
+		job.Error = bc.unmarshalResponse(resp, job)
+	}
+	return job
+}
+
+func (bc *BaseClient) buildListFineTuningJobsOutput(resp *gjrc.GjrcResponse) *ListFineTuningJobsOutput {
+	jobs := &ListFineTuningJobsOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if jobs.Error == nil {
+		jobs.Error = bc.unmarshalResponse(resp, jobs)
+	}
+	return jobs
+}
+
+func (bc *BaseClient) buildListFineTuningEventsOutput(resp *gjrc.GjrcResponse) *ListFineTuningEventsOutput {
+	events := &ListFineTuningEventsOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if events.Error == nil {
+		events.Error = bc.unmarshalResponse(resp, events)
+	}
+	return events
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlFineTuningJobs builds the url of the 'fine-tuning jobs' API: suffix is appended to the job's path
+// (e.g. "/cancel").
+//
+// Note: the "/openai/fine_tuning/jobs" path requires api-version 2023-12-01-preview or later (older api-versions
+// served the legacy "/openai/fine-tunes" API, which is not supported).
+func (c *AzureOpenAIClient) buildUrlFineTuningJobs(resourceName, id, suffix string) string {
+	apiUrl := "{azure-base-url}/openai/fine_tuning/jobs{id}?api-version={azure-api-version}"
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	if id != "" {
+		id = "/" + url.PathEscape(id) + suffix
+	}
+	apiUrl = strings.ReplaceAll(apiUrl, "{id}", id)
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-api-version}", c.apiVersion)
+	return apiUrl
+}
+
+// CreateFineTuningJob implements Client.CreateFineTuningJob
+//
+// Note: model is the name of the base model (e.g. "gpt-35-turbo-0125"), not a deployment name. Like files, jobs
+// belong to a resource, hence all 'fine-tuning' calls are sent to the primary resource only (no failover).
+func (c *AzureOpenAIClient) CreateFineTuningJob(trainingFileId, model string, hyperparameters *FineTuningHyperparameters, opts ...Option) *FineTuningJobOutput {
+	c = c.forCall(opts)
+	input := &createFineTuningJobInput{TrainingFile: trainingFileId, Model: model, Hyperparameters: hyperparameters}
+	return c.buildFineTuningJobOutput(c.postJsonOnce(c.buildUrlFineTuningJobs(c.resourceName, "", ""), c.buildRequestHeaders(), input))
+}
+
+// ListFineTuningJobs implements Client.ListFineTuningJobs
+func (c *AzureOpenAIClient) ListFineTuningJobs(opts ...Option) *ListFineTuningJobsOutput {
+	c = c.forCall(opts)
+	return c.buildListFineTuningJobsOutput(c.getJson(c.buildUrlFineTuningJobs(c.resourceName, "", ""), c.buildRequestHeaders()))
+}
+
+// RetrieveFineTuningJob implements Client.RetrieveFineTuningJob
+func (c *AzureOpenAIClient) RetrieveFineTuningJob(id string, opts ...Option) *FineTuningJobOutput {
+	c = c.forCall(opts)
+	return c.buildFineTuningJobOutput(c.getJson(c.buildUrlFineTuningJobs(c.resourceName, id, ""), c.buildRequestHeaders()))
+}
+
+// CancelFineTuningJob implements Client.CancelFineTuningJob
+func (c *AzureOpenAIClient) CancelFineTuningJob(id string, opts ...Option) *FineTuningJobOutput {
+	c = c.forCall(opts)
+	return c.buildFineTuningJobOutput(c.postJsonOnce(c.buildUrlFineTuningJobs(c.resourceName, id, "/cancel"), c.buildRequestHeaders(), nil))
+}
+
+// ListFineTuningEvents implements Client.ListFineTuningEvents
+func (c *AzureOpenAIClient) ListFineTuningEvents(id string, opts ...Option) *ListFineTuningEventsOutput {
+	c = c.forCall(opts)
+	return c.buildListFineTuningEventsOutput(c.getJson(c.buildUrlFineTuningJobs(c.resourceName, id, "/events"), c.buildRequestHeaders()))
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlFineTuningJobs builds the url of the 'fine-tuning jobs' API: suffix is appended to the job's path
+// (e.g. "/cancel").
+func (c *PlatformOpenAIClient) buildUrlFineTuningJobs(id, suffix string) string {
+	apiUrl := c.baseUrl + "/fine_tuning/jobs"
+	if id != "" {
+		apiUrl += "/" + url.PathEscape(id) + suffix
+	}
+	return apiUrl
+}
+
+// CreateFineTuningJob implements Client.CreateFineTuningJob
+func (c *PlatformOpenAIClient) CreateFineTuningJob(trainingFileId, model string, hyperparameters *FineTuningHyperparameters, opts ...Option) *FineTuningJobOutput {
+	c = c.forCall(opts)
+	input := &createFineTuningJobInput{TrainingFile: trainingFileId, Model: model, Hyperparameters: hyperparameters}
+	return c.buildFineTuningJobOutput(c.postJsonOnce(c.buildUrlFineTuningJobs("", ""), c.buildRequestHeaders(), input))
+}
+
+// ListFineTuningJobs implements Client.ListFineTuningJobs
+func (c *PlatformOpenAIClient) ListFineTuningJobs(opts ...Option) *ListFineTuningJobsOutput {
+	c = c.forCall(opts)
+	return c.buildListFineTuningJobsOutput(c.getJson(c.buildUrlFineTuningJobs("", ""), c.buildRequestHeaders()))
+}
+
+// RetrieveFineTuningJob implements Client.RetrieveFineTuningJob
+func (c *PlatformOpenAIClient) RetrieveFineTuningJob(id string, opts ...Option) *FineTuningJobOutput {
+	c = c.forCall(opts)
+	return c.buildFineTuningJobOutput(c.getJson(c.buildUrlFineTuningJobs(id, ""), c.buildRequestHeaders()))
+}
+
+// CancelFineTuningJob implements Client.CancelFineTuningJob
+func (c *PlatformOpenAIClient) CancelFineTuningJob(id string, opts ...Option) *FineTuningJobOutput {
+	c = c.forCall(opts)
+	return c.buildFineTuningJobOutput(c.postJsonOnce(c.buildUrlFineTuningJobs(id, "/cancel"), c.buildRequestHeaders(), nil))
+}
+
+// ListFineTuningEvents implements Client.ListFineTuningEvents
+func (c *PlatformOpenAIClient) ListFineTuningEvents(id string, opts ...Option) *ListFineTuningEventsOutput {
+	c = c.forCall(opts)
+	return c.buildListFineTuningEventsOutput(c.getJson(c.buildUrlFineTuningJobs(id, "/events"), c.buildRequestHeaders()))
+}
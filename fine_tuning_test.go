@@ -0,0 +1,93 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_FineTuning(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_FineTuning"
+	jobJson := func(status string) string {
+		return `{"id":"ftjob-abc123","object":"fine_tuning.job","model":"gpt-4o-mini-2024-07-18","created_at":1721764800,` +
+			`"fine_tuned_model":"ft:gpt-4o-mini:my-org::abc123","status":"` + status + `","training_file":"file-abc123",` +
+			`"result_files":["file-res789"],"trained_tokens":5768,"hyperparameters":{"n_epochs":3,"batch_size":"auto"}}`
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /fine_tuning/jobs":
+			input := map[string]interface{}{}
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input["training_file"] != "file-abc123" ||
+				input["model"] != "gpt-4o-mini-2024-07-18" || input["hyperparameters"].(map[string]interface{})["n_epochs"] != 3.0 {
+				t.Errorf("%s failed: unexpected request body %#v / %#v", testName, err, input)
+			}
+			_, _ = w.Write([]byte(jobJson(FineTuningStatusQueued)))
+		case "GET /fine_tuning/jobs":
+			_, _ = w.Write([]byte(`{"object":"list","data":[` + jobJson(FineTuningStatusSucceeded) + `],"has_more":false}`))
+		case "GET /fine_tuning/jobs/ftjob-abc123":
+			_, _ = w.Write([]byte(jobJson(FineTuningStatusSucceeded)))
+		case "POST /fine_tuning/jobs/ftjob-abc123/cancel":
+			_, _ = w.Write([]byte(jobJson(FineTuningStatusCancelled)))
+		case "GET /fine_tuning/jobs/ftjob-abc123/events":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"fine_tuning.job.event","id":"ft-event-1",` +
+				`"created_at":1721764800,"level":"info","message":"Fine-tuning job started","type":"message"}],"has_more":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"Job not found","type":"invalid_request_error","code":null}}`))
+		}
+	})
+	defer server.Close()
+
+	job := client.CreateFineTuningJob("file-abc123", "gpt-4o-mini-2024-07-18", &FineTuningHyperparameters{NEpochs: 3})
+	if job.Error != nil || job.Id != "ftjob-abc123" || job.Status != FineTuningStatusQueued || job.IsFinished() {
+		t.Fatalf("%s failed: unexpected create output %#v / %#v", testName, job.Error, job.FineTuningJobInfo)
+	}
+	job = client.RetrieveFineTuningJob("ftjob-abc123")
+	if job.Error != nil || !job.IsFinished() || job.FineTunedModel != "ft:gpt-4o-mini:my-org::abc123" ||
+		job.TrainedTokens != 5768 || job.Hyperparameters.BatchSize != "auto" {
+		t.Fatalf("%s failed: unexpected retrieve output %#v / %#v", testName, job.Error, job.FineTuningJobInfo)
+	}
+	if job = client.CancelFineTuningJob("ftjob-abc123"); job.Error != nil || job.Status != FineTuningStatusCancelled {
+		t.Fatalf("%s failed: unexpected cancel output %#v / %#v", testName, job.Error, job.FineTuningJobInfo)
+	}
+	if jobs := client.ListFineTuningJobs(); jobs.Error != nil || len(jobs.Data) != 1 || jobs.HasMore {
+		t.Fatalf("%s failed: unexpected list output %#v / %#v", testName, jobs.Error, jobs)
+	}
+	events := client.ListFineTuningEvents("ftjob-abc123")
+	if events.Error != nil || len(events.Data) != 1 || events.Data[0].Level != "info" || !events.HasMore {
+		t.Fatalf("%s failed: unexpected events output %#v / %#v", testName, events.Error, events)
+	}
+	if job = client.RetrieveFineTuningJob("unknown"); job.StatusCode != 404 || job.Error == nil {
+		t.Fatalf("%s failed: expected error for unknown job but received %#v / %#v", testName, job.StatusCode, job.Error)
+	}
+}
+
+func TestAzureOpenAIClient_FineTuning(t *testing.T) {
+	testName := "TestAzureOpenAIClient_FineTuning"
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"id":"ftjob-abc123","object":"fine_tuning.job","model":"gpt-35-turbo-0125","status":"pending"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"},
+		Option{Key: OptAzureApiVersion, Value: "2024-10-21"})
+
+	if job := client.CreateFineTuningJob("file-abc123", "gpt-35-turbo-0125", nil); job.Error != nil || job.Id != "ftjob-abc123" {
+		t.Fatalf("%s failed: unexpected create output %#v / %#v", testName, job.Error, job.FineTuningJobInfo)
+	}
+	_ = client.ListFineTuningEvents("ftjob-abc123")
+	expected := []string{"POST /openai/fine_tuning/jobs?api-version=2024-10-21", "GET /openai/fine_tuning/jobs/ftjob-abc123/events?api-version=2024-10-21"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("%s failed: expected requests %#v but received %#v", testName, expected, requests)
+	}
+}
+
+func TestFineTuning_NotReplayed(t *testing.T) {
+	assertNotReplayed(t, "TestFineTuning_NotReplayed", map[string]func(client Client){
+		"create": func(client Client) { client.CreateFineTuningJob("file-abc123", "gpt-4o-mini-2024-07-18", nil) },
+		"cancel": func(client Client) { client.CancelFineTuningJob("ftjob-abc123") },
+	})
+}
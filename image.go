@@ -0,0 +1,166 @@
+package oaiaux
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MimeTypeJpeg is the mime type of JPEG images.
+	MimeTypeJpeg = "image/jpeg"
+	// MimeTypePng is the mime type of PNG images.
+	MimeTypePng = "image/png"
+	// MimeTypeGif is the mime type of GIF images.
+	MimeTypeGif = "image/gif"
+)
+
+var (
+	ErrUnsupportedImageFormat = errors.New("unsupported image format")
+)
+
+// ChatContentPart is a part of a multimodal chat message's content (see ChatMessage.ContentParts).
+type ChatContentPart struct {
+	// Type is either "text" or "image_url".
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageUrl *ChatImageUrl `json:"image_url,omitempty"`
+}
+
+// ChatImageUrl is the image of an "image_url" content part.
+type ChatImageUrl struct {
+	// Url is either the URL of a remote image or a base64 data URI (e.g. "data:image/png;base64,...").
+	Url string `json:"url"`
+	// Detail optionally specifies the level of detail of the image: "low", "high" or "auto".
+	Detail string `json:"detail,omitempty"`
+}
+
+// TextPart builds a "text" content part.
+func TextPart(text string) ChatContentPart {
+	return ChatContentPart{Type: "text", Text: text}
+}
+
+// ImageUrlPart builds an "image_url" content part from the URL of a remote image. detail is optional.
+func ImageUrlPart(url, detail string) ChatContentPart {
+	return ChatContentPart{Type: "image_url", ImageUrl: &ChatImageUrl{Url: url, Detail: detail}}
+}
+
+// ImageDataPart builds an "image_url" content part embedding the image data as a base64 data URI. detail is optional.
+//
+// If mimeType is empty, it is detected from data. See PrepareImage to shrink large images beforehand.
+func ImageDataPart(data []byte, mimeType, detail string) ChatContentPart {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return ImageUrlPart("data:"+mimeType+";base64,"+base64.StdEncoding.EncodeToString(data), detail)
+}
+
+// PrepareImage shrinks an image so that it fits within maxDimension x maxDimension pixels, preserving aspect ratio.
+//
+// Supported input formats are JPEG, PNG and GIF. The resized image is re-encoded as PNG (for PNG and GIF inputs, so that
+// transparency is kept) or JPEG (for JPEG inputs). If the image already fits, the original data is returned untouched.
+// The returned mime type describes the returned data and is suitable to build a base64 data URI for vision calls.
+func PrepareImage(data []byte, maxDimension int) ([]byte, string, error) {
+	if maxDimension <= 0 {
+		return nil, "", fmt.Errorf("invalid max dimension %#v", maxDimension)
+	}
+	mimeType := http.DetectContentType(data)
+	switch mimeType {
+	case MimeTypeJpeg, MimeTypePng, MimeTypeGif:
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedImageFormat, mimeType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width <= maxDimension && cfg.Height <= maxDimension {
+		return data, mimeType, nil
+	}
+
+	var img image.Image
+	switch mimeType {
+	case MimeTypeJpeg:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case MimeTypePng:
+		img, err = png.Decode(bytes.NewReader(data))
+	case MimeTypeGif:
+		img, err = gif.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	width, height := cfg.Width, cfg.Height
+	if width >= height {
+		height = atLeast(height*maxDimension/width, 1)
+		width = maxDimension
+	} else {
+		width = atLeast(width*maxDimension/height, 1)
+		height = maxDimension
+	}
+	resized := resizeImage(img, width, height)
+
+	buf := &bytes.Buffer{}
+	if mimeType == MimeTypeJpeg {
+		err = jpeg.Encode(buf, resized, &jpeg.Options{Quality: 85})
+	} else {
+		mimeType = MimeTypePng
+		err = png.Encode(buf, resized)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mimeType, nil
+}
+
+func atLeast(v, minValue int) int {
+	if v < minValue {
+		return minValue
+	}
+	return v
+}
+
+// resizeImage scales down an image to the target size, averaging all source pixels covered by each target pixel.
+func resizeImage(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	rgba := image.NewNRGBA(bounds)
+	draw.Draw(rgba, bounds, src, bounds.Min, draw.Src)
+
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcH/height, (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcW/width, (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					offset := sy*rgba.Stride + sx*4
+					r += uint64(rgba.Pix[offset])
+					g += uint64(rgba.Pix[offset+1])
+					b += uint64(rgba.Pix[offset+2])
+					a += uint64(rgba.Pix[offset+3])
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+	return dst
+}
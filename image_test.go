@@ -0,0 +1,92 @@
+package oaiaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestPrepareImage(t *testing.T) {
+	testName := "TestPrepareImage"
+	src := image.NewRGBA(image.Rect(0, 0, 2000, 1000))
+	for y := 0; y < 1000; y++ {
+		for x := 0; x < 2000; x++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	bufPng, bufJpeg := &bytes.Buffer{}, &bytes.Buffer{}
+	_ = png.Encode(bufPng, src)
+	_ = jpeg.Encode(bufJpeg, src, nil)
+
+	testData := []struct {
+		name           string
+		input          []byte
+		maxDimension   int
+		expectedMime   string
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{name: "png", input: bufPng.Bytes(), maxDimension: 512, expectedMime: MimeTypePng, expectedWidth: 512, expectedHeight: 256},
+		{name: "jpeg", input: bufJpeg.Bytes(), maxDimension: 768, expectedMime: MimeTypeJpeg, expectedWidth: 768, expectedHeight: 384},
+		{name: "small", input: bufPng.Bytes(), maxDimension: 2048, expectedMime: MimeTypePng, expectedWidth: 2000, expectedHeight: 1000},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			data, mimeType, err := PrepareImage(testCase.input, testCase.maxDimension)
+			if err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			if mimeType != testCase.expectedMime {
+				t.Fatalf("%s failed: expected mime %#v but received %#v", testName+"/"+testCase.name, testCase.expectedMime, mimeType)
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			if cfg.Width != testCase.expectedWidth || cfg.Height != testCase.expectedHeight {
+				t.Fatalf("%s failed: expected %dx%d but received %dx%d", testName+"/"+testCase.name, testCase.expectedWidth, testCase.expectedHeight, cfg.Width, cfg.Height)
+			}
+		})
+	}
+
+	if _, _, err := PrepareImage([]byte("not an image"), 512); err == nil {
+		t.Fatalf("%s failed: expected error for non-image input", testName)
+	}
+}
+
+func TestChatMessage_ContentParts(t *testing.T) {
+	testName := "TestChatMessage_ContentParts"
+	msg := ChatMessage{Role: "user", ContentParts: []ChatContentPart{
+		TextPart("What is in this image?"),
+		ImageUrlPart("https://example.com/cat.png", "low"),
+		ImageDataPart([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}, "", ""),
+	}}
+	js, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	expected := `{"role":"user","content":[{"type":"text","text":"What is in this image?"},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/cat.png","detail":"low"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo="}}]}`
+	if string(js) != expected {
+		t.Fatalf("%s failed: expected %s but received %s", testName, expected, js)
+	}
+
+	var decoded ChatMessage
+	if err = json.Unmarshal(js, &decoded); err != nil || !reflect.DeepEqual(decoded, msg) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %s", testName, msg, decoded, err)
+	}
+
+	plain := ChatMessage{Role: "assistant", Content: "A cat."}
+	if js, _ = json.Marshal(plain); string(js) != `{"role":"assistant","content":"A cat."}` {
+		t.Fatalf("%s failed: unexpected serialization of plain message %s", testName, js)
+	}
+	if err = json.Unmarshal([]byte(`{"role":"assistant","content":null}`), &decoded); err != nil || decoded.Content != "" || decoded.ContentParts != nil {
+		t.Fatalf("%s failed: unexpected decoding of null content %#v / %s", testName, decoded, err)
+	}
+}
@@ -0,0 +1,88 @@
+package oaiaux
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+type ImagesGenerateInput struct {
+	Prompt  string `json:"prompt"`
+	Model   string `json:"model,omitempty"`
+	N       int    `json:"n,omitempty"`
+	Size    string `json:"size,omitempty"`
+	Quality string `json:"quality,omitempty"`
+	Style   string `json:"style,omitempty"`
+	// ResponseFormat is either "url" (default) or "b64_json" (see ImageData.Bytes).
+	ResponseFormat string `json:"response_format,omitempty"`
+	User           string `json:"user,omitempty"`
+}
+
+// ImagesGenerateOutput captures the output of an 'images-generations' API call.
+type ImagesGenerateOutput struct {
+	BaseResponse   `json:"-"`
+	Created        int64       `json:"created"`
+	ModelRequested string      `json:"-"`
+	Data           []ImageData `json:"data"`
+}
+
+// ImageData is a generated image, either as an URL or as base64-encoded data (depending on the input's ResponseFormat).
+type ImageData struct {
+	Url           string `json:"url,omitempty"`
+	B64Json       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// Bytes decodes the base64-encoded image data. An error is returned if the image was returned as an URL.
+func (d ImageData) Bytes() ([]byte, error) {
+	if d.B64Json == "" {
+		return nil, errors.New("no image data, request response_format \"b64_json\" to receive image data")
+	}
+	return base64.StdEncoding.DecodeString(d.B64Json)
+}
+
+func (bc *BaseClient) buildImagesGenerateOutput(resp *gjrc.GjrcResponse, modelRequested string) *ImagesGenerateOutput {
+	images := &ImagesGenerateOutput{BaseResponse: bc.buildBaseResponse(resp), ModelRequested: modelRequested}
+	if images.Error == nil {
+		err := bc.unmarshalResponse(resp, images)
+		images.Error = err
+	}
+	return images
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *AzureOpenAIClient) buildUrlImagesGenerate(resourceName string, input *ImagesGenerateInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/images/generations?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(input.Model))
+	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
+	return url
+}
+
+// ImagesGenerate implements Client.ImagesGenerate
+func (c *AzureOpenAIClient) ImagesGenerate(input *ImagesGenerateInput, opts ...Option) *ImagesGenerateOutput {
+	c = c.forCall(opts)
+	resp := c.postJsonWithFailover(func(resourceName string) string {
+		return c.buildUrlImagesGenerate(resourceName, input)
+	}, input)
+	return c.buildImagesGenerateOutput(resp, input.Model)
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *PlatformOpenAIClient) buildUrlImagesGenerate(input *ImagesGenerateInput) string {
+	url := c.baseUrl + "/images/generations"
+	return url
+}
+
+// ImagesGenerate implements Client.ImagesGenerate
+func (c *PlatformOpenAIClient) ImagesGenerate(input *ImagesGenerateInput, opts ...Option) *ImagesGenerateOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlImagesGenerate(input)
+	header := c.buildRequestHeaders()
+	resp := c.postJson(apiUrl, header, input)
+	return c.buildImagesGenerateOutput(resp, input.Model)
+}
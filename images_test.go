@@ -0,0 +1,37 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_ImagesGenerate(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_ImagesGenerate"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/generations" {
+			t.Errorf("%s failed: unexpected path %#v", testName, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"created":1700000000,"data":[{"b64_json":"aGVsbG8=","revised_prompt":"a cute cat"},{"url":"https://example.com/cat.png"}]}`))
+	})
+	defer server.Close()
+
+	output := client.ImagesGenerate(&ImagesGenerateInput{Model: "dall-e-3", Prompt: "a cat", Size: "1024x1024", ResponseFormat: "b64_json"})
+	if output.Error != nil || output.StatusCode != 200 || len(output.Data) != 2 {
+		t.Fatalf("%s failed: %#v / %#v / %#v", testName, output.Error, output.StatusCode, output.Data)
+	}
+	if received["prompt"] != "a cat" || received["response_format"] != "b64_json" || received["size"] != "1024x1024" {
+		t.Fatalf("%s failed: unexpected request body %#v", testName, received)
+	}
+	if _, ok := received["n"]; ok {
+		t.Fatalf("%s failed: n should be omitted when unset", testName)
+	}
+	if data, err := output.Data[0].Bytes(); err != nil || string(data) != "hello" || output.Data[0].RevisedPrompt != "a cute cat" {
+		t.Fatalf("%s failed: unexpected image data %#v / %s", testName, data, err)
+	}
+	if _, err := output.Data[1].Bytes(); err == nil || output.Data[1].Url != "https://example.com/cat.png" {
+		t.Fatalf("%s failed: expected error decoding URL image", testName)
+	}
+}
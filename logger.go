@@ -0,0 +1,166 @@
+package oaiaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestInfo describes a request sent to the API (see Logger).
+type RequestInfo struct {
+	Method string
+	Url    string
+	// Endpoint is the path of the API endpoint, e.g. "/v1/chat/completions" (for Azure OpenAI,
+	// "/openai/deployments/{deployment}/chat/completions").
+	Endpoint string
+	// Model is the model of the request, read from the "model" field of JSON request bodies or, for Azure OpenAI,
+	// the deployment name of the url. It is empty if the request has no model.
+	Model string
+	// Header holds the request headers, with credentials ("Authorization" and "api-key") redacted.
+	Header    http.Header
+	StartTime time.Time
+}
+
+// ResponseInfo describes the outcome of a request sent to the API (see Logger).
+type ResponseInfo struct {
+	// StatusCode is zero if no response was received (see Error).
+	StatusCode int
+	// Duration is the duration from sending the request until the response body is fully read (or closed).
+	Duration time.Duration
+	// Usage is the token usage reported by JSON responses (nil for streamed, binary or usage-less responses).
+	Usage *ChatCompletionsUsage
+	// Error is the network error if the request failed before a response was received.
+	Error error
+}
+
+// Logger is invoked after each HTTP request sent to the API, including retried attempts (see OptLogger), e.g. to
+// record uniform telemetry across all endpoints. It may be invoked concurrently, hence it must be safe for concurrent
+// use, and it should return quickly.
+type Logger func(req RequestInfo, resp ResponseInfo)
+
+// maxLoggedBodySize is the maximum size of a JSON response body parsed for the token usage: larger responses are
+// logged without usage.
+const maxLoggedBodySize = 4 * 1024 * 1024
+
+// loggingTransport is a http.RoundTripper invoking a Logger once each response is fully read.
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger Logger
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	info := RequestInfo{
+		Method:    req.Method,
+		Url:       req.URL.String(),
+		Endpoint:  req.URL.Path,
+		Model:     requestModel(req),
+		Header:    redactHeader(req.Header),
+		StartTime: time.Now(),
+	}
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil {
+		t.logger(info, ResponseInfo{Duration: time.Since(info.StartTime), Error: err})
+		return resp, err
+	}
+	body := &loggingBody{ReadCloser: resp.Body, transport: t, req: info, resp: ResponseInfo{StatusCode: resp.StatusCode}}
+	body.buffered = strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+	resp.Body = body
+	return resp, nil
+}
+
+func (t *loggingTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// loggingBody wraps a response body, invoking the Logger when the body is fully read or closed.
+type loggingBody struct {
+	io.ReadCloser
+	transport *loggingTransport
+	req       RequestInfo
+	resp      ResponseInfo
+	buffered  bool
+	buf       bytes.Buffer
+	once      sync.Once
+}
+
+// Read implements io.Reader.Read
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.buffered && n > 0 {
+		if b.buf.Len()+n <= maxLoggedBodySize {
+			b.buf.Write(p[:n])
+		} else {
+			b.buffered = false
+			b.buf = bytes.Buffer{}
+		}
+	}
+	if err == io.EOF {
+		b.log()
+	}
+	return n, err
+}
+
+// Close implements io.Closer.Close
+func (b *loggingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.log()
+	return err
+}
+
+func (b *loggingBody) log() {
+	b.once.Do(func() {
+		b.resp.Duration = time.Since(b.req.StartTime)
+		if b.buffered && b.buf.Len() > 0 {
+			var body struct {
+				Usage *ChatCompletionsUsage `json:"usage"`
+			}
+			if json.Unmarshal(b.buf.Bytes(), &body) == nil {
+				b.resp.Usage = body.Usage
+			}
+		}
+		b.transport.logger(b.req, b.resp)
+	})
+}
+
+// requestModel extracts the model of a request: the "model" field of a JSON body, or else the deployment name of an
+// Azure OpenAI url.
+func requestModel(req *http.Request) string {
+	if req.GetBody != nil && strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		if body, err := req.GetBody(); err == nil {
+			var input struct {
+				Model string `json:"model"`
+			}
+			err = json.NewDecoder(body).Decode(&input)
+			_ = body.Close()
+			if err == nil && input.Model != "" {
+				return input.Model
+			}
+		}
+	}
+	segments := strings.Split(req.URL.Path, "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "deployments" {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+// redactHeader returns a copy of header with credentials redacted.
+func redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, name := range []string{"Authorization", "Api-Key"} {
+		if header.Get(name) != "" {
+			header.Set(name, "REDACTED")
+		}
+	}
+	return header
+}
@@ -0,0 +1,87 @@
+package oaiaux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestOptLogger(t *testing.T) {
+	testName := "TestOptLogger"
+	var lock sync.Mutex
+	var requests []RequestInfo
+	var responses []ResponseInfo
+	logger := func(req RequestInfo, resp ResponseInfo) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests, responses = append(requests, req), append(responses, resp)
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/models/unknown" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"The model does not exist","type":"invalid_request_error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-2024-08-06",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}`))
+	}, Option{Key: OptLogger, Value: logger})
+	defer server.Close()
+
+	if output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}}); output.Error != nil {
+		t.Fatalf("%s failed: %s", testName, output.Error)
+	}
+	_ = client.RetrieveModel("unknown")
+	lock.Lock()
+	defer lock.Unlock()
+	if len(requests) != 2 {
+		t.Fatalf("%s failed: expected 2 logged requests but received %d", testName, len(requests))
+	}
+	if req, resp := requests[0], responses[0]; req.Method != http.MethodPost || req.Endpoint != "/chat/completions" || req.Model != "gpt-4o" ||
+		resp.StatusCode != 200 || resp.Duration <= 0 || resp.Usage == nil || resp.Usage.TotalTokens != 10 {
+		t.Fatalf("%s failed: unexpected log entry %#v / %#v", testName, req, resp)
+	}
+	if auth := requests[0].Header.Get("Authorization"); auth != "REDACTED" {
+		t.Fatalf("%s failed: expected redacted Authorization header but received %q", testName, auth)
+	}
+	if req, resp := requests[1], responses[1]; req.Method != http.MethodGet || req.Endpoint != "/models/unknown" ||
+		resp.StatusCode != 404 || resp.Usage != nil {
+		t.Fatalf("%s failed: unexpected log entry %#v / %#v", testName, req, resp)
+	}
+
+	if _, err := NewClient(PlatformOpenAI, Option{Key: OptOpenAIApiKey, Value: "test-key"}, Option{Key: OptLogger, Value: "stdout"}); err == nil {
+		t.Fatalf("%s failed: expected error for invalid logger", testName)
+	}
+}
+
+func TestOptLogger_Azure(t *testing.T) {
+	testName := "TestOptLogger_Azure"
+	var logged []RequestInfo
+	var errs []error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"},
+		Option{Key: OptAzureDeployments, Value: map[string]string{"text-embedding-3-small": "my-embeddings"}},
+		WithLogger(func(req RequestInfo, resp ResponseInfo) {
+			logged, errs = append(logged, req), append(errs, resp.Error)
+		}))
+
+	if output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"}); output.Error != nil {
+		t.Fatalf("%s failed: %s", testName, output.Error)
+	}
+	if len(logged) != 1 || logged[0].Model != "text-embedding-3-small" || logged[0].Endpoint != "/openai/deployments/my-embeddings/embeddings" || logged[0].Header.Get("api-key") != "REDACTED" {
+		t.Fatalf("%s failed: unexpected log entries %#v", testName, logged)
+	}
+
+	// requests failing without a response are logged with the error
+	server.Close()
+	_ = client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"})
+	if len(errs) != 2 || errs[1] == nil {
+		t.Fatalf("%s failed: expected network error to be logged but received %#v", testName, errs)
+	}
+}
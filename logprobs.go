@@ -0,0 +1,62 @@
+package oaiaux
+
+import "math"
+
+// ChatLogprobs captures the log probabilities of the tokens of a chat-completions choice
+// (see ChatPromptInput.Logprobs).
+type ChatLogprobs struct {
+	// Content holds the log probabilities of the message content tokens, in order.
+	Content []ChatTokenLogprob `json:"content"`
+	// Refusal holds the log probabilities of the refusal message tokens, if the model refused to answer.
+	Refusal []ChatTokenLogprob `json:"refusal,omitempty"`
+}
+
+// ChatTokenLogprob is the log probability of a generated token, along with the most likely alternatives.
+type ChatTokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	// Bytes is the UTF-8 bytes of the token (a token may be part of a multi-byte character), nil if not available.
+	Bytes []int `json:"bytes"`
+	// TopLogprobs lists the most likely tokens at this position (see ChatPromptInput.TopLogprobs).
+	TopLogprobs []ChatTopLogprob `json:"top_logprobs"`
+}
+
+// ChatTopLogprob is one of the most likely tokens at a position of the generated content.
+type ChatTopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes"`
+}
+
+// Probability returns the probability of the token, in [0, 1].
+func (l ChatTokenLogprob) Probability() float64 {
+	return math.Exp(l.Logprob)
+}
+
+// Probability returns the probability of the token, in [0, 1].
+func (l ChatTopLogprob) Probability() float64 {
+	return math.Exp(l.Logprob)
+}
+
+// CompletionsLogprobs captures the log probabilities of the tokens of a completions choice (see PromptInput.LogProbs).
+// The arrays are parallel: element i of each array describes token i.
+//
+// With PromptInput.Echo, the prompt tokens are included first; the first token has no log probability (its
+// TokenLogprobs element is nil, and its TopLogprobs element is empty).
+type CompletionsLogprobs struct {
+	Tokens        []string   `json:"tokens"`
+	TokenLogprobs []*float64 `json:"token_logprobs"`
+	// TopLogprobs maps the most likely tokens at each position to their log probabilities.
+	TopLogprobs []map[string]float64 `json:"top_logprobs"`
+	// TextOffset is the character offset of each token in the text (including the echoed prompt, if any).
+	TextOffset []int `json:"text_offset"`
+}
+
+// Probability returns the probability, in [0, 1], of the i-th token. false is returned if the token has no log
+// probability (or i is out of range).
+func (l *CompletionsLogprobs) Probability(i int) (float64, bool) {
+	if l == nil || i < 0 || i >= len(l.TokenLogprobs) || l.TokenLogprobs[i] == nil {
+		return 0, false
+	}
+	return math.Exp(*l.TokenLogprobs[i]), true
+}
@@ -0,0 +1,87 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestChatCompletions_Logprobs(t *testing.T) {
+	testName := "TestChatCompletions_Logprobs"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,
+			"message":{"role":"assistant","content":"Yes"},"finish_reason":"stop",
+			"logprobs":{"content":[{"token":"Yes","logprob":-0.1,"bytes":[89,101,115],
+				"top_logprobs":[{"token":"Yes","logprob":-0.1,"bytes":[89,101,115]},{"token":"No","logprob":-2.4,"bytes":[78,111]}]}]}}]}`))
+	})
+	defer server.Close()
+
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Is it?"}}, TopLogprobs: 2})
+	if output.Error != nil || received["logprobs"] != true || received["top_logprobs"] != 2.0 {
+		t.Fatalf("%s failed: unexpected request %#v / %s", testName, received, output.Error)
+	}
+	logprobs := output.Choices[0].Logprobs
+	if logprobs == nil || len(logprobs.Content) != 1 || len(logprobs.Content[0].TopLogprobs) != 2 ||
+		logprobs.Content[0].TopLogprobs[1].Token != "No" || math.Abs(logprobs.Content[0].Probability()-math.Exp(-0.1)) > 1e-9 {
+		t.Fatalf("%s failed: unexpected logprobs %#v", testName, logprobs)
+	}
+
+	client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Is it?"}}})
+	if _, ok := received["logprobs"]; ok {
+		t.Fatalf("%s failed: logprobs should be omitted when unset", testName)
+	}
+}
+
+func TestCollectChatStream_Logprobs(t *testing.T) {
+	testName := "TestCollectChatStream_Logprobs"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, token := range []string{"Hello", " world"} {
+			_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q},\"logprobs\":{\"content\":[{\"token\":%q,\"logprob\":-0.%d,\"top_logprobs\":[]}]}}]}\n\n", token, token, i+1)
+		}
+		_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
+	})
+	defer server.Close()
+
+	output, _ := CollectChatStream(client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Hi"}}, Logprobs: true}))
+	logprobs := output.Choices[0].Logprobs
+	if output.Error != nil || logprobs == nil || len(logprobs.Content) != 2 || logprobs.Content[1].Token != " world" || logprobs.Content[1].Logprob != -0.2 {
+		t.Fatalf("%s failed: unexpected logprobs %#v / %s", testName, logprobs, output.Error)
+	}
+}
+
+func TestCompletions_Logprobs(t *testing.T) {
+	testName := "TestCompletions_Logprobs"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","object":"text_completion","choices":[{"index":0,"text":"Say yes","finish_reason":"length",
+			"logprobs":{"tokens":["Say"," yes"],"token_logprobs":[null,-0.5],
+				"top_logprobs":[null,{" yes":-0.5," no":-1.2}],"text_offset":[0,3]}}]}`))
+	})
+	defer server.Close()
+
+	output := client.Completions(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompt: "Say", Echo: true, LogProbs: 2, MaxTokens: 1})
+	if output.Error != nil || received["echo"] != true || received["logprobs"] != 2.0 {
+		t.Fatalf("%s failed: unexpected request %#v / %s", testName, received, output.Error)
+	}
+	logprobs := output.Choices[0].LogProbs
+	if logprobs == nil || len(logprobs.Tokens) != 2 || logprobs.Tokens[1] != " yes" || logprobs.TokenLogprobs[0] != nil ||
+		*logprobs.TokenLogprobs[1] != -0.5 || logprobs.TopLogprobs[1][" no"] != -1.2 || logprobs.TextOffset[1] != 3 {
+		t.Fatalf("%s failed: unexpected logprobs %#v", testName, logprobs)
+	}
+	if _, ok := logprobs.Probability(0); ok {
+		t.Fatalf("%s failed: expected no probability for the first echoed token", testName)
+	}
+	if p, ok := logprobs.Probability(1); !ok || math.Abs(p-math.Exp(-0.5)) > 1e-9 {
+		t.Fatalf("%s failed: unexpected probability %#v", testName, p)
+	}
+	if _, ok := logprobs.Probability(2); ok {
+		t.Fatalf("%s failed: expected no probability out of range", testName)
+	}
+}
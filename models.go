@@ -0,0 +1,210 @@
+package oaiaux
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// modelContextWindows maps model names to their maximum context window (in tokens), see ModelContextWindow.
+var (
+	modelContextWindowsLock sync.RWMutex
+	modelContextWindows     = map[string]int{
+		"gpt-3.5-turbo":          16385,
+		"gpt-3.5-turbo-0613":     4096,
+		"gpt-3.5-turbo-16k":      16385,
+		"gpt-3.5-turbo-instruct": 4096,
+		"gpt-35-turbo":           16385,
+		"gpt-35-turbo-16k":       16385,
+		"gpt-4":                  8192,
+		"gpt-4-32k":              32768,
+		"gpt-4-1106-preview":     128000,
+		"gpt-4-0125-preview":     128000,
+		"gpt-4-vision-preview":   128000,
+		"gpt-4-turbo":            128000,
+		"gpt-4-turbo-preview":    128000,
+		"gpt-4o":                 128000,
+		"gpt-4o-mini":            128000,
+		"gpt-4.1":                1047576,
+		"gpt-4.1-mini":           1047576,
+		"gpt-4.1-nano":           1047576,
+		"o1":                     200000,
+		"o1-mini":                128000,
+		"o1-preview":             128000,
+		"o3":                     200000,
+		"o3-mini":                200000,
+		"o4-mini":                200000,
+		"text-davinci-003":       4097,
+		"text-davinci-002":       4097,
+		"text-embedding-ada-002": 8191,
+		"text-embedding-3-small": 8191,
+		"text-embedding-3-large": 8191,
+	}
+)
+
+// RegisterModelContextWindow adds or overrides the context window (in tokens) of a model used by ModelContextWindow,
+// e.g. for fine-tuned models or Azure OpenAI deployments not named after their model.
+func RegisterModelContextWindow(model string, tokens int) {
+	modelContextWindowsLock.Lock()
+	defer modelContextWindowsLock.Unlock()
+	modelContextWindows[model] = tokens
+}
+
+// ModelContextWindow returns the context window (maximum number of prompt plus completion tokens) of a model, e.g.
+// to size the chunks of a document. Dated snapshots (e.g. "gpt-4o-2024-08-06") resolve to the longest matching model
+// name (e.g. "gpt-4o"). (0, false) is returned for unknown models, see RegisterModelContextWindow.
+func ModelContextWindow(model string) (int, bool) {
+	modelContextWindowsLock.RLock()
+	defer modelContextWindowsLock.RUnlock()
+	if window, ok := modelContextWindows[model]; ok {
+		return window, true
+	}
+	match := ""
+	for name := range modelContextWindows {
+		if len(name) > len(match) && strings.HasPrefix(model, name+"-") {
+			match = name
+		}
+	}
+	if match == "" {
+		return 0, false
+	}
+	return modelContextWindows[match], true
+}
+
+// checkContextWindow returns an error (ErrContextWindowExceeded) if promptTokens plus maxTokens exceed the context
+// window of the model. Models with unknown context window are not checked, nor are prompts whose tokens cannot be
+// counted (promptTokens returning a negative value).
+func checkContextWindow(model string, maxTokens int, promptTokens func() int) error {
+	window, ok := ModelContextWindow(model)
+	if !ok {
+		return nil
+	}
+	n := promptTokens()
+	if n < 0 {
+		return nil
+	}
+	if maxTokens < 0 {
+		maxTokens = 0
+	}
+	if n+maxTokens > window {
+		return fmt.Errorf("%w: %d prompt tokens + %d max tokens exceed the %d tokens of model %s",
+			ErrContextWindowExceeded, n, maxTokens, window, model)
+	}
+	return nil
+}
+
+// reasoningModels lists the names of reasoning models.
+var reasoningModels = []string{"o1", "o1-mini", "o1-preview", "o3", "o3-mini", "o4-mini"}
+
+// IsReasoningModel returns true if the model is a reasoning model (o1/o3/o4 families, including dated snapshots).
+//
+// Reasoning models require max_completion_tokens instead of max_tokens and do not support temperature/top_p sampling.
+func IsReasoningModel(model string) bool {
+	for _, name := range reasoningModels {
+		if model == name || strings.HasPrefix(model, name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// OptReserveTokens specifies the number of tokens to reserve for the completion when selecting a model (default 0).
+const OptReserveTokens = "reserve-tokens"
+
+// SelectModel returns the candidate model with the smallest context window that fits promptTokens plus the reserved
+// completion tokens (see OptReserveTokens). Candidates with the same context window are preferred in the supplied order.
+//
+// Candidates whose context window is unknown are skipped. An error is returned if no candidate fits.
+func SelectModel(promptTokens int, candidates []string, opts ...Option) (string, error) {
+	var optList OptionList = opts
+	reserve, _ := optList.GetInt(OptReserveTokens)
+	required := promptTokens + reserve
+
+	type candidate struct {
+		model  string
+		window int
+	}
+	fits := make([]candidate, 0, len(candidates))
+	for _, model := range candidates {
+		if window, ok := ModelContextWindow(model); ok && window >= required {
+			fits = append(fits, candidate{model: model, window: window})
+		}
+	}
+	if len(fits) == 0 {
+		return "", fmt.Errorf("no candidate model can fit %d tokens", required)
+	}
+	sort.SliceStable(fits, func(i, j int) bool { return fits[i].window < fits[j].window })
+	return fits[0].model, nil
+}
+
+// ModelPricing is the price (in USD per 1K tokens) of a model.
+type ModelPricing struct {
+	InputPer1K  float64
+	OutputPer1K float64
+}
+
+// modelPricings maps model names to their pricing.
+//
+// Dated snapshots resolve to the longest matching model name, the same way as modelContextWindows.
+var (
+	modelPricingsLock sync.RWMutex
+	modelPricings     = map[string]ModelPricing{
+		"gpt-3.5-turbo":          {InputPer1K: 0.0005, OutputPer1K: 0.0015},
+		"gpt-3.5-turbo-instruct": {InputPer1K: 0.0015, OutputPer1K: 0.002},
+		"gpt-35-turbo":           {InputPer1K: 0.0005, OutputPer1K: 0.0015},
+		"gpt-4":                  {InputPer1K: 0.03, OutputPer1K: 0.06},
+		"gpt-4-32k":              {InputPer1K: 0.06, OutputPer1K: 0.12},
+		"gpt-4-1106-preview":     {InputPer1K: 0.01, OutputPer1K: 0.03},
+		"gpt-4-0125-preview":     {InputPer1K: 0.01, OutputPer1K: 0.03},
+		"gpt-4-vision-preview":   {InputPer1K: 0.01, OutputPer1K: 0.03},
+		"gpt-4-turbo":            {InputPer1K: 0.01, OutputPer1K: 0.03},
+		"gpt-4o":                 {InputPer1K: 0.0025, OutputPer1K: 0.01},
+		"gpt-4o-mini":            {InputPer1K: 0.00015, OutputPer1K: 0.0006},
+		"o1":                     {InputPer1K: 0.015, OutputPer1K: 0.06},
+		"o1-preview":             {InputPer1K: 0.015, OutputPer1K: 0.06},
+		"o1-mini":                {InputPer1K: 0.0011, OutputPer1K: 0.0044},
+		"o3-mini":                {InputPer1K: 0.0011, OutputPer1K: 0.0044},
+		"text-embedding-ada-002": {InputPer1K: 0.0001},
+		"text-embedding-3-small": {InputPer1K: 0.00002},
+		"text-embedding-3-large": {InputPer1K: 0.00013},
+	}
+)
+
+// RegisterModelPricing adds or overrides the pricing (in USD per 1K tokens) of a model used by EstimateCost.
+func RegisterModelPricing(model string, inputPer1K, outputPer1K float64) {
+	modelPricingsLock.Lock()
+	defer modelPricingsLock.Unlock()
+	modelPricings[model] = ModelPricing{InputPer1K: inputPer1K, OutputPer1K: outputPer1K}
+}
+
+// lookupModelPricing returns the pricing of a model, matching dated snapshots to their base model name.
+func lookupModelPricing(model string) (ModelPricing, bool) {
+	modelPricingsLock.RLock()
+	defer modelPricingsLock.RUnlock()
+	if pricing, ok := modelPricings[model]; ok {
+		return pricing, true
+	}
+	match := ""
+	for name := range modelPricings {
+		if len(name) > len(match) && strings.HasPrefix(model, name+"-") {
+			match = name
+		}
+	}
+	if match == "" {
+		return ModelPricing{}, false
+	}
+	return modelPricings[match], true
+}
+
+// EstimateCost estimates the cost (in USD) of an API call from its token usage, based on the model's pricing
+// (see RegisterModelPricing). An error is returned if the model's pricing is unknown.
+//
+// Prices change over time and differ between OpenAI and Azure OpenAI; the built-in table is a best-effort default.
+func EstimateCost(model string, promptTokens, completionTokens int) (float64, error) {
+	pricing, ok := lookupModelPricing(model)
+	if !ok {
+		return 0, fmt.Errorf("unknown pricing for model <%s>, register it with RegisterModelPricing", model)
+	}
+	return (float64(promptTokens)*pricing.InputPer1K + float64(completionTokens)*pricing.OutputPer1K) / 1000, nil
+}
@@ -0,0 +1,173 @@
+package oaiaux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// ModelInfo describes a model (or, for Azure OpenAI, a model deployment).
+//
+// For Azure OpenAI, Id is the deployment name and Model is the deployed model; Created and OwnedBy are not available.
+type ModelInfo struct {
+	Id      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	Model   string `json:"model,omitempty"`
+}
+
+// ListModelsOutput captures the output of a 'models' listing API call.
+type ListModelsOutput struct {
+	BaseResponse `json:"-"`
+	Object       string      `json:"object"`
+	Data         []ModelInfo `json:"data"`
+}
+
+// RetrieveModelOutput captures the output of a 'models' retrieval API call.
+type RetrieveModelOutput struct {
+	BaseResponse `json:"-"`
+	ModelInfo
+}
+
+// getJson sends a GET request, applying client-wide policies such as rate limiting and retries.
+func (bc *BaseClient) getJson(apiUrl string, header http.Header) *gjrc.GjrcResponse {
+	return bc.sendWithRetries(nil, isRetryableResponse, func() *gjrc.GjrcResponse {
+		return bc.gjrc.Get(apiUrl, gjrc.RequestMeta{Header: header})
+	})
+}
+
+func (bc *BaseClient) buildListModelsOutput(resp *gjrc.GjrcResponse) *ListModelsOutput {
+	models := &ListModelsOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if models.Error == nil {
+		err := bc.unmarshalResponse(resp, models)
+		models.Error = err
+	}
+	return models
+}
+
+func (bc *BaseClient) buildRetrieveModelOutput(resp *gjrc.GjrcResponse) *RetrieveModelOutput {
+	model := &RetrieveModelOutput{BaseResponse: bc.buildBaseResponse(resp)}
+	if model.Error == nil {
+		err := bc.unmarshalResponse(resp, model)
+		model.Error = err
+	}
+	return model
+}
+
+// ping sends an authenticated GET request to apiUrl, returning nil if the response is successful (2xx). A nil ctx is
+// treated as context.Background().
+func (bc *BaseClient) ping(ctx context.Context, apiUrl string, header http.Header) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := bc.waitRateLimiter(ctx, nil); err != nil {
+		return fmt.Errorf("cannot ping: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
+	if err != nil {
+		return fmt.Errorf("cannot ping: %w", err)
+	}
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	resp, err := bc.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot ping: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var detail error = fmt.Errorf("status %d", resp.StatusCode)
+	if apiErr := parseAPIErrorBody(body); apiErr != nil {
+		detail = apiErr
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("cannot ping: %w: %s", ErrUnauthorized, detail)
+	}
+	return fmt.Errorf("cannot ping: %w", detail)
+}
+
+/*----------------------------------------------------------------------*/
+
+// azureDeploymentsApiVersion is the latest api-version of the Azure OpenAI data-plane "deployments" API (see
+// AzureOpenAIClient.ListModels).
+const azureDeploymentsApiVersion = "2023-05-15"
+
+// buildUrlModels builds the url of the "deployments" API, which is always called with azureDeploymentsApiVersion
+// regardless of the configured api-version.
+func (c *AzureOpenAIClient) buildUrlModels(resourceName, id string) string {
+	apiUrl := "{azure-base-url}/openai/deployments{id}?api-version={azure-api-version}"
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	if id != "" {
+		id = "/" + url.PathEscape(id)
+	}
+	apiUrl = strings.ReplaceAll(apiUrl, "{id}", id)
+	apiUrl = strings.ReplaceAll(apiUrl, "{azure-api-version}", azureDeploymentsApiVersion)
+	return apiUrl
+}
+
+// ListModels implements Client.ListModels
+//
+// Azure OpenAI has no equivalent of the platform's models listing: the model deployments of the resource are listed
+// instead, via the data-plane "deployments" API. This API is only available with api-versions up to 2023-05-15, hence
+// it is called with api-version 2023-05-15 whatever the configured api-version (see OptAzureApiVersion).
+func (c *AzureOpenAIClient) ListModels(opts ...Option) *ListModelsOutput {
+	c = c.forCall(opts)
+	resp := c.getJson(c.buildUrlModels(c.resourceName, ""), c.buildRequestHeaders())
+	return c.buildListModelsOutput(resp)
+}
+
+// RetrieveModel implements Client.RetrieveModel
+//
+// Azure OpenAI retrieves the model deployment by its name (see ListModels for limitations).
+func (c *AzureOpenAIClient) RetrieveModel(id string, opts ...Option) *RetrieveModelOutput {
+	c = c.forCall(opts)
+	resp := c.getJson(c.buildUrlModels(c.resourceName, id), c.buildRequestHeaders())
+	return c.buildRetrieveModelOutput(resp)
+}
+
+// Ping implements Client.Ping
+//
+// The primary resource is checked by listing the models available to it ("models" API), with the configured
+// api-version (see OptAzureApiVersion).
+func (c *AzureOpenAIClient) Ping(ctx context.Context) error {
+	apiUrl := c.buildBaseUrl(c.resourceName) + "/openai/models?api-version=" + url.QueryEscape(c.apiVersion)
+	return c.ping(ctx, apiUrl, c.buildRequestHeaders())
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *PlatformOpenAIClient) buildUrlModels(id string) string {
+	apiUrl := c.baseUrl + "/models"
+	if id != "" {
+		apiUrl += "/" + url.PathEscape(id)
+	}
+	return apiUrl
+}
+
+// ListModels implements Client.ListModels
+func (c *PlatformOpenAIClient) ListModels(opts ...Option) *ListModelsOutput {
+	c = c.forCall(opts)
+	resp := c.getJson(c.buildUrlModels(""), c.buildRequestHeaders())
+	return c.buildListModelsOutput(resp)
+}
+
+// RetrieveModel implements Client.RetrieveModel
+func (c *PlatformOpenAIClient) RetrieveModel(id string, opts ...Option) *RetrieveModelOutput {
+	c = c.forCall(opts)
+	resp := c.getJson(c.buildUrlModels(id), c.buildRequestHeaders())
+	return c.buildRetrieveModelOutput(resp)
+}
+
+// Ping implements Client.Ping
+func (c *PlatformOpenAIClient) Ping(ctx context.Context) error {
+	return c.ping(ctx, c.buildUrlModels(""), c.buildRequestHeaders())
+}
@@ -0,0 +1,126 @@
+package oaiaux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_Models(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_Models"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("%s failed: unexpected request %s / %#v", testName, r.Method, r.Header)
+		}
+		switch r.URL.Path {
+		case "/models":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"},` +
+				`{"id":"ft:gpt-4o-mini:my-org::abc123","object":"model","created":1720000000,"owned_by":"my-org"}]}`))
+		case "/models/gpt-4o":
+			_, _ = w.Write([]byte(`{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"The model does not exist","type":"invalid_request_error","code":"model_not_found"}}`))
+		}
+	})
+	defer server.Close()
+
+	models := client.ListModels()
+	if models.Error != nil || models.StatusCode != 200 || len(models.Data) != 2 || models.Data[1].OwnedBy != "my-org" {
+		t.Fatalf("%s failed: unexpected output %#v / %#v / %#v", testName, models.Error, models.StatusCode, models.Data)
+	}
+	model := client.RetrieveModel("gpt-4o")
+	if model.Error != nil || model.Id != "gpt-4o" || model.Created != 1715367049 {
+		t.Fatalf("%s failed: unexpected output %#v / %#v", testName, model.Error, model.ModelInfo)
+	}
+	if model = client.RetrieveModel("unknown"); model.StatusCode != 404 || model.Error == nil {
+		t.Fatalf("%s failed: expected error for unknown model but received %#v / %#v", testName, model.StatusCode, model.Error)
+	}
+}
+
+func TestAzureOpenAIClient_Models(t *testing.T) {
+	testName := "TestAzureOpenAIClient_Models"
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+"?"+r.URL.RawQuery)
+		if r.URL.Path == "/openai/deployments" {
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-35-turbo-prod","object":"deployment","model":"gpt-35-turbo"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"gpt-35-turbo-prod","object":"deployment","model":"gpt-35-turbo"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"})
+
+	models := client.ListModels()
+	if models.Error != nil || len(models.Data) != 1 || models.Data[0].Model != "gpt-35-turbo" {
+		t.Fatalf("%s failed: unexpected output %#v / %#v", testName, models.Error, models.Data)
+	}
+	model := client.RetrieveModel("gpt-35-turbo-prod", Option{Key: OptAzureApiVersion, Value: "2024-10-21"})
+	if model.Error != nil || model.Id != "gpt-35-turbo-prod" {
+		t.Fatalf("%s failed: unexpected output %#v / %#v", testName, model.Error, model.ModelInfo)
+	}
+	expected := []string{"/openai/deployments?api-version=2023-05-15", "/openai/deployments/gpt-35-turbo-prod?api-version=2023-05-15"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("%s failed: expected %#v but received %#v", testName, expected, requests)
+	}
+}
+
+func TestPing(t *testing.T) {
+	testName := "TestPing"
+	status := http.StatusOK
+	var path string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.URL.RawQuery != "" {
+			path += "?" + r.URL.RawQuery
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`))
+	}
+	client, server := newTestPlatformClient(t, handler)
+	defer server.Close()
+
+	if err := client.Ping(context.Background()); err != nil || path != "/models" {
+		t.Fatalf("%s failed: unexpected error %v (path %s)", testName, err, path)
+	}
+	status = http.StatusUnauthorized
+	err := client.Ping(context.Background())
+	if !errors.Is(err, ErrUnauthorized) || !strings.Contains(err.Error(), "Incorrect API key") {
+		t.Fatalf("%s failed: expected ErrUnauthorized but received %v", testName, err)
+	}
+	status = http.StatusInternalServerError
+	var apiErr *APIError
+	if err = client.Ping(context.Background()); errors.Is(err, ErrUnauthorized) || !errors.As(err, &apiErr) {
+		t.Fatalf("%s failed: expected APIError but received %v", testName, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = client.Ping(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("%s failed: expected context.Canceled but received %v", testName, err)
+	}
+
+	azureServer := httptest.NewServer(http.HandlerFunc(handler))
+	defer azureServer.Close()
+	azureClient, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: azureServer.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"})
+	status = http.StatusOK
+	if err = azureClient.Ping(nil); err != nil || path != "/openai/models?api-version="+DefaultAzureApiVersion {
+		t.Fatalf("%s failed: unexpected error %v (path %s)", testName, err, path)
+	}
+	status = http.StatusNotFound
+	if err = azureClient.Ping(context.Background()); err == nil {
+		t.Fatalf("%s failed: expected error for status %d", testName, status)
+	}
+	status = http.StatusForbidden
+	if err = azureClient.Ping(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("%s failed: expected ErrUnauthorized but received %v", testName, err)
+	}
+}
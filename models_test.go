@@ -0,0 +1,217 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestSelectModel(t *testing.T) {
+	testName := "TestSelectModel"
+	candidates := []string{"gpt-4o", "gpt-4", "gpt-3.5-turbo-instruct", "unknown-model"}
+	testData := []struct {
+		name         string
+		promptTokens int
+		reserve      int
+		expected     string
+	}{
+		{name: "small", promptTokens: 1000, expected: "gpt-3.5-turbo-instruct"},
+		{name: "small+reserve", promptTokens: 1000, reserve: 4000, expected: "gpt-4"},
+		{name: "medium", promptTokens: 6000, expected: "gpt-4"},
+		{name: "large", promptTokens: 100000, expected: "gpt-4o"},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			model, err := SelectModel(testCase.promptTokens, candidates, Option{Key: OptReserveTokens, Value: testCase.reserve})
+			if err != nil || model != testCase.expected {
+				t.Fatalf("%s failed: expected %#v but received %#v / %s", testName+"/"+testCase.name, testCase.expected, model, err)
+			}
+		})
+	}
+	if _, err := SelectModel(200000, candidates); err == nil {
+		t.Fatalf("%s failed: expected error when no candidate fits", testName)
+	}
+	if window, ok := ModelContextWindow("gpt-4o-mini-2024-07-18"); !ok || window != 128000 {
+		t.Fatalf("%s failed: expected dated snapshot to resolve to its base model but received %#v", testName, window)
+	}
+}
+
+func TestModelContextWindow(t *testing.T) {
+	testName := "TestModelContextWindow"
+	testData := []struct {
+		model    string
+		expected int
+	}{
+		{model: "gpt-3.5-turbo-instruct", expected: 4096},
+		{model: "gpt-4", expected: 8192},
+		{model: "gpt-4-0613", expected: 8192},
+		{model: "gpt-4-32k-0613", expected: 32768},
+		{model: "gpt-4o-2024-08-06", expected: 128000},
+		{model: "gpt-4.1-mini-2025-04-14", expected: 1047576},
+		{model: "o3-mini-2025-01-31", expected: 200000},
+		{model: "gpt-35-turbo", expected: 16385},
+		{model: "unknown-model", expected: 0},
+	}
+	for _, testCase := range testData {
+		if window, ok := ModelContextWindow(testCase.model); window != testCase.expected || ok != (testCase.expected > 0) {
+			t.Fatalf("%s failed: expected %d for %s but received %d / %v", testName, testCase.expected, testCase.model, window, ok)
+		}
+	}
+
+	RegisterModelContextWindow("my-finetuned-model", 16384)
+	defer func() {
+		modelContextWindowsLock.Lock()
+		defer modelContextWindowsLock.Unlock()
+		delete(modelContextWindows, "my-finetuned-model")
+	}()
+	if window, ok := ModelContextWindow("my-finetuned-model-2025-01-01"); !ok || window != 16384 {
+		t.Fatalf("%s failed: expected registered context window but received %d / %v", testName, window, ok)
+	}
+}
+
+func TestOptValidateContextWindow(t *testing.T) {
+	testName := "TestOptValidateContextWindow"
+	calls := 0
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","choices":[{"index":0,"text":"Hi","message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`))
+	}, Option{Key: OptValidateContextWindow, Value: true})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "Hello world"}}
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4", Messages: messages, MaxTokens: 8190})
+	if !errors.Is(output.Error, ErrContextWindowExceeded) || calls != 0 {
+		t.Fatalf("%s failed: expected ErrContextWindowExceeded without calling the API but received %#v after %d calls", testName, output.Error, calls)
+	}
+	if output = client.ChatCompletions(&ChatPromptInput{Model: "gpt-4", Messages: messages, MaxTokens: 8000}); output.Error != nil || calls != 1 {
+		t.Fatalf("%s failed: expected fitting prompt to be sent but received %#v after %d calls", testName, output.Error, calls)
+	}
+	if output = client.ChatCompletions(&ChatPromptInput{Model: "my-custom-model", Messages: messages, MaxTokens: 1000000}); output.Error != nil || calls != 2 {
+		t.Fatalf("%s failed: expected unknown model to skip the check but received %#v after %d calls", testName, output.Error, calls)
+	}
+	if output := client.Completions(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompts: []string{"Hi", "Hello world"}, MaxTokens: 4095}); !errors.Is(output.Error, ErrContextWindowExceeded) || calls != 2 {
+		t.Fatalf("%s failed: expected ErrContextWindowExceeded without calling the API but received %#v after %d calls", testName, output.Error, calls)
+	}
+	if stream := client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-4", Messages: messages, MaxTokens: 8190}); !errors.Is(stream.Error, ErrContextWindowExceeded) || calls != 2 {
+		t.Fatalf("%s failed: expected ErrContextWindowExceeded without calling the API but received %#v after %d calls", testName, stream.Error, calls)
+	}
+
+	client, server2 := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","choices":[]}`))
+	})
+	defer server2.Close()
+	if output = client.ChatCompletions(&ChatPromptInput{Model: "gpt-4", Messages: messages, MaxTokens: 8190}); output.Error != nil || calls != 3 {
+		t.Fatalf("%s failed: expected no check by default but received %#v after %d calls", testName, output.Error, calls)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	testName := "TestEstimateCost"
+	testData := []struct {
+		model            string
+		promptTokens     int
+		completionTokens int
+		expected         float64
+	}{
+		{model: "gpt-4", promptTokens: 1000, completionTokens: 500, expected: 0.06},
+		{model: "gpt-4o-2024-08-06", promptTokens: 2000, completionTokens: 1000, expected: 0.015},
+		{model: "text-embedding-3-small", promptTokens: 1000000, expected: 0.02},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.model, func(t *testing.T) {
+			cost, err := EstimateCost(testCase.model, testCase.promptTokens, testCase.completionTokens)
+			if err != nil || math.Abs(cost-testCase.expected) > 1e-9 {
+				t.Fatalf("%s failed: expected %#v but received %#v / %s", testName+"/"+testCase.model, testCase.expected, cost, err)
+			}
+		})
+	}
+	defer func() {
+		modelPricingsLock.Lock()
+		defer modelPricingsLock.Unlock()
+		delete(modelPricings, "my-custom-model")
+	}()
+	if _, err := EstimateCost("my-custom-model", 1000, 1000); err == nil {
+		t.Fatalf("%s failed: expected error for unknown model", testName)
+	}
+	RegisterModelPricing("my-custom-model", 0.001, 0.002)
+	if cost, err := EstimateCost("my-custom-model", 1000, 1000); err != nil || math.Abs(cost-0.003) > 1e-9 {
+		t.Fatalf("%s failed: expected %#v but received %#v / %s", testName, 0.003, cost, err)
+	}
+}
+
+func TestIsReasoningModel(t *testing.T) {
+	testName := "TestIsReasoningModel"
+	testData := map[string]bool{
+		"o1": true, "o1-mini": true, "o1-2024-12-17": true, "o3-mini-2025-01-31": true, "o4-mini": true,
+		"gpt-4o": false, "gpt-4o-mini": false, "o10": false, "text-embedding-3-small": false,
+	}
+	for model, expected := range testData {
+		if value := IsReasoningModel(model); value != expected {
+			t.Fatalf("%s failed for model <%s>: expected %#v but received %#v", testName, model, expected, value)
+		}
+	}
+}
+
+func TestPrepareChatPrompt_ReasoningModel(t *testing.T) {
+	testName := "TestPrepareChatPrompt_ReasoningModel"
+	bc := &BaseClient{defaultMaxTokens: 200}
+	testData := []struct {
+		name                        string
+		input                       ChatPromptInput
+		expectedMaxTokens           int
+		expectedMaxCompletionTokens int
+		expectedTemperature         float64
+	}{
+		{name: "swap", input: ChatPromptInput{Model: "o1-mini", MaxTokens: 500, Temperature: 0.2},
+			expectedMaxCompletionTokens: 500, expectedTemperature: 1},
+		{name: "explicit", input: ChatPromptInput{Model: "o1-mini", MaxTokens: 500, MaxCompletionTokens: 1000},
+			expectedMaxCompletionTokens: 1000, expectedTemperature: 1},
+		{name: "default", input: ChatPromptInput{Model: "o3-mini"}, expectedMaxCompletionTokens: 200, expectedTemperature: 1},
+		{name: "non-reasoning", input: ChatPromptInput{Model: "gpt-4o", MaxTokens: 500, Temperature: 0.2},
+			expectedMaxTokens: 500, expectedTemperature: 0.2},
+		{name: "non-reasoning-override", input: ChatPromptInput{Model: "my-deployment", MaxCompletionTokens: 300, Temperature: 1},
+			expectedMaxCompletionTokens: 300, expectedTemperature: 1},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			prompt := bc.prepareChatPrompt(&testCase.input)
+			if prompt.MaxTokens != testCase.expectedMaxTokens || prompt.MaxCompletionTokens != testCase.expectedMaxCompletionTokens ||
+				prompt.Temperature != testCase.expectedTemperature {
+				t.Fatalf("%s failed: expected %#v/%#v/%#v but received %#v/%#v/%#v", testName+"/"+testCase.name,
+					testCase.expectedMaxTokens, testCase.expectedMaxCompletionTokens, testCase.expectedTemperature,
+					prompt.MaxTokens, prompt.MaxCompletionTokens, prompt.Temperature)
+			}
+		})
+	}
+}
+
+func TestChatCompletions_ReasoningTokens(t *testing.T) {
+	testName := "TestChatCompletions_ReasoningTokens"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"o3-mini-2025-01-31","choices":[` +
+			`{"index":0,"message":{"role":"assistant","content":"42"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":20,"completion_tokens":310,"total_tokens":330,` +
+			`"completion_tokens_details":{"reasoning_tokens":300,"accepted_prediction_tokens":0,"rejected_prediction_tokens":0}}}`))
+	})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "What is the answer?"}}
+	output := client.ChatCompletions(&ChatPromptInput{Model: "o3-mini", ReasoningEffort: "low", Messages: messages})
+	if output.Error != nil || received["reasoning_effort"] != "low" {
+		t.Fatalf("%s failed: %#v / %#v", testName, output.Error, received)
+	}
+	if output.Usage == nil || output.Usage.CompletionTokensDetails == nil || output.Usage.CompletionTokensDetails.ReasoningTokens != 300 {
+		t.Fatalf("%s failed: unexpected usage %#v", testName, output.Usage)
+	}
+
+	client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: messages})
+	if _, ok := received["reasoning_effort"]; ok {
+		t.Fatalf("%s failed: reasoning_effort should be omitted if empty", testName)
+	}
+}
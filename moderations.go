@@ -0,0 +1,88 @@
+package oaiaux
+
+import (
+	"strings"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+type ModerationsInput struct {
+	Model string `json:"model,omitempty"`
+	Input string `json:"input"`
+}
+
+// ModerationsOutput captures the output of a 'moderations' API call.
+//
+// Model is the model actually serving the request, whereas ModelRequested is the model specified in the input
+// (for Azure OpenAI, the model name as specified, not the deployment name it maps to via OptAzureDeployments).
+type ModerationsOutput struct {
+	BaseResponse   `json:"-"`
+	Id             string              `json:"id"`
+	Model          string              `json:"model"`
+	ModelRequested string              `json:"-"`
+	Results        []ModerationsResult `json:"results"`
+}
+
+// ModerationsResult is the moderation result of an input.
+type ModerationsResult struct {
+	// Flagged is true if the input violates any of the categories.
+	Flagged bool `json:"flagged"`
+	// Categories maps category names (e.g. "hate", "violence/graphic") to whether the input violates the category.
+	Categories map[string]bool `json:"categories"`
+	// CategoryScores maps category names to the model's confidence (between 0 and 1) that the input violates the category.
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// Flagged returns true if any of the inputs is flagged.
+func (o *ModerationsOutput) Flagged() bool {
+	for _, r := range o.Results {
+		if r.Flagged {
+			return true
+		}
+	}
+	return false
+}
+
+func (bc *BaseClient) buildModerationsOutput(resp *gjrc.GjrcResponse, modelRequested string) *ModerationsOutput {
+	moderations := &ModerationsOutput{BaseResponse: bc.buildBaseResponse(resp), ModelRequested: modelRequested}
+	if moderations.Error == nil {
+		err := bc.unmarshalResponse(resp, moderations)
+		moderations.Error = err
+	}
+	return moderations
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *AzureOpenAIClient) buildUrlModerations(resourceName string, input *ModerationsInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/moderations?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(input.Model))
+	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
+	return url
+}
+
+// Moderations implements Client.Moderations
+func (c *AzureOpenAIClient) Moderations(input *ModerationsInput, opts ...Option) *ModerationsOutput {
+	c = c.forCall(opts)
+	resp := c.postJsonWithFailover(func(resourceName string) string {
+		return c.buildUrlModerations(resourceName, input)
+	}, input)
+	return c.buildModerationsOutput(resp, input.Model)
+}
+
+/*----------------------------------------------------------------------*/
+
+func (c *PlatformOpenAIClient) buildUrlModerations(input *ModerationsInput) string {
+	url := c.baseUrl + "/moderations"
+	return url
+}
+
+// Moderations implements Client.Moderations
+func (c *PlatformOpenAIClient) Moderations(input *ModerationsInput, opts ...Option) *ModerationsOutput {
+	c = c.forCall(opts)
+	apiUrl := c.buildUrlModerations(input)
+	header := c.buildRequestHeaders()
+	resp := c.postJson(apiUrl, header, input)
+	return c.buildModerationsOutput(resp, input.Model)
+}
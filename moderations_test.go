@@ -0,0 +1,39 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_Moderations(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_Moderations"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/moderations" {
+			t.Errorf("%s failed: unexpected path %#v", testName, r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"modr-123","model":"text-moderation-007","results":[{"flagged":true,` +
+			`"categories":{"hate":false,"violence":true},"category_scores":{"hate":0.01,"violence":0.93}}]}`))
+	})
+	defer server.Close()
+
+	output := client.Moderations(&ModerationsInput{Model: "text-moderation-latest", Input: "some text"})
+	if output.Error != nil || output.StatusCode != 200 {
+		t.Fatalf("%s failed: %#v / %#v", testName, output.Error, output.StatusCode)
+	}
+	if received["input"] != "some text" || received["model"] != "text-moderation-latest" {
+		t.Fatalf("%s failed: unexpected request body %#v", testName, received)
+	}
+	if !output.Flagged() || len(output.Results) != 1 {
+		t.Fatalf("%s failed: expected flagged result but received %#v", testName, output.Results)
+	}
+	result := output.Results[0]
+	if !result.Categories["violence"] || result.Categories["hate"] || result.CategoryScores["violence"] != 0.93 {
+		t.Fatalf("%s failed: unexpected categories %#v / %#v", testName, result.Categories, result.CategoryScores)
+	}
+	if output.ModelRequested != "text-moderation-latest" || output.Model != "text-moderation-007" {
+		t.Fatalf("%s failed: unexpected models %#v / %#v", testName, output.ModelRequested, output.Model)
+	}
+}
@@ -108,22 +108,30 @@ Sample usage:
 package oaiaux
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/btnguyen2k/consu/gjrc"
 	"github.com/btnguyen2k/consu/reddo"
-	"github.com/tiktoken-go/tokenizer"
 )
 
 const (
 	// Version of oaiaux
-	Version = "0.1.2"
+	Version = "0.2.0"
+
+	defaultTimeout = 60 * time.Second
 )
 
 // Flavor specifies which OpenAI "flavor" to use (currently available: platform.openai.com and Azure OpenAI).
@@ -136,6 +144,14 @@ const (
 
 var (
 	ErrOptionNotFound = errors.New("option not found")
+
+	// ErrInvalidParameter is returned (wrapped) when a prompt is rejected before being sent, e.g. a parameter out of
+	// the range accepted by the API.
+	ErrInvalidParameter = errors.New("invalid parameter")
+
+	// ErrContextWindowExceeded is returned (wrapped) when a prompt cannot fit the model's context window and
+	// OptValidateContextWindow is enabled.
+	ErrContextWindowExceeded = errors.New("context window exceeded")
 )
 
 // Option contains an option/parameter to supply to API/function calls.
@@ -149,9 +165,44 @@ func (o Option) AsString() (string, error) {
 	return reddo.ToString(o.Value)
 }
 
+// AsBool returns the option value as bool.
+func (o Option) AsBool() (bool, error) {
+	return reddo.ToBool(o.Value)
+}
+
+// AsInt returns the option value as int.
+func (o Option) AsInt() (int, error) {
+	v, err := reddo.ToInt(o.Value)
+	return int(v), err
+}
+
+// AsDuration returns the option value as time.Duration.
+//
+// The value can be a time.Duration, a number of seconds, or a string parseable by time.ParseDuration (e.g. "1m30s").
+func (o Option) AsDuration() (time.Duration, error) {
+	switch v := o.Value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		return time.ParseDuration(v)
+	}
+	v, err := reddo.ToFloat(o.Value)
+	return time.Duration(v * float64(time.Second)), err
+}
+
 // OptionList combines individual Option instances for convenient use.
 type OptionList []Option
 
+// Get finds an option matching 'key' and return its raw value.
+func (ol OptionList) Get(key string) (interface{}, error) {
+	for _, o := range ol {
+		if o.Key == key {
+			return o.Value, nil
+		}
+	}
+	return nil, ErrOptionNotFound
+}
+
 // GetString finds an option matching 'key' and return its value as string.
 func (ol OptionList) GetString(key string) (string, error) {
 	for _, o := range ol {
@@ -162,6 +213,36 @@ func (ol OptionList) GetString(key string) (string, error) {
 	return "", ErrOptionNotFound
 }
 
+// GetBool finds an option matching 'key' and return its value as bool.
+func (ol OptionList) GetBool(key string) (bool, error) {
+	for _, o := range ol {
+		if o.Key == key {
+			return o.AsBool()
+		}
+	}
+	return false, ErrOptionNotFound
+}
+
+// GetInt finds an option matching 'key' and return its value as int.
+func (ol OptionList) GetInt(key string) (int, error) {
+	for _, o := range ol {
+		if o.Key == key {
+			return o.AsInt()
+		}
+	}
+	return 0, ErrOptionNotFound
+}
+
+// GetDuration finds an option matching 'key' and return its value as time.Duration.
+func (ol OptionList) GetDuration(key string) (time.Duration, error) {
+	for _, o := range ol {
+		if o.Key == key {
+			return o.AsDuration()
+		}
+	}
+	return 0, ErrOptionNotFound
+}
+
 /*----------------------------------------------------------------------*/
 
 // NewClient creates a new Client instance.
@@ -170,34 +251,123 @@ func (ol OptionList) GetString(key string) (string, error) {
 func NewClient(flavor Flavor, opts ...Option) (Client, error) {
 	switch flavor {
 	case AzureOpenAI:
-		baseClient := &BaseClient{
-			gjrc: gjrc.NewGjrc(nil, 60*time.Second),
-			opts: opts,
-		}
-		client := &AzureOpenAIClient{BaseClient: baseClient}
+		client := &AzureOpenAIClient{BaseClient: newBaseClient(opts)}
 		return client, client.Init()
 	case PlatformOpenAI:
-		baseClient := &BaseClient{
-			gjrc: gjrc.NewGjrc(nil, 60*time.Second),
-			opts: opts,
-		}
-		client := &PlatformOpenAIClient{BaseClient: baseClient}
+		client := &PlatformOpenAIClient{BaseClient: newBaseClient(opts)}
 		return client, client.Init()
 	}
 	return nil, fmt.Errorf("unknown flavor %#v", flavor)
 }
 
+func newBaseClient(opts OptionList) *BaseClient {
+	timeout, err := opts.GetDuration(OptTimeout)
+	var httpClient *http.Client
+	var ownedTransport *http.Transport
+	if v, _ := opts.Get(OptHttpClient); v != nil {
+		if c, ok := v.(*http.Client); ok && c != nil {
+			clone := *c
+			httpClient = &clone
+		}
+	}
+	if httpClient == nil {
+		// the client owns its connection pool, released by Close
+		ownedTransport = http.DefaultTransport.(*http.Transport).Clone()
+		httpClient = &http.Client{Timeout: defaultTimeout, Transport: ownedTransport}
+	}
+	if err == nil && timeout > 0 {
+		httpClient.Timeout = timeout
+	}
+	return &BaseClient{
+		httpClient:     httpClient,
+		ownedTransport: ownedTransport,
+		gjrc:           gjrc.NewGjrc(httpClient, 0),
+		opts:           opts,
+	}
+}
+
 type BaseResponse struct {
 	Error      error `json:"-"`
 	StatusCode int   `json:"-"`
+
+	// Headers holds the HTTP response headers (also populated for error responses), see RateLimit.
+	Headers http.Header `json:"-"`
+
+	// RequestId is the id assigned to the request by the API ("x-request-id" header, or "apim-request-id" for Azure
+	// OpenAI), also populated for error responses. Supply it when reporting issues to OpenAI/Azure support.
+	RequestId string `json:"-"`
+
+	// RawResponse is the underlying HTTP response, retained only if OptExposeRawResponse is enabled.
+	RawResponse *gjrc.GjrcResponse `json:"-"`
 }
 
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-	Name    string `json:"name,omitempty"`
+	Role       string `json:"role"`
+	Content    string `json:"content"`
+	Name       string `json:"name,omitempty"`
+	ToolCallId string `json:"tool_call_id,omitempty"`
+	// Refusal is the refusal message returned by the model in place of Content, e.g. when declining a request in
+	// structured outputs mode.
+	Refusal string `json:"refusal,omitempty"`
+
+	// ToolCalls are the tool calls requested by the model (in "assistant" messages).
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// FunctionCall is the function call requested by the model, when using the legacy Functions API.
+	FunctionCall *FunctionCall `json:"function_call,omitempty"`
+
+	// ContentParts, if non-empty, is sent as the message's content instead of Content (e.g. to mix text and images
+	// for vision models, see TextPart, ImageUrlPart and ImageDataPart).
+	ContentParts []ChatContentPart `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler: ContentParts, if non-empty, is serialized to the "content" key.
+func (msg ChatMessage) MarshalJSON() ([]byte, error) {
+	type chatMessage ChatMessage
+	if len(msg.ContentParts) == 0 {
+		return json.Marshal(chatMessage(msg))
+	}
+	return json.Marshal(struct {
+		chatMessage
+		Content []ChatContentPart `json:"content"`
+	}{chatMessage: chatMessage(msg), Content: msg.ContentParts})
 }
 
+// UnmarshalJSON implements json.Unmarshaler: "content" is parsed into Content if it is a string, or into ContentParts
+// if it is an array of content parts.
+func (msg *ChatMessage) UnmarshalJSON(data []byte) error {
+	type chatMessage ChatMessage
+	var temp struct {
+		chatMessage
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	*msg = ChatMessage(temp.chatMessage)
+	content := bytes.TrimSpace(temp.Content)
+	switch {
+	case len(content) == 0 || bytes.Equal(content, []byte("null")):
+		return nil
+	case content[0] == '[':
+		return json.Unmarshal(content, &msg.ContentParts)
+	default:
+		return json.Unmarshal(content, &msg.Content)
+	}
+}
+
+// ChatPromptInput captures the input of a 'chat-completions' API call.
+//
+// PresencePenalty and FrequencyPenalty must be within [-2.0, 2.0]: out-of-range values make the call fail with
+// ErrInvalidParameter before anything is sent.
+//
+// N is the number of alternative replies to generate (default 1, values below 1 are sent as 1). It is always sent,
+// and all N replies are returned in ChatCompletionsOutput.Choices, sorted by Index (prompt tokens are billed once,
+// completion tokens are billed for each reply), e.g.
+//
+//	prompt := &oaiaux.ChatPromptInput{Model: "gpt-4o-mini", N: 3, Temperature: 1.0, Messages: messages}
+//	for _, choice := range client.ChatCompletions(prompt).Choices { // 3 choices, Index 0, 1 and 2
+//		fmt.Println(choice.Index, choice.Message.Content)
+//	}
 type ChatPromptInput struct {
 	Model            string         `json:"model,omitempty"`
 	Messages         []ChatMessage  `json:"messages"`
@@ -206,35 +376,216 @@ type ChatPromptInput struct {
 	N                int            `json:"n"`
 	Stream           bool           `json:"stream"`
 	Stop             []string       `json:"stop,omitempty"`
-	MaxTokens        int            `json:"max_tokens"`
+	MaxTokens        int            `json:"max_tokens,omitempty"`
 	PresencePenalty  float64        `json:"presence_penalty"`
 	FrequencyPenalty float64        `json:"frequency_penalty"`
 	LogitBias        map[string]int `json:"logit_bias,omitempty"`
 	User             string         `json:"user,omitempty"`
+
+	// Tools lists the tools the model may call.
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice controls which tool is called: "none", "auto", "required", or a specific tool (see ToolChoiceFunction).
+	ToolChoice interface{} `json:"tool_choice,omitempty"`
+	// ParallelToolCalls, if set to false, makes the model call at most one tool per reply (nil: the API's default,
+	// i.e. parallel tool calls are enabled).
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+	// Functions lists the functions the model may call (legacy, superseded by Tools).
+	Functions []FunctionDefinition `json:"functions,omitempty"`
+	// FunctionCall controls which function is called (legacy, superseded by ToolChoice): "none", "auto",
+	// or {"name": "my_function"}.
+	FunctionCall interface{} `json:"function_call,omitempty"`
+
+	// ResponseFormat constrains the format of the reply (see ResponseFormatJsonObject and ResponseFormatJsonSchema).
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Seed, if set, makes the sampling deterministic on a best-effort basis: repeated requests with the same seed and
+	// parameters should return the same result, as long as the backend configuration (see
+	// ChatCompletionsOutput.SystemFingerprint) does not change.
+	Seed *int `json:"seed,omitempty"`
+	// Logprobs, if true, returns the log probabilities of the generated tokens (see ChatCompletionsChoice.Logprobs).
+	Logprobs bool `json:"logprobs,omitempty"`
+	// TopLogprobs is the number of most likely tokens (0 to 20) returned at each position, along with their log
+	// probabilities. Logprobs is implied.
+	TopLogprobs int `json:"top_logprobs,omitempty"`
+	// StreamOptions configures streamed calls (see ChatCompletionsStream); it must be nil for other calls.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// MaxCompletionTokens is the upper bound of generated tokens, including reasoning tokens. It supersedes MaxTokens
+	// and is required by reasoning models (see IsReasoningModel), for which MaxTokens is automatically sent as
+	// MaxCompletionTokens. For other models (or Azure deployments not named after the model), set it explicitly.
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+	// ReasoningEffort constrains the effort of reasoning models on reasoning: one of "low", "medium" (the API's
+	// default) or "high". Reducing it speeds up replies and reduces reasoning tokens (see CompletionTokensDetails).
+	// It must be empty for other models.
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+}
+
+// StopSequences sets the sequences where the model stops generating (the API accepts up to 4) and returns the prompt,
+// e.g. client.ChatCompletions((&ChatPromptInput{...}).StopSequences("\n")).
+func (prompt *ChatPromptInput) StopSequences(stop ...string) *ChatPromptInput {
+	prompt.Stop = stop
+	return prompt
 }
 
+// Clone returns a deep copy of the prompt, e.g. to derive variations of a prompt shared across goroutines. Messages,
+// Stop, LogitBias, Tools and Functions are copied, as well as the pointer fields; values of interface{} fields
+// (ToolChoice, FunctionCall and JSON schemas) are shared.
+func (prompt *ChatPromptInput) Clone() *ChatPromptInput {
+	if prompt == nil {
+		return nil
+	}
+	clone := *prompt
+	clone.Messages = cloneChatMessages(prompt.Messages)
+	clone.Stop = cloneStrings(prompt.Stop)
+	clone.LogitBias = cloneLogitBias(prompt.LogitBias)
+	if prompt.Tools != nil {
+		clone.Tools = append([]Tool{}, prompt.Tools...)
+	}
+	if prompt.Functions != nil {
+		clone.Functions = append([]FunctionDefinition{}, prompt.Functions...)
+	}
+	if prompt.ParallelToolCalls != nil {
+		v := *prompt.ParallelToolCalls
+		clone.ParallelToolCalls = &v
+	}
+	if prompt.ResponseFormat != nil {
+		format := *prompt.ResponseFormat
+		if format.JsonSchema != nil {
+			schema := *format.JsonSchema
+			format.JsonSchema = &schema
+		}
+		clone.ResponseFormat = &format
+	}
+	if prompt.Seed != nil {
+		v := *prompt.Seed
+		clone.Seed = &v
+	}
+	if prompt.StreamOptions != nil {
+		v := *prompt.StreamOptions
+		clone.StreamOptions = &v
+	}
+	return &clone
+}
+
+// cloneChatMessages returns a deep copy of a list of chat messages.
+func cloneChatMessages(messages []ChatMessage) []ChatMessage {
+	if messages == nil {
+		return nil
+	}
+	clone := make([]ChatMessage, len(messages))
+	for i, msg := range messages {
+		if msg.ToolCalls != nil {
+			msg.ToolCalls = append([]ToolCall{}, msg.ToolCalls...)
+		}
+		if msg.FunctionCall != nil {
+			v := *msg.FunctionCall
+			msg.FunctionCall = &v
+		}
+		if msg.ContentParts != nil {
+			parts := make([]ChatContentPart, len(msg.ContentParts))
+			for j, part := range msg.ContentParts {
+				if part.ImageUrl != nil {
+					v := *part.ImageUrl
+					part.ImageUrl = &v
+				}
+				parts[j] = part
+			}
+			msg.ContentParts = parts
+		}
+		clone[i] = msg
+	}
+	return clone
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+func cloneLogitBias(bias map[string]int) map[string]int {
+	if bias == nil {
+		return nil
+	}
+	clone := make(map[string]int, len(bias))
+	for k, v := range bias {
+		clone[k] = v
+	}
+	return clone
+}
+
+// Fingerprint returns a stable hash (hex-encoded SHA-256) of the prompt.
+//
+// The prompt is normalized with the same defaults applied before sending, and serialized with sorted map keys,
+// so that semantically identical prompts produce identical fingerprints. The prompt itself is not modified.
+func (prompt *ChatPromptInput) Fingerprint() string {
+	clone := (&BaseClient{}).prepareChatPrompt(prompt)
+	js, _ := json.Marshal(clone) // encoding/json always sorts map keys
+	hash := sha256.Sum256(js)
+	return hex.EncodeToString(hash[:])
+}
+
+// ChatCompletionsOutput captures the output of a 'chat-completions' API call.
+//
+// Model is the model actually serving the request, which may be a dated snapshot of the requested one (e.g.
+// "gpt-4o-2024-08-06" when "gpt-4o" is requested), whereas ModelRequested is the model specified in the input (for
+// Azure OpenAI, the model name as specified, not the deployment name it maps to via OptAzureDeployments).
+//
+// SystemFingerprint identifies the backend configuration serving the request. Determinism of seeded requests
+// (see ChatPromptInput.Seed) is only expected for responses with the same fingerprint.
 type ChatCompletionsOutput struct {
-	BaseResponse `json:"-"`
-	Id           string `json:"id"`
-	Object       string `json:"object"`
-	Created      int64  `json:"created"`
-	Model        string `json:"model"`
-	Usage        *struct {
-		CompletionTokens int `json:"completion_tokens"`
-		PromptTokens     int `json:"prompt_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
-	Choices []struct {
-		Message      ChatMessage `json:"message"`
-		Index        int         `json:"index"`
-		FinishReason string      `json:"finish_reason"`
-	} `json:"choices"`
+	BaseResponse      `json:"-"`
+	Id                string                  `json:"id"`
+	Object            string                  `json:"object"`
+	Created           int64                   `json:"created"`
+	Model             string                  `json:"model"`
+	ModelRequested    string                  `json:"-"`
+	SystemFingerprint string                  `json:"system_fingerprint"`
+	Usage             *ChatCompletionsUsage   `json:"usage"`
+	Choices           []ChatCompletionsChoice `json:"choices"`
 }
 
+// ChatCompletionsUsage captures the token usage of a 'chat-completions' API call.
+//
+// For reasoning models, CompletionTokens includes the (billed but invisible) reasoning tokens, broken down in
+// CompletionTokensDetails. The details are nil if not reported by the API.
+type ChatCompletionsUsage struct {
+	CompletionTokens        int                      `json:"completion_tokens"`
+	PromptTokens            int                      `json:"prompt_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// CompletionTokensDetails is the breakdown of the completion tokens of a 'chat-completions' API call.
+type CompletionTokensDetails struct {
+	// ReasoningTokens is the number of tokens generated by a reasoning model to "think", not part of the reply.
+	ReasoningTokens int `json:"reasoning_tokens"`
+	// AcceptedPredictionTokens and RejectedPredictionTokens are the numbers of tokens of a predicted output that
+	// appeared, or not, in the reply.
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens,omitempty"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens,omitempty"`
+	AudioTokens              int `json:"audio_tokens,omitempty"`
+}
+
+type ChatCompletionsChoice struct {
+	Message      ChatMessage   `json:"message"`
+	Index        int           `json:"index"`
+	FinishReason string        `json:"finish_reason"`
+	Logprobs     *ChatLogprobs `json:"logprobs"`
+}
+
+// PromptInput captures the input of a 'completions' API call.
+//
+// PresencePenalty and FrequencyPenalty must be within [-2.0, 2.0]: out-of-range values make the call fail with
+// ErrInvalidParameter before anything is sent.
+//
+// To complete multiple prompts in one call, supply them via Prompts (which takes precedence over Prompt when
+// non-empty). The output's choices are indexed across prompts, but not necessarily ordered: the choice at Index i
+// answers the prompt at i / N.
 type PromptInput struct {
 	Model            string         `json:"model,omitempty"`
 	Prompt           string         `json:"prompt"`
-	MaxTokens        int            `json:"max_tokens"`
+	Prompts          []string       `json:"-"`
+	MaxTokens        int            `json:"max_tokens,omitempty"`
 	Temperature      float64        `json:"temperature"`
 	TopP             float64        `json:"top_p"`
 	LogitBias        map[string]int `json:"logit_bias,omitempty"`
@@ -250,83 +601,773 @@ type PromptInput struct {
 	BestOf           int            `json:"best_of"`
 }
 
+// MarshalJSON implements json.Marshaler: Prompts, if non-empty, is serialized to the "prompt" key.
+func (prompt PromptInput) MarshalJSON() ([]byte, error) {
+	type promptInput PromptInput
+	if len(prompt.Prompts) == 0 {
+		return json.Marshal(promptInput(prompt))
+	}
+	return json.Marshal(struct {
+		promptInput
+		Prompt []string `json:"prompt"`
+	}{promptInput: promptInput(prompt), Prompt: prompt.Prompts})
+}
+
+// Clone returns a deep copy of the prompt, e.g. to derive variations of a prompt shared across goroutines.
+func (prompt *PromptInput) Clone() *PromptInput {
+	if prompt == nil {
+		return nil
+	}
+	clone := *prompt
+	clone.Prompts = cloneStrings(prompt.Prompts)
+	clone.Stop = cloneStrings(prompt.Stop)
+	clone.LogitBias = cloneLogitBias(prompt.LogitBias)
+	return &clone
+}
+
+// StopSequences sets the sequences where the model stops generating (the API accepts up to 4) and returns the prompt.
+func (prompt *PromptInput) StopSequences(stop ...string) *PromptInput {
+	prompt.Stop = stop
+	return prompt
+}
+
+// CompletionsOutput captures the output of a 'completions' API call.
+//
+// Model is the model actually serving the request, which may be a dated snapshot of the requested one (e.g.
+// "gpt-4o-2024-08-06" when "gpt-4o" is requested), whereas ModelRequested is the model specified in the input (for
+// Azure OpenAI, the model name as specified, not the deployment name it maps to via OptAzureDeployments).
 type CompletionsOutput struct {
-	BaseResponse `json:"-"`
-	Id           string `json:"id"`
-	Object       string `json:"object"`
-	Created      int64  `json:"created"`
-	Model        string `json:"model"`
-	Usage        *struct {
+	BaseResponse   `json:"-"`
+	Id             string `json:"id"`
+	Object         string `json:"object"`
+	Created        int64  `json:"created"`
+	Model          string `json:"model"`
+	ModelRequested string `json:"-"`
+	Usage          *struct {
 		CompletionTokens int `json:"completion_tokens"`
 		PromptTokens     int `json:"prompt_tokens"`
 		TotalTokens      int `json:"total_tokens"`
 	} `json:"usage"`
 	Choices []struct {
-		Text         string                 `json:"text"`
-		Index        int                    `json:"index"`
-		FinishReason string                 `json:"finish_reason"`
-		LogProbs     map[string]interface{} `json:"logprobs"`
+		Text         string               `json:"text"`
+		Index        int                  `json:"index"`
+		FinishReason string               `json:"finish_reason"`
+		LogProbs     *CompletionsLogprobs `json:"logprobs"`
 	} `json:"choices"`
 }
 
+// EmbeddingsInput is the input of an 'embeddings' API call.
+//
+// To embed multiple strings in one call, supply them via Inputs (which takes precedence over Input when non-empty),
+// and correlate the output's vectors by index (see EmbeddingsOutput.Vectors and EmbeddingsOutput.VectorByIndex).
 type EmbeddingsInput struct {
-	Model     string `json:"model,omitempty"`
-	Input     string `json:"input"`
-	InputType string `json:"input_type,omitempty"`
-	User      string `json:"user,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	Input     string   `json:"input"`
+	Inputs    []string `json:"-"`
+	InputType string   `json:"input_type,omitempty"`
+	User      string   `json:"user,omitempty"`
+	// EncodingFormat is either "float" (default) or "base64", which reduces the response size. Vectors are decoded
+	// transparently in both cases.
+	EncodingFormat string `json:"encoding_format,omitempty"`
+	// Dimensions, if positive, is the number of dimensions of the returned vectors (text-embedding-3 and later models).
+	Dimensions int `json:"dimensions,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler: Inputs, if non-empty, is serialized to the "input" key.
+func (input EmbeddingsInput) MarshalJSON() ([]byte, error) {
+	type embeddingsInput EmbeddingsInput
+	if len(input.Inputs) == 0 {
+		return json.Marshal(embeddingsInput(input))
+	}
+	return json.Marshal(struct {
+		embeddingsInput
+		Input []string `json:"input"`
+	}{embeddingsInput: embeddingsInput(input), Input: input.Inputs})
 }
 
+// EmbeddingsOutput captures the output of an 'embeddings' API call.
+//
+// Model is the model actually serving the request, whereas ModelRequested is the model specified in the input
+// (for Azure OpenAI, the model name as specified, not the deployment name it maps to via OptAzureDeployments).
 type EmbeddingsOutput struct {
-	BaseResponse `json:"-"`
-	Object       string `json:"object"`
-	Model        string `json:"model"`
-	Data         []struct {
-		Index     int    `json:"index"`
-		Object    string `json:"object"`
-		Embedding Vector `json:"embedding"`
-	} `json:"data"`
-	Usage *struct {
+	BaseResponse   `json:"-"`
+	Object         string           `json:"object"`
+	Model          string           `json:"model"`
+	ModelRequested string           `json:"-"`
+	Data           []EmbeddingsData `json:"data"`
+	Usage          *struct {
 		PromptTokens int `json:"prompt_tokens"`
 		TotalTokens  int `json:"total_tokens"`
 	} `json:"usage"`
 }
 
+type EmbeddingsData struct {
+	Index     int    `json:"index"`
+	Object    string `json:"object"`
+	Embedding Vector `json:"embedding"`
+}
+
+// Vectors returns the embeddings vectors, ordered by their index.
+func (o *EmbeddingsOutput) Vectors() []Vector {
+	data := make([]EmbeddingsData, len(o.Data))
+	copy(data, o.Data)
+	sort.SliceStable(data, func(i, j int) bool { return data[i].Index < data[j].Index })
+	result := make([]Vector, len(data))
+	for i, d := range data {
+		result[i] = d.Embedding
+	}
+	return result
+}
+
+// Vectors32 is like Vectors, but returns single-precision vectors (see Vector32).
+func (o *EmbeddingsOutput) Vectors32() []Vector32 {
+	vectors := o.Vectors()
+	result := make([]Vector32, len(vectors))
+	for i, v := range vectors {
+		result[i] = v.To32()
+	}
+	return result
+}
+
+// VectorByIndex returns the embeddings vector at index i, or false if there is no such vector.
+func (o *EmbeddingsOutput) VectorByIndex(i int) (Vector, bool) {
+	for _, d := range o.Data {
+		if d.Index == i {
+			return d.Embedding, true
+		}
+	}
+	return nil, false
+}
+
 // Client captures OpenAI REST API.
+//
+// API methods accept optional per-call settings overriding the client's settings for that call only: OptTimeout,
+// OptHeaders, OptMaxRetries and OptContext, as well as OptOpenAIOrganization and OptOpenAIProject for OpenAI Platform
+// clients (e.g. to serve several tenants with one client and connection pool), and OptAzureApiVersion for Azure
+// OpenAI clients. Other settings are ignored.
+//
+// API methods do not modify their inputs: defaults (e.g. OptDefaultModel or OptDefaultMaxTokens) are applied to a
+// copy of the input, hence an input can be reused for repeated calls, or shared by concurrent calls.
 type Client interface {
 	// Completions make a 'completions' API call and returns the completions output.
-	Completions(prompt *PromptInput) *CompletionsOutput
+	Completions(prompt *PromptInput, opts ...Option) *CompletionsOutput
+
+	// CompletionsStream makes a streamed 'completions' API call and returns the stream of completions chunks.
+	CompletionsStream(prompt *PromptInput, opts ...Option) *CompletionsStreamOutput
 
 	// ChatCompletions make a 'chat-completions' API call and returns the completions output.
-	ChatCompletions(prompt *ChatPromptInput) *ChatCompletionsOutput
+	ChatCompletions(prompt *ChatPromptInput, opts ...Option) *ChatCompletionsOutput
+
+	// ChatCompletionsBatch makes 'chat-completions' API calls for independent prompts concurrently and returns
+	// the completions outputs in input order (see OptBatchConcurrency, OptBatchInterval and OptBatchFailFast).
+	// Failed calls are retried as per OptMaxRetries.
+	ChatCompletionsBatch(prompts []*ChatPromptInput, opts ...Option) []*ChatCompletionsOutput
+
+	// ChatCompletionsStream makes a streamed 'chat-completions' API call and returns the stream of completions chunks.
+	ChatCompletionsStream(prompt *ChatPromptInput, opts ...Option) *ChatCompletionsStreamOutput
 
 	// Embeddings make an 'embeddings' API call and returns the embeddings output.
-	Embeddings(input *EmbeddingsInput) *EmbeddingsOutput
+	Embeddings(input *EmbeddingsInput, opts ...Option) *EmbeddingsOutput
+
+	// EmbedText makes an 'embeddings' API call for a single text and returns its vector, e.g.
+	//
+	//	v, err := client.EmbedText("Hello world", oaiaux.Option{Key: "model", Value: "text-embedding-3-small"})
+	//
+	// The model is specified by the "model" option, or else OptDefaultEmbeddingsModel. An error is returned if the
+	// call fails, or if the response carries no vector. Use Embeddings for advanced cases (dimensions, batching...).
+	EmbedText(text string, opts ...Option) (Vector, error)
+
+	// EmbedTexts embeds texts in batched 'embeddings' API calls (batchSize texts per call, default 100), running up
+	// to concurrency calls in flight (default 4), and returns the vectors in input order. The model is specified like
+	// EmbedText's.
+	//
+	// Failed batches do not abort the others: their errors are aggregated in an *EmbedTextsError, returned along with
+	// the vectors of the successful batches (nil for the texts of the failed ones). Once ctx is done, the pending
+	// batches fail with the context's error, and the in-flight ones are aborted (see OptContext).
+	EmbedTexts(ctx context.Context, texts []string, concurrency, batchSize int, opts ...Option) ([]Vector, error)
+
+	// Moderations make a 'moderations' API call and returns the moderations output.
+	Moderations(input *ModerationsInput, opts ...Option) *ModerationsOutput
+
+	// ImagesGenerate make an 'images-generations' API call and returns the generated images.
+	ImagesGenerate(input *ImagesGenerateInput, opts ...Option) *ImagesGenerateOutput
+
+	// AudioTranslations make an 'audio-translations' API call and returns the English translation of the audio.
+	AudioTranslations(input *AudioTranslationsInput, opts ...Option) *AudioTranslationsOutput
+
+	// Speech make an 'audio-speech' API call and returns the generated audio (see SpeechOutput).
+	Speech(input *SpeechInput, opts ...Option) *SpeechOutput
+
+	// ListModels lists the available models (for Azure OpenAI, the model deployments).
+	ListModels(opts ...Option) *ListModelsOutput
+
+	// RetrieveModel retrieves a model by its id (for Azure OpenAI, a model deployment by its name).
+	RetrieveModel(id string, opts ...Option) *RetrieveModelOutput
+
+	// UploadFile uploads a file (e.g. a JSONL file with purpose "fine-tune" or "batch") via a multipart/form-data
+	// request. The whole content of reader is read before sending the request.
+	UploadFile(reader io.Reader, fileName, purpose string, opts ...Option) *FileOutput
+
+	// ListFiles lists the uploaded files.
+	ListFiles(opts ...Option) *ListFilesOutput
+
+	// RetrieveFile retrieves the information of an uploaded file by its id.
+	RetrieveFile(id string, opts ...Option) *FileOutput
+
+	// DeleteFile deletes an uploaded file by its id.
+	DeleteFile(id string, opts ...Option) *DeleteFileOutput
+
+	// DownloadFileContent downloads the content of an uploaded file by its id (see FileContentOutput).
+	DownloadFileContent(id string, opts ...Option) *FileContentOutput
+
+	// CreateBatch creates a batch processing the requests of an uploaded JSONL file (purpose "batch") asynchronously.
+	// endpoint is the API path of the requests (e.g. "/v1/chat/completions"); an empty completionWindow defaults to
+	// "24h". Poll the batch with RetrieveBatch until it is finished (see BatchInfo.IsFinished).
+	CreateBatch(inputFileId, endpoint, completionWindow string, opts ...Option) *BatchOutput
+
+	// RetrieveBatch retrieves a batch by its id.
+	RetrieveBatch(id string, opts ...Option) *BatchOutput
+
+	// ListBatches lists the batches.
+	ListBatches(opts ...Option) *ListBatchesOutput
+
+	// CancelBatch cancels an in-progress batch. The batch is "cancelling" until in-flight requests are finished.
+	CancelBatch(id string, opts ...Option) *BatchOutput
+
+	// CreateFineTuningJob creates a job fine-tuning a base model with an uploaded JSONL file (purpose "fine-tune").
+	// hyperparameters is optional. Poll the job with RetrieveFineTuningJob until it is finished
+	// (see FineTuningJobInfo.IsFinished).
+	CreateFineTuningJob(trainingFileId, model string, hyperparameters *FineTuningHyperparameters, opts ...Option) *FineTuningJobOutput
+
+	// ListFineTuningJobs lists the fine-tuning jobs.
+	ListFineTuningJobs(opts ...Option) *ListFineTuningJobsOutput
+
+	// RetrieveFineTuningJob retrieves a fine-tuning job by its id.
+	RetrieveFineTuningJob(id string, opts ...Option) *FineTuningJobOutput
+
+	// CancelFineTuningJob cancels a fine-tuning job by its id.
+	CancelFineTuningJob(id string, opts ...Option) *FineTuningJobOutput
+
+	// ListFineTuningEvents lists the status updates of a fine-tuning job, most recent first.
+	ListFineTuningEvents(id string, opts ...Option) *ListFineTuningEventsOutput
+
+	// CreateAssistant creates an assistant of the (beta) Assistants API.
+	CreateAssistant(input *AssistantInput, opts ...Option) *AssistantOutput
+
+	// ListAssistants lists the assistants.
+	ListAssistants(opts ...Option) *ListAssistantsOutput
+
+	// CreateThread creates a thread, optionally starting with messages (input may be nil).
+	CreateThread(input *ThreadInput, opts ...Option) *ThreadOutput
+
+	// CreateMessage adds a message to a thread.
+	CreateMessage(threadId string, input *MessageInput, opts ...Option) *MessageOutput
+
+	// ListMessages lists the messages of a thread, most recent first.
+	ListMessages(threadId string, opts ...Option) *ListMessagesOutput
+
+	// CreateRun runs an assistant on a thread. Poll the run with RetrieveRun until it is finished
+	// (see RunInfo.IsFinished), then read the assistant's replies with ListMessages.
+	CreateRun(threadId string, input *RunInput, opts ...Option) *RunOutput
+
+	// RetrieveRun retrieves a run of a thread by its id.
+	RetrieveRun(threadId, runId string, opts ...Option) *RunOutput
+
+	// Ping checks connectivity and credentials with a lightweight authenticated request, e.g. as a startup or
+	// readiness check. nil is returned on success; otherwise, the error wraps ErrUnauthorized if the credentials are
+	// rejected, or else the network error or the APIError. A nil ctx is treated as context.Background().
+	Ping(ctx context.Context) error
+
+	// RawRequest sends a request to an arbitrary API endpoint (e.g. one not wrapped by this library yet), with the
+	// client's credentials and base url (and, for Azure OpenAI, api-version), and unmarshals the JSON response into
+	// out (if not nil). path is relative to the API root, e.g. "/vector_stores"; body (if not nil) is sent as JSON.
+	// On a non-2xx response, the returned error is the *APIError of the response body if any.
+	RawRequest(ctx context.Context, method, path string, body, out interface{}, opts ...Option) error
+
+	// Close releases the client's idle connections. It is a no-op if the http.Client is supplied via OptHttpClient,
+	// whose connections are owned by the caller. The client remains usable: connections are re-opened as needed.
+	//
+	// The embeddings cache (see OptEmbeddingsCache) is flushed if it supports it (e.g. FileEmbeddingsStore), but not
+	// closed: the store is owned by the caller.
+	Close() error
 }
 
 const (
 	// OptAzureResourceName specifies the Azure OpenAI's resource-name.
 	OptAzureResourceName = "azure-resource-name"
-	// OptAzureApiVersion specifies the version of Azure OpenAI to use (default "2023-03-15-preview").
+	// OptAzureApiVersion specifies the version of Azure OpenAI to use (default DefaultAzureApiVersion), in the
+	// "YYYY-MM-DD" or "YYYY-MM-DD-preview" format. It can also be a per-call setting (see Client), e.g. for a feature
+	// only available in a preview version; invalid per-call versions are ignored.
 	OptAzureApiVersion = "azure-api-version"
 	// OptAzureApiKey specifies the API key used to call Azure OpenAI APIs.
 	OptAzureApiKey = "azure-api-key"
+	// OptAzureFailoverResources specifies secondary Azure OpenAI resources ([]AzureResource, or []string/comma-separated
+	// resource names sharing the primary API key). Requests failing against the primary resource with a transient
+	// error (connection error, 429, 500, 502, 503 or 504, as for OptMaxRetries) are retried against each secondary
+	// resource in order. All resources must have the same model deployment names.
+	OptAzureFailoverResources = "azure-failover-resources"
+	// OptAzureADToken specifies a static Azure AD (Microsoft Entra ID) access token used to call Azure OpenAI APIs,
+	// via the "Authorization: Bearer" header, instead of an API key. Tokens expire: prefer OptAzureTokenProvider.
+	OptAzureADToken = "azure-ad-token"
+	// OptAzureTokenProvider specifies an AzureTokenProvider (or a func() (string, error)) supplying the Azure AD access
+	// token of each request, instead of an API key. It takes precedence over OptAzureADToken and OptAzureApiKey.
+	OptAzureTokenProvider = "azure-token-provider"
+	// OptAzureDeployments specifies a map[string]string mapping model names (e.g. "gpt-3.5-turbo") to Azure OpenAI
+	// deployment names (e.g. "gpt-35-turbo"), so that inputs can use the same model names for both flavors.
+	// Models not in the map are used verbatim as deployment names.
+	OptAzureDeployments = "azure-deployments"
+	// OptAzureBaseUrl specifies the scheme and host of Azure OpenAI endpoints (default
+	// "https://{azure-resource-name}.openai.azure.com"), e.g. for sovereign clouds ("https://my-resource.openai.azure.us")
+	// or private endpoints. The "{azure-resource-name}" placeholder, if present, is replaced by the resource name, so
+	// that failover resources (see OptAzureFailoverResources) get their own endpoints. If this setting is specified,
+	// OptAzureResourceName is optional.
+	OptAzureBaseUrl = "azure-base-url"
 
 	// OptOpenAIApiKey specifies the API key used to call OpenAI APIs.
 	OptOpenAIApiKey = "openai-api-key"
-	// OptOpenAIApiKey specifies the OpenAI's organization name.
+	// OptOpenAIApiKey specifies the OpenAI's organization name. It can also be a per-call setting (see Client).
 	OptOpenAIOrganization = "openai-organization"
+	// OptOpenAIProject specifies the OpenAI's project id (sent as the "OpenAI-Project" header), independently of the
+	// organization. It can also be a per-call setting (see Client).
+	OptOpenAIProject = "openai-project"
 	// OptOpenAIBaseUrl specifies the custom base url for OpenAI APIs (for example "http://localhost:5123").
 	OptOpenAIBaseUrl = "openai-base-url"
+
+	// OptTimeout specifies the timeout of API calls, as a time.Duration or a number of seconds (default 60 seconds).
+	// This is a whole-request timeout, covering connection, writing the request and reading the response
+	// (including the whole event stream of streamed calls).
+	OptTimeout = "timeout"
+	// OptHttpClient specifies the *http.Client used to make API calls, e.g. to configure proxies or connection pooling.
+	// The client is copied (its Transport is shared): wrapping it with client-wide policies does not affect the supplied
+	// instance. OptTimeout, if specified, overrides the client's Timeout.
+	OptHttpClient = "http-client"
+
+	// OptHeaders specifies extra HTTP headers (http.Header or map[string]string) sent with every request, e.g. headers
+	// required by proxies or gateways ("HTTP-Referer", "X-Title"...). Authentication headers are never overwritten.
+	// As a per-call setting (see Client), the headers are added to the client's ones.
+	OptHeaders = "headers"
+
+	// OptMaxRetries specifies how many times an API call failing with a transient error (connection error, 429, 500,
+	// 502, 503 or 504) is retried (default 0: no retry). Other errors are never retried. Calls creating server-side
+	// objects or starting jobs (e.g. CreateBatch, UploadFile or CreateRun) are retried on 429 only, as they could
+	// otherwise be processed twice.
+	OptMaxRetries = "max-retries"
+	// OptRetryBaseDelay specifies the base delay of the exponential backoff between retries, as a time.Duration or a
+	// number of seconds (default 1 second). The delay doubles after each attempt and is randomized with jitter;
+	// the delay requested by the server via the Retry-After (or "retry-after-ms") header takes precedence, and fallback
+	// to backoff applies only when the header is absent.
+	OptRetryBaseDelay = "retry-base-delay"
+	// OptContext specifies, as a per-call setting (see Client), a context.Context bounding the call: once the context
+	// is done, the in-flight request is aborted, the call is not retried anymore and the response of the last attempt
+	// is returned.
+	OptContext = "context"
+
+	// OptDefaultMaxTokens specifies the max_tokens value injected into prompts not setting MaxTokens (default 0: the
+	// max_tokens field is omitted and the API's default applies, e.g. the model's maximum for chat-completions, or 16
+	// for completions).
+	OptDefaultMaxTokens = "default-max-tokens"
+
+	// OptDefaultModel specifies the model used by prompts (see PromptInput and ChatPromptInput) not setting Model.
+	// For Azure OpenAI, supply the model name mapped by OptAzureDeployments or the deployment name.
+	OptDefaultModel = "default-model"
+	// OptDefaultEmbeddingsModel specifies the model used by embeddings inputs not setting Model.
+	OptDefaultEmbeddingsModel = "default-embeddings-model"
+
+	// OptEmbeddingsCache specifies an EmbeddingsStore used to cache embeddings vectors.
+	OptEmbeddingsCache = "embeddings-cache"
+	// OptRequestSigner specifies a RequestSigner invoked just before each request is sent.
+	OptRequestSigner = "request-signer"
+
+	// OptLogger specifies a Logger invoked after each request sent to the API, with credentials redacted.
+	OptLogger = "logger"
+
+	// OptRateLimiter specifies a RateLimiter pacing API calls; the same instance can be shared by multiple clients.
+	OptRateLimiter = "rate-limiter"
+
+	// OptPromptCompressor specifies a PromptCompressor transforming chat messages before they are sent.
+	OptPromptCompressor = "prompt-compressor"
+
+	// OptRecorder specifies a FineTuningRecorder capturing chat-completions prompt/response pairs.
+	OptRecorder = "recorder"
+
+	// OptNormalizeEmbeddings, if true, normalizes returned embeddings vectors to unit length (default false).
+	OptNormalizeEmbeddings = "normalize-embeddings"
+
+	// OptValidateContextWindow, if true, counts the prompt tokens of (chat-)completions calls before sending them and
+	// fails the calls locally with ErrContextWindowExceeded if the prompt tokens plus max_tokens (or
+	// max_completion_tokens) exceed the model's context window (default false). Models with unknown context window are
+	// not checked. Prompt tokens are counted by the tokenizer, hence the check is approximate for models not supported
+	// by CountChatTokens (and tools/images are not counted).
+	OptValidateContextWindow = "validate-context-window"
+
+	// OptValidateJsonSchema, if true, validates the replies of chat-completions calls using structured outputs (see
+	// ResponseFormatJsonSchema) against the supplied JSON schema, setting the output's Error (wrapping
+	// ErrSchemaViolation) if a reply does not conform (default false). See ValidateJsonSchema for the supported
+	// schema keywords. Refused replies (see ChatMessage.Refusal) and streamed calls are not validated.
+	OptValidateJsonSchema = "validate-json-schema"
+
+	// OptStrictDecoding, if true, makes API calls fail when responses contain fields not modeled by the output structs (default false).
+	OptStrictDecoding = "strict-decoding"
+
+	// OptExposeRawResponse, if true, retains the underlying HTTP response in the outputs' RawResponse field (default false).
+	OptExposeRawResponse = "expose-raw-response"
+
+	// OptStrictOptions, if true, makes client initialization fail when unknown settings are supplied (default false).
+	OptStrictOptions = "strict-options"
 )
 
+// knownOptions lists all settings recognized by clients, used by OptStrictOptions.
+var knownOptions = []string{
+	OptAzureResourceName,
+	OptAzureApiVersion,
+	OptAzureApiKey,
+	OptAzureFailoverResources,
+	OptAzureADToken,
+	OptAzureTokenProvider,
+	OptAzureDeployments,
+	OptAzureBaseUrl,
+	OptOpenAIApiKey,
+	OptOpenAIOrganization,
+	OptOpenAIProject,
+	OptOpenAIBaseUrl,
+	OptTimeout,
+	OptHttpClient,
+	OptHeaders,
+	OptMaxRetries,
+	OptRetryBaseDelay,
+	OptContext,
+	OptDefaultMaxTokens,
+	OptDefaultModel,
+	OptDefaultEmbeddingsModel,
+	OptEmbeddingsCache,
+	OptRequestSigner,
+	OptLogger,
+	OptRateLimiter,
+	OptPromptCompressor,
+	OptRecorder,
+	OptNormalizeEmbeddings,
+	OptValidateContextWindow,
+	OptValidateJsonSchema,
+	OptStrictDecoding,
+	OptExposeRawResponse,
+	OptStrictOptions,
+}
+
 type BaseClient struct {
-	gjrc *gjrc.Gjrc
-	opts OptionList
+	httpClient       *http.Client
+	ownedTransport   *http.Transport // nil if the http.Client is supplied via OptHttpClient
+	gjrc             *gjrc.Gjrc
+	opts             OptionList
+	embeddingsCache  EmbeddingsStore
+	rateLimiter      *RateLimiter
+	promptCompressor PromptCompressor
+	recorder         *FineTuningRecorder
+
+	normalizeEmbeddings    bool
+	strictDecoding         bool
+	validateContextWindow  bool
+	validateJsonSchema     bool
+	exposeRawResponse      bool
+	maxRetries             int
+	retryBaseDelay         time.Duration
+	defaultMaxTokens       int
+	defaultModel           string
+	defaultEmbeddingsModel string
+	headers                http.Header
+	ctx                    context.Context // per-call context, see OptContext
+}
+
+// Close implements Client.Close
+func (bc *BaseClient) Close() error {
+	if bc.ownedTransport != nil {
+		bc.ownedTransport.CloseIdleConnections()
+	}
+	if store, ok := bc.embeddingsCache.(interface{ Flush() error }); ok {
+		return store.Flush()
+	}
+	return nil
+}
+
+// parseHeaders converts a setting value (http.Header or map[string]string) to http.Header.
+func parseHeaders(key string, v interface{}) (http.Header, error) {
+	switch h := v.(type) {
+	case http.Header:
+		return h, nil
+	case map[string]string:
+		header := make(http.Header, len(h))
+		for k, value := range h {
+			header.Set(k, value)
+		}
+		return header, nil
+	}
+	return nil, fmt.Errorf("cannot parse setting <%s>: expected http.Header or map[string]string but received %T", key, v)
+}
+
+// withCallOptions returns a copy of this client applying the per-call settings (see Client), or this client itself
+// if there is none. Invalid per-call settings are ignored.
+func (bc *BaseClient) withCallOptions(opts []Option) *BaseClient {
+	if len(opts) == 0 {
+		return bc
+	}
+	var optList OptionList = opts
+	clone := *bc
+	timeout, err := optList.GetDuration(OptTimeout)
+	hasTimeout := err == nil && timeout > 0
+	v, _ := optList.Get(OptContext)
+	ctx, hasContext := v.(context.Context)
+	hasContext = hasContext && ctx != nil
+	if hasTimeout || hasContext {
+		httpClient := *bc.httpClient
+		if hasTimeout {
+			httpClient.Timeout = timeout
+		}
+		if hasContext {
+			// in-flight requests are aborted once the call's context is done
+			httpClient.Transport = &contextTransport{base: httpClient.Transport, ctx: ctx}
+			clone.ctx = ctx
+		}
+		clone.httpClient = &httpClient
+		clone.gjrc = gjrc.NewGjrc(clone.httpClient, 0)
+	}
+	if v, err := optList.Get(OptHeaders); err == nil && v != nil {
+		if header, err := parseHeaders(OptHeaders, v); err == nil {
+			clone.headers = bc.headers.Clone()
+			if clone.headers == nil {
+				clone.headers = make(http.Header, len(header))
+			}
+			for k := range header {
+				clone.headers[http.CanonicalHeaderKey(k)] = header.Values(k)
+			}
+		}
+	}
+	if maxRetries, err := optList.GetInt(OptMaxRetries); err == nil && maxRetries >= 0 {
+		clone.maxRetries = maxRetries
+	}
+	return &clone
+}
+
+// newRequestHeader creates the header of a request, pre-populated with the extra headers (see OptHeaders).
+func (bc *BaseClient) newRequestHeader() http.Header {
+	if bc.headers == nil {
+		return http.Header{}
+	}
+	return bc.headers.Clone()
+}
+
+// init parses settings common to all client flavors.
+func (bc *BaseClient) init() error {
+	if strict, err := bc.opts.GetBool(OptStrictOptions); err == nil && strict {
+		if err = bc.validateOptions(); err != nil {
+			return err
+		}
+	}
+	if v, err := bc.opts.Get(OptHttpClient); err == nil && v != nil {
+		if _, ok := v.(*http.Client); !ok {
+			return fmt.Errorf("cannot parse setting <%s>: expected *http.Client but received %T", OptHttpClient, v)
+		}
+	}
+	if v, err := bc.opts.Get(OptHeaders); err == nil && v != nil {
+		header, err := parseHeaders(OptHeaders, v)
+		if err != nil {
+			return err
+		}
+		bc.headers = header.Clone()
+	}
+	if v, err := bc.opts.Get(OptEmbeddingsCache); err == nil && v != nil {
+		store, ok := v.(EmbeddingsStore)
+		if !ok {
+			return fmt.Errorf("cannot parse setting <%s>: expected EmbeddingsStore but received %T", OptEmbeddingsCache, v)
+		}
+		bc.embeddingsCache = store
+	}
+	if v, err := bc.opts.Get(OptRateLimiter); err == nil && v != nil {
+		limiter, ok := v.(*RateLimiter)
+		if !ok {
+			return fmt.Errorf("cannot parse setting <%s>: expected *RateLimiter but received %T", OptRateLimiter, v)
+		}
+		bc.rateLimiter = limiter
+	}
+	if v, err := bc.opts.Get(OptPromptCompressor); err == nil && v != nil {
+		switch f := v.(type) {
+		case PromptCompressor:
+			bc.promptCompressor = f
+		case func([]ChatMessage) []ChatMessage:
+			bc.promptCompressor = f
+		default:
+			return fmt.Errorf("cannot parse setting <%s>: expected PromptCompressor but received %T", OptPromptCompressor, v)
+		}
+	}
+	if v, err := bc.opts.Get(OptRecorder); err == nil && v != nil {
+		recorder, ok := v.(*FineTuningRecorder)
+		if !ok {
+			return fmt.Errorf("cannot parse setting <%s>: expected *FineTuningRecorder but received %T", OptRecorder, v)
+		}
+		bc.recorder = recorder
+	}
+	if bc.maxRetries, _ = bc.opts.GetInt(OptMaxRetries); bc.maxRetries < 0 {
+		bc.maxRetries = 0
+	}
+	if delay, err := bc.opts.GetDuration(OptRetryBaseDelay); err == nil && delay > 0 {
+		bc.retryBaseDelay = delay
+	} else {
+		bc.retryBaseDelay = defaultRetryBaseDelay
+	}
+	if bc.defaultMaxTokens, _ = bc.opts.GetInt(OptDefaultMaxTokens); bc.defaultMaxTokens < 0 {
+		bc.defaultMaxTokens = 0
+	}
+	bc.defaultModel, _ = bc.opts.GetString(OptDefaultModel)
+	bc.defaultEmbeddingsModel, _ = bc.opts.GetString(OptDefaultEmbeddingsModel)
+	bc.normalizeEmbeddings, _ = bc.opts.GetBool(OptNormalizeEmbeddings)
+	bc.strictDecoding, _ = bc.opts.GetBool(OptStrictDecoding)
+	bc.validateContextWindow, _ = bc.opts.GetBool(OptValidateContextWindow)
+	bc.validateJsonSchema, _ = bc.opts.GetBool(OptValidateJsonSchema)
+	bc.exposeRawResponse, _ = bc.opts.GetBool(OptExposeRawResponse)
+	// innermost, so that the other transports (e.g. the logger) see decompressed responses
+	bc.httpClient.Transport = &decompressingTransport{base: bc.httpClient.Transport}
+	if v, err := bc.opts.Get(OptLogger); err == nil && v != nil {
+		var logger Logger
+		switch f := v.(type) {
+		case Logger:
+			logger = f
+		case func(RequestInfo, ResponseInfo):
+			logger = f
+		default:
+			return fmt.Errorf("cannot parse setting <%s>: expected Logger but received %T", OptLogger, v)
+		}
+		// installed before the signer and the token provider, so that the logged requests carry their headers
+		bc.httpClient.Transport = &loggingTransport{base: bc.httpClient.Transport, logger: logger}
+	}
+	if v, err := bc.opts.Get(OptRequestSigner); err == nil && v != nil {
+		var signer RequestSigner
+		switch f := v.(type) {
+		case RequestSigner:
+			signer = f
+		case func(*http.Request, []byte) error:
+			signer = f
+		default:
+			return fmt.Errorf("cannot parse setting <%s>: expected RequestSigner but received %T", OptRequestSigner, v)
+		}
+		bc.httpClient.Transport = &signingTransport{base: bc.httpClient.Transport, signer: signer}
+	}
+	// tracks response bodies, so that responses can be inspected once gjrc has read them (see awaitResponse)
+	bc.httpClient.Transport = &trackingTransport{base: bc.httpClient.Transport}
+	return nil
+}
+
+// validateOptions returns an error listing all supplied settings that are not recognized.
+func (bc *BaseClient) validateOptions() error {
+	known := make(map[string]bool, len(knownOptions))
+	for _, key := range knownOptions {
+		known[key] = true
+	}
+	unknown := make([]string, 0)
+	for _, o := range bc.opts {
+		if !known[o.Key] {
+			unknown = append(unknown, "<"+o.Key+">")
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown setting(s) %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// prepareSampling normalizes the temperature and top_p sampling parameters.
+//
+// Zero is a valid temperature (greedy sampling) and is kept as-is, whereas an unset (zero) top_p falls back to 1.0.
+// Otherwise, values are kept as-is (both parameters can be set together) and range-checked by validateSampling.
+func prepareSampling(temperature, topP float64) (float64, float64) {
+	if topP == 0.0 {
+		topP = 1.0
+	}
+	return temperature, topP
+}
+
+// validateSampling returns an error if temperature is outside [0.0, 2.0] or top_p is outside [0.0, 1.0], the ranges
+// accepted by the API (like penalties, see validatePenalties).
+func validateSampling(temperature, topP float64) error {
+	if temperature < 0.0 || temperature > 2.0 {
+		return fmt.Errorf("%w: temperature must be within [0.0, 2.0] but received %v", ErrInvalidParameter, temperature)
+	}
+	if topP < 0.0 || topP > 1.0 {
+		return fmt.Errorf("%w: top_p must be within [0.0, 1.0] but received %v", ErrInvalidParameter, topP)
+	}
+	return nil
+}
+
+// validatePenalties returns an error if presence_penalty or frequency_penalty is outside [-2.0, 2.0], the range
+// accepted by the API (out-of-range values are not clamped, as they are likely typos).
+func validatePenalties(presencePenalty, frequencyPenalty float64) error {
+	if presencePenalty < -2.0 || presencePenalty > 2.0 {
+		return fmt.Errorf("%w: presence_penalty must be within [-2.0, 2.0] but received %v", ErrInvalidParameter, presencePenalty)
+	}
+	if frequencyPenalty < -2.0 || frequencyPenalty > 2.0 {
+		return fmt.Errorf("%w: frequency_penalty must be within [-2.0, 2.0] but received %v", ErrInvalidParameter, frequencyPenalty)
+	}
+	return nil
+}
+
+// validatePrompt returns an error if a completions prompt is rejected before being sent (see validateSampling,
+// validatePenalties and OptValidateContextWindow).
+func (bc *BaseClient) validatePrompt(prompt *PromptInput) error {
+	if err := validateSampling(prompt.Temperature, prompt.TopP); err != nil {
+		return err
+	}
+	if err := validatePenalties(prompt.PresencePenalty, prompt.FrequencyPenalty); err != nil {
+		return err
+	}
+	if !bc.validateContextWindow {
+		return nil
+	}
+	prompts := prompt.Prompts
+	if len(prompts) == 0 {
+		prompts = []string{prompt.Prompt}
+	}
+	return checkContextWindow(prompt.Model, prompt.MaxTokens, func() int {
+		promptTokens := 0
+		for _, p := range prompts {
+			n := CountTokens(p, Option{Key: "model", Value: prompt.Model})
+			if n < 0 {
+				return -1
+			}
+			if n > promptTokens {
+				promptTokens = n
+			}
+		}
+		return promptTokens
+	})
 }
 
+// validateChatPrompt returns an error if a chat-completions prompt is rejected before being sent (see
+// validateSampling, validatePenalties and OptValidateContextWindow).
+func (bc *BaseClient) validateChatPrompt(prompt *ChatPromptInput) error {
+	if err := validateSampling(prompt.Temperature, prompt.TopP); err != nil {
+		return err
+	}
+	if err := validatePenalties(prompt.PresencePenalty, prompt.FrequencyPenalty); err != nil {
+		return err
+	}
+	if !bc.validateContextWindow {
+		return nil
+	}
+	maxTokens := prompt.MaxCompletionTokens
+	if maxTokens <= 0 {
+		maxTokens = prompt.MaxTokens
+	}
+	return checkContextWindow(prompt.Model, maxTokens, func() int {
+		return CountChatTokens(prompt.Messages, Option{Key: "model", Value: prompt.Model})
+	})
+}
+
+// preparePrompt returns a copy of the prompt with the client's defaults applied, the caller's prompt is not modified.
 func (bc *BaseClient) preparePrompt(prompt *PromptInput) *PromptInput {
+	prompt = prompt.Clone()
+	if prompt.Model == "" {
+		prompt.Model = bc.defaultModel
+	}
 	if prompt.MaxTokens <= 0 {
-		prompt.MaxTokens = 100
+		prompt.MaxTokens = bc.defaultMaxTokens
 	}
 	if prompt.N < 1 {
 		prompt.N = 1
@@ -335,168 +1376,436 @@ func (bc *BaseClient) preparePrompt(prompt *PromptInput) *PromptInput {
 		prompt.BestOf = prompt.N
 	}
 
-	if 0.0 == prompt.Temperature && 0.0 == prompt.TopP {
-		prompt.Temperature = 1.0
-		prompt.TopP = 1.0
+	prompt.Temperature, prompt.TopP = prepareSampling(prompt.Temperature, prompt.TopP)
+
+	return prompt
+}
+
+// prepareChatPrompt returns a copy of the prompt with the client's defaults applied, the caller's prompt is not
+// modified (hence a prompt can be shared by concurrent calls).
+func (bc *BaseClient) prepareChatPrompt(prompt *ChatPromptInput) *ChatPromptInput {
+	prompt = prompt.Clone()
+	if prompt.Model == "" {
+		prompt.Model = bc.defaultModel
 	}
-	if prompt.Temperature < 0.0 || prompt.Temperature > 1.0 {
-		prompt.Temperature = 1.0
+	if bc.promptCompressor != nil {
+		prompt.Messages = bc.promptCompressor(prompt.Messages)
 	}
-	if prompt.TopP < 0.0 || prompt.TopP > 1.0 {
-		prompt.TopP = 1.0
+	if IsReasoningModel(prompt.Model) {
+		// reasoning models reject max_tokens, and any temperature/top_p but 1
+		if prompt.MaxCompletionTokens <= 0 {
+			prompt.MaxCompletionTokens = prompt.MaxTokens
+		}
+		prompt.MaxTokens = 0
+		if prompt.MaxCompletionTokens <= 0 {
+			prompt.MaxCompletionTokens = bc.defaultMaxTokens
+		}
+		prompt.Temperature, prompt.TopP = 1.0, 1.0
+	} else if prompt.MaxTokens <= 0 && prompt.MaxCompletionTokens <= 0 {
+		prompt.MaxTokens = bc.defaultMaxTokens
 	}
-	if 0.0 < prompt.Temperature && prompt.Temperature < 1.0 {
-		prompt.TopP = 1.0
+	if prompt.N < 1 {
+		prompt.N = 1
 	}
-	if 0.0 < prompt.TopP && prompt.TopP < 1.0 {
-		prompt.Temperature = 1.0
+	if prompt.TopLogprobs > 0 {
+		prompt.Logprobs = true
 	}
 
+	prompt.Temperature, prompt.TopP = prepareSampling(prompt.Temperature, prompt.TopP)
+
 	return prompt
 }
 
-func (bc *BaseClient) prepareChatPrompt(prompt *ChatPromptInput) *ChatPromptInput {
-	if prompt.MaxTokens <= 0 {
-		prompt.MaxTokens = 100
-	}
-	if prompt.N < 1 {
-		prompt.N = 1
+// prepareEmbeddingsInput returns the input to send, with the default model (see OptDefaultEmbeddingsModel) if the input
+// does not set Model. The supplied input is not modified.
+func (bc *BaseClient) prepareEmbeddingsInput(input *EmbeddingsInput) *EmbeddingsInput {
+	if input.Model == "" && bc.defaultEmbeddingsModel != "" {
+		clone := *input
+		clone.Model = bc.defaultEmbeddingsModel
+		return &clone
 	}
+	return input
+}
 
-	if 0.0 == prompt.Temperature && 0.0 == prompt.TopP {
-		prompt.Temperature = 1.0
-		prompt.TopP = 1.0
+// postJson sends a JSON POST request, applying client-wide policies such as rate limiting and retries.
+//
+// Transient failures are retried up to OptMaxRetries times; the response of the last attempt is returned.
+func (bc *BaseClient) postJson(apiUrl string, header http.Header, body interface{}) *gjrc.GjrcResponse {
+	return bc.sendWithRetries(body, isRetryableResponse, func() *gjrc.GjrcResponse {
+		return bc.gjrc.PostJson(apiUrl, body, gjrc.RequestMeta{Header: header})
+	})
+}
+
+// postJsonOnce is the form of postJson for non-idempotent requests (e.g. creating a server-side object or starting a
+// job): only rate-limited attempts are retried (see isRateLimitedResponse), so that the request is processed at most
+// once.
+func (bc *BaseClient) postJsonOnce(apiUrl string, header http.Header, body interface{}) *gjrc.GjrcResponse {
+	return bc.sendWithRetries(body, isRateLimitedResponse, func() *gjrc.GjrcResponse {
+		return bc.gjrc.PostJson(apiUrl, body, gjrc.RequestMeta{Header: header})
+	})
+}
+
+// unmarshalResponse parses the JSON-encoded response's body and puts the result to v.
+//
+// If OptStrictDecoding is enabled, successful responses containing fields not modeled by v are rejected.
+func (bc *BaseClient) unmarshalResponse(resp *gjrc.GjrcResponse, v interface{}) error {
+	if !bc.strictDecoding || resp.StatusCode() >= 300 {
+		return resp.Unmarshal(v)
 	}
-	if prompt.Temperature < 0.0 || prompt.Temperature > 1.0 {
-		prompt.Temperature = 1.0
+	body, err := resp.Body()
+	if err != nil {
+		return err
 	}
-	if prompt.TopP < 0.0 || prompt.TopP > 1.0 {
-		prompt.TopP = 1.0
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(v); err != nil {
+		return fmt.Errorf("cannot decode response (strict mode): %w", err)
 	}
-	if 0.0 < prompt.Temperature && prompt.Temperature < 1.0 {
-		prompt.TopP = 1.0
+	return nil
+}
+
+// responseError returns the error (if any) caused by performing the request.
+//
+// Response bodies that are not JSON (e.g. plain-text transcripts or HTML error pages from proxies) are not considered
+// errors at this level.
+func responseError(resp *gjrc.GjrcResponse) error {
+	if resp.HttpResponse() == nil {
+		return resp.Error()
 	}
-	if 0.0 < prompt.TopP && prompt.TopP < 1.0 {
-		prompt.Temperature = 1.0
+	_, err := resp.Body()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil
 	}
+	return err
+}
 
-	return prompt
+// requestId returns the id of a request from the response headers (see BaseResponse.RequestId).
+func requestId(header http.Header) string {
+	if id := header.Get("X-Request-Id"); id != "" {
+		return id
+	}
+	return header.Get("Apim-Request-Id")
+}
+
+func (bc *BaseClient) buildBaseResponse(resp *gjrc.GjrcResponse) BaseResponse {
+	base := BaseResponse{Error: responseError(resp)}
+	if resp.HttpResponse() != nil {
+		base.StatusCode = resp.StatusCode()
+		base.Headers = resp.HttpResponse().Header
+		base.RequestId = requestId(base.Headers)
+	}
+	if base.Error == nil && base.StatusCode >= 400 {
+		if apiErr := parseAPIError(resp); apiErr != nil {
+			base.Error = apiErr
+		}
+	}
+	if bc.exposeRawResponse {
+		base.RawResponse = resp
+	}
+	return base
 }
 
-func (bc *BaseClient) buildCompletionsOutput(resp *gjrc.GjrcResponse) *CompletionsOutput {
-	completions := &CompletionsOutput{BaseResponse: BaseResponse{Error: resp.Error()}}
+func (bc *BaseClient) buildCompletionsOutput(resp *gjrc.GjrcResponse, modelRequested string) *CompletionsOutput {
+	completions := &CompletionsOutput{BaseResponse: bc.buildBaseResponse(resp), ModelRequested: modelRequested}
 	if completions.Error == nil {
-		err := resp.Unmarshal(completions)
+		err := bc.unmarshalResponse(resp, completions)
 		completions.Error = err
 	}
-	completions.StatusCode = resp.StatusCode()
 	return completions
 }
 
-func (bc *BaseClient) buildChatCompletionsOutput(resp *gjrc.GjrcResponse) *ChatCompletionsOutput {
-	completions := &ChatCompletionsOutput{BaseResponse: BaseResponse{Error: resp.Error()}}
+func (bc *BaseClient) buildChatCompletionsOutput(resp *gjrc.GjrcResponse, modelRequested string) *ChatCompletionsOutput {
+	completions := &ChatCompletionsOutput{BaseResponse: bc.buildBaseResponse(resp), ModelRequested: modelRequested}
 	if completions.Error == nil {
-		err := resp.Unmarshal(completions)
+		err := bc.unmarshalResponse(resp, completions)
 		completions.Error = err
+		sort.SliceStable(completions.Choices, func(i, j int) bool {
+			return completions.Choices[i].Index < completions.Choices[j].Index
+		})
 	}
-	completions.StatusCode = resp.StatusCode()
 	return completions
 }
 
-func (bc *BaseClient) buildEmbeddingsOutput(resp *gjrc.GjrcResponse) *EmbeddingsOutput {
-	embeddings := &EmbeddingsOutput{BaseResponse: BaseResponse{Error: resp.Error()}}
+func (bc *BaseClient) buildEmbeddingsOutput(resp *gjrc.GjrcResponse, modelRequested string) *EmbeddingsOutput {
+	embeddings := &EmbeddingsOutput{BaseResponse: bc.buildBaseResponse(resp), ModelRequested: modelRequested}
 	if embeddings.Error == nil {
-		err := resp.Unmarshal(embeddings)
+		err := bc.unmarshalResponse(resp, embeddings)
 		embeddings.Error = err
 	}
-	embeddings.StatusCode = resp.StatusCode()
+	return embeddings
+}
+
+// normalizeEmbeddingsOutput normalizes the vectors of an embeddings output if OptNormalizeEmbeddings is set. It is
+// applied after the embeddings cache, which always stores vectors as returned by the API.
+func (bc *BaseClient) normalizeEmbeddingsOutput(embeddings *EmbeddingsOutput) *EmbeddingsOutput {
+	if bc.normalizeEmbeddings {
+		for i := range embeddings.Data {
+			embeddings.Data[i].Embedding.NormalizeInPlace()
+		}
+	}
 	return embeddings
 }
 
 /*----------------------------------------------------------------------*/
 
+// DefaultAzureApiVersion is the Azure OpenAI api-version used if OptAzureApiVersion is not specified: the latest GA
+// version at the time of release, supporting tools, JSON mode and structured outputs.
+const DefaultAzureApiVersion = "2024-10-21"
+
+// reAzureApiVersion matches valid Azure OpenAI api-versions, e.g. "2024-10-21" or "2025-01-01-preview".
+var reAzureApiVersion = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(-preview)?$`)
+
 // AzureOpenAIClient is AzureOpenAI-flavor of Client.
 type AzureOpenAIClient struct {
 	*BaseClient
 	resourceName, apiVersion, apiKey string
+	baseUrl                          string
+	failoverResources                []AzureResource
+	deployments                      map[string]string
+}
+
+// AzureResource identifies an Azure OpenAI resource, used as failover target (see OptAzureFailoverResources).
+type AzureResource struct {
+	// Name is the Azure OpenAI's resource-name.
+	Name string
+	// ApiKey is the API key of the resource (if empty, the primary API key is used).
+	ApiKey string
 }
 
 // Init should be called to initialize the client before any API call.
 func (c *AzureOpenAIClient) Init() error {
-	var err error
+	err := c.BaseClient.init()
+	if err != nil {
+		return err
+	}
 
+	c.baseUrl, _ = c.opts.GetString(OptAzureBaseUrl)
+	c.baseUrl = strings.TrimSuffix(strings.TrimSpace(c.baseUrl), "/")
 	c.resourceName, err = c.opts.GetString(OptAzureResourceName)
-	if err != nil || c.resourceName == "" {
-		return fmt.Errorf("cannot parse setting <%s> %s", OptAzureResourceName, err)
+	if c.baseUrl == "" {
+		if err != nil || c.resourceName == "" {
+			return fmt.Errorf("cannot parse setting <%s> %s", OptAzureResourceName, err)
+		}
+		c.baseUrl = "https://{azure-resource-name}.openai.azure.com"
+	}
+
+	var tokenProvider AzureTokenProvider
+	if v, err := c.opts.Get(OptAzureTokenProvider); err == nil && v != nil {
+		switch provider := v.(type) {
+		case AzureTokenProvider:
+			tokenProvider = provider
+		case func() (string, error):
+			tokenProvider = provider
+		default:
+			return fmt.Errorf("cannot parse setting <%s>: expected AzureTokenProvider but received %T", OptAzureTokenProvider, v)
+		}
+	} else if token, _ := c.opts.GetString(OptAzureADToken); token != "" {
+		tokenProvider = func() (string, error) { return token, nil }
 	}
 
 	c.apiKey, err = c.opts.GetString(OptAzureApiKey)
-	if err != nil || c.apiKey == "" {
+	if tokenProvider != nil {
+		// Azure AD authentication takes precedence over API key
+		c.apiKey = ""
+		c.httpClient.Transport = &bearerTokenTransport{base: c.httpClient.Transport, tokenProvider: tokenProvider}
+	} else if err != nil || c.apiKey == "" {
 		return fmt.Errorf("cannot parse setting <%s> %s", OptAzureApiKey, err)
 	}
 
 	c.apiVersion, err = c.opts.GetString(OptAzureApiVersion)
 	if err != nil || c.apiVersion == "" {
-		c.apiVersion = "2023-03-15-preview"
+		c.apiVersion = DefaultAzureApiVersion
+	} else if !reAzureApiVersion.MatchString(c.apiVersion) {
+		return fmt.Errorf("cannot parse setting <%s>: expected YYYY-MM-DD[-preview] but received %q", OptAzureApiVersion, c.apiVersion)
+	}
+
+	if v, err := c.opts.Get(OptAzureDeployments); err == nil && v != nil {
+		deployments, ok := v.(map[string]string)
+		if !ok {
+			return fmt.Errorf("cannot parse setting <%s>: expected map[string]string but received %T", OptAzureDeployments, v)
+		}
+		c.deployments = deployments
+	}
+
+	if v, err := c.opts.Get(OptAzureFailoverResources); err == nil && v != nil {
+		switch resources := v.(type) {
+		case []AzureResource:
+			c.failoverResources = resources
+		case []string:
+			for _, name := range resources {
+				c.failoverResources = append(c.failoverResources, AzureResource{Name: name})
+			}
+		case string:
+			for _, name := range strings.Split(resources, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					c.failoverResources = append(c.failoverResources, AzureResource{Name: name})
+				}
+			}
+		default:
+			return fmt.Errorf("cannot parse setting <%s>: expected []AzureResource or []string but received %T", OptAzureFailoverResources, v)
+		}
 	}
 
 	return nil
 }
 
 func (c *AzureOpenAIClient) buildRequestHeaders() http.Header {
-	header := http.Header{}
-	header.Set("api-key", c.apiKey)
+	return c.buildRequestHeadersWithKey(c.apiKey)
+}
+
+// forCall returns the client to use for a call supplied with per-call settings (see Client).
+func (c *AzureOpenAIClient) forCall(opts []Option) *AzureOpenAIClient {
+	if len(opts) == 0 {
+		return c
+	}
+	clone := *c
+	clone.BaseClient = c.BaseClient.withCallOptions(opts)
+	var optList OptionList = opts
+	if apiVersion, err := optList.GetString(OptAzureApiVersion); err == nil && reAzureApiVersion.MatchString(apiVersion) {
+		clone.apiVersion = apiVersion
+	}
+	return &clone
+}
+
+func (c *AzureOpenAIClient) buildRequestHeadersWithKey(apiKey string) http.Header {
+	header := c.newRequestHeader()
+	if apiKey != "" {
+		header.Set("api-key", apiKey)
+	} else {
+		// requests are authenticated with an Azure AD token (see bearerTokenTransport)
+		header.Del("api-key")
+	}
 	return header
 }
 
-func (c *AzureOpenAIClient) buildUrlCompletions(prompt *PromptInput) string {
-	url := "https://{azure-resource-name}.openai.azure.com/openai/deployments/{model}/completions?api-version={azure-api-version}"
-	url = strings.ReplaceAll(url, "{azure-resource-name}", c.resourceName)
-	url = strings.ReplaceAll(url, "{model}", prompt.Model)
+// buildBaseUrl returns the scheme and host of the endpoints of a resource (see OptAzureBaseUrl).
+func (c *AzureOpenAIClient) buildBaseUrl(resourceName string) string {
+	return strings.ReplaceAll(c.baseUrl, "{azure-resource-name}", resourceName)
+}
+
+// deploymentName returns the deployment name of a model (see OptAzureDeployments).
+func (c *AzureOpenAIClient) deploymentName(model string) string {
+	if deployment, ok := c.deployments[model]; ok {
+		return deployment
+	}
+	return model
+}
+
+// postJsonWithFailover sends the request to the primary resource, then to each failover resource in order
+// until one responds successfully (see OptAzureFailoverResources). The last response is returned.
+func (c *AzureOpenAIClient) postJsonWithFailover(buildUrl func(resourceName string) string, body interface{}) *gjrc.GjrcResponse {
+	return c.sendWithFailover(buildUrl, func(apiUrl string, header http.Header) *gjrc.GjrcResponse {
+		return c.postJson(apiUrl, header, body)
+	})
+}
+
+// sendWithFailover is the generic form of postJsonWithFailover: send is called with the URL and headers of each
+// resource in turn.
+func (c *AzureOpenAIClient) sendWithFailover(buildUrl func(resourceName string) string, send func(apiUrl string, header http.Header) *gjrc.GjrcResponse) *gjrc.GjrcResponse {
+	resp := send(buildUrl(c.resourceName), c.buildRequestHeaders())
+	for _, resource := range c.failoverResources {
+		// resources are failed over on the same transient errors as retries (see isRetryableResponse)
+		if !isRetryableResponse(resp) {
+			break
+		}
+		apiKey := resource.ApiKey
+		if apiKey == "" {
+			apiKey = c.apiKey
+		}
+		resp = send(buildUrl(resource.Name), c.buildRequestHeadersWithKey(apiKey))
+	}
+	return resp
+}
+
+func (c *AzureOpenAIClient) buildUrlCompletions(resourceName string, prompt *PromptInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/completions?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(prompt.Model))
 	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
 	return url
 }
 
 // Completions implements Client.Completions
-func (c *AzureOpenAIClient) Completions(prompt *PromptInput) *CompletionsOutput {
-	apiUrl := c.buildUrlCompletions(prompt)
-	header := c.buildRequestHeaders()
+func (c *AzureOpenAIClient) Completions(prompt *PromptInput, opts ...Option) *CompletionsOutput {
+	c = c.forCall(opts)
+	prompt = c.preparePrompt(prompt)
+	if err := c.validatePrompt(prompt); err != nil {
+		return &CompletionsOutput{BaseResponse: BaseResponse{Error: err}, ModelRequested: prompt.Model}
+	}
+	resp := c.postJsonWithFailover(func(resourceName string) string {
+		return c.buildUrlCompletions(resourceName, prompt)
+	}, prompt)
+	return c.buildCompletionsOutput(resp, prompt.Model)
+}
+
+// CompletionsStream implements Client.CompletionsStream
+//
+// Note: streamed calls are sent to the primary resource only (no failover).
+func (c *AzureOpenAIClient) CompletionsStream(prompt *PromptInput, opts ...Option) *CompletionsStreamOutput {
+	c = c.forCall(opts)
 	prompt = c.preparePrompt(prompt)
-	resp := c.gjrc.PostJson(apiUrl, prompt, gjrc.RequestMeta{Header: header})
-	return c.buildCompletionsOutput(resp)
+	apiUrl := c.buildUrlCompletions(c.resourceName, prompt)
+	header := c.buildRequestHeaders()
+	return c.streamCompletions(apiUrl, header, prompt)
 }
 
-func (c *AzureOpenAIClient) buildUrlChatCompletions(prompt *ChatPromptInput) string {
-	url := "https://{azure-resource-name}.openai.azure.com/openai/deployments/{model}/chat/completions?api-version={azure-api-version}"
-	url = strings.ReplaceAll(url, "{azure-resource-name}", c.resourceName)
-	url = strings.ReplaceAll(url, "{model}", prompt.Model)
+func (c *AzureOpenAIClient) buildUrlChatCompletions(resourceName string, prompt *ChatPromptInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/chat/completions?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(prompt.Model))
 	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
 	return url
 }
 
 // ChatCompletions implements Client.ChatCompletions
-func (c *AzureOpenAIClient) ChatCompletions(prompt *ChatPromptInput) *ChatCompletionsOutput {
-	apiUrl := c.buildUrlChatCompletions(prompt)
-	header := c.buildRequestHeaders()
+func (c *AzureOpenAIClient) ChatCompletions(prompt *ChatPromptInput, opts ...Option) *ChatCompletionsOutput {
+	c = c.forCall(opts)
 	prompt = c.prepareChatPrompt(prompt)
-	resp := c.gjrc.PostJson(apiUrl, prompt, gjrc.RequestMeta{Header: header})
-	return c.buildChatCompletionsOutput(resp)
+	if err := c.validateChatPrompt(prompt); err != nil {
+		return &ChatCompletionsOutput{BaseResponse: BaseResponse{Error: err}, ModelRequested: prompt.Model}
+	}
+	resp := c.postJsonWithFailover(func(resourceName string) string {
+		return c.buildUrlChatCompletions(resourceName, prompt)
+	}, prompt)
+	return c.recordChatCompletions(prompt, c.checkResponseSchema(prompt, c.buildChatCompletionsOutput(resp, prompt.Model)))
 }
 
-func (c *AzureOpenAIClient) buildUrlEmbeddings(input *EmbeddingsInput) string {
-	url := "https://{azure-resource-name}.openai.azure.com/openai/deployments/{model}/embeddings?api-version={azure-api-version}"
-	url = strings.ReplaceAll(url, "{azure-resource-name}", c.resourceName)
-	url = strings.ReplaceAll(url, "{model}", input.Model)
+// ChatCompletionsBatch implements Client.ChatCompletionsBatch
+func (c *AzureOpenAIClient) ChatCompletionsBatch(prompts []*ChatPromptInput, opts ...Option) []*ChatCompletionsOutput {
+	return chatCompletionsBatch(c, prompts, opts...)
+}
+
+// ChatCompletionsStream implements Client.ChatCompletionsStream
+//
+// Note: streamed calls are sent to the primary resource only (no failover).
+func (c *AzureOpenAIClient) ChatCompletionsStream(prompt *ChatPromptInput, opts ...Option) *ChatCompletionsStreamOutput {
+	c = c.forCall(opts)
+	prompt = c.prepareChatPrompt(prompt)
+	apiUrl := c.buildUrlChatCompletions(c.resourceName, prompt)
+	header := c.buildRequestHeaders()
+	return c.streamChatCompletions(apiUrl, header, prompt)
+}
+
+func (c *AzureOpenAIClient) buildUrlEmbeddings(resourceName string, input *EmbeddingsInput) string {
+	url := "{azure-base-url}/openai/deployments/{model}/embeddings?api-version={azure-api-version}"
+	url = strings.ReplaceAll(url, "{azure-base-url}", c.buildBaseUrl(resourceName))
+	url = strings.ReplaceAll(url, "{model}", c.deploymentName(input.Model))
 	url = strings.ReplaceAll(url, "{azure-api-version}", c.apiVersion)
 	return url
 }
 
 // Embeddings implements Client.Embeddings
-func (c *AzureOpenAIClient) Embeddings(input *EmbeddingsInput) *EmbeddingsOutput {
-	apiUrl := c.buildUrlEmbeddings(input)
-	header := c.buildRequestHeaders()
-	resp := c.gjrc.PostJson(apiUrl, input, gjrc.RequestMeta{Header: header})
-	return c.buildEmbeddingsOutput(resp)
+func (c *AzureOpenAIClient) Embeddings(input *EmbeddingsInput, opts ...Option) *EmbeddingsOutput {
+	c = c.forCall(opts)
+	input = c.prepareEmbeddingsInput(input)
+	if cached := c.lookupEmbeddingsCache(input); cached != nil {
+		return c.normalizeEmbeddingsOutput(cached)
+	}
+	resp := c.postJsonWithFailover(func(resourceName string) string {
+		return c.buildUrlEmbeddings(resourceName, input)
+	}, input)
+	return c.normalizeEmbeddingsOutput(c.updateEmbeddingsCache(input, c.buildEmbeddingsOutput(resp, input.Model)))
 }
 
 /*----------------------------------------------------------------------*/
@@ -505,11 +1814,15 @@ func (c *AzureOpenAIClient) Embeddings(input *EmbeddingsInput) *EmbeddingsOutput
 type PlatformOpenAIClient struct {
 	*BaseClient
 	apiKey, organization string
+	project              string
 	baseUrl              string
 }
 
 func (c *PlatformOpenAIClient) Init() error {
-	var err error
+	err := c.BaseClient.init()
+	if err != nil {
+		return err
+	}
 
 	c.apiKey, err = c.opts.GetString(OptOpenAIApiKey)
 	if err != nil || c.apiKey == "" {
@@ -517,6 +1830,7 @@ func (c *PlatformOpenAIClient) Init() error {
 	}
 
 	c.organization, _ = c.opts.GetString(OptOpenAIOrganization)
+	c.project, _ = c.opts.GetString(OptOpenAIProject)
 	c.baseUrl, _ = c.opts.GetString(OptOpenAIBaseUrl)
 	c.baseUrl = strings.TrimSuffix(c.baseUrl, "/")
 	if c.baseUrl == "" {
@@ -526,12 +1840,32 @@ func (c *PlatformOpenAIClient) Init() error {
 	return nil
 }
 
+// forCall returns the client to use for a call supplied with per-call settings (see Client).
+func (c *PlatformOpenAIClient) forCall(opts []Option) *PlatformOpenAIClient {
+	if len(opts) == 0 {
+		return c
+	}
+	clone := *c
+	clone.BaseClient = c.BaseClient.withCallOptions(opts)
+	var optList OptionList = opts
+	if organization, err := optList.GetString(OptOpenAIOrganization); err == nil {
+		clone.organization = organization
+	}
+	if project, err := optList.GetString(OptOpenAIProject); err == nil {
+		clone.project = project
+	}
+	return &clone
+}
+
 func (c *PlatformOpenAIClient) buildRequestHeaders() http.Header {
-	header := http.Header{}
+	header := c.newRequestHeader()
 	header.Set("Authorization", "Bearer "+c.apiKey)
 	if c.organization != "" {
 		header.Set("OpenAI-Organization", c.organization)
 	}
+	if c.project != "" {
+		header.Set("OpenAI-Project", c.project)
+	}
 	return header
 }
 
@@ -541,12 +1875,25 @@ func (c *PlatformOpenAIClient) buildUrlCompletions(prompt *PromptInput) string {
 }
 
 // Completions implements Client.Completions
-func (c *PlatformOpenAIClient) Completions(prompt *PromptInput) *CompletionsOutput {
+func (c *PlatformOpenAIClient) Completions(prompt *PromptInput, opts ...Option) *CompletionsOutput {
+	c = c.forCall(opts)
 	apiUrl := c.buildUrlCompletions(prompt)
 	header := c.buildRequestHeaders()
 	prompt = c.preparePrompt(prompt)
-	resp := c.gjrc.PostJson(apiUrl, prompt, gjrc.RequestMeta{Header: header})
-	return c.buildCompletionsOutput(resp)
+	if err := c.validatePrompt(prompt); err != nil {
+		return &CompletionsOutput{BaseResponse: BaseResponse{Error: err}, ModelRequested: prompt.Model}
+	}
+	resp := c.postJson(apiUrl, header, prompt)
+	return c.buildCompletionsOutput(resp, prompt.Model)
+}
+
+// CompletionsStream implements Client.CompletionsStream
+func (c *PlatformOpenAIClient) CompletionsStream(prompt *PromptInput, opts ...Option) *CompletionsStreamOutput {
+	c = c.forCall(opts)
+	prompt = c.preparePrompt(prompt)
+	apiUrl := c.buildUrlCompletions(prompt)
+	header := c.buildRequestHeaders()
+	return c.streamCompletions(apiUrl, header, prompt)
 }
 
 func (c *PlatformOpenAIClient) buildUrlChatCompletions(prompt *ChatPromptInput) string {
@@ -555,12 +1902,30 @@ func (c *PlatformOpenAIClient) buildUrlChatCompletions(prompt *ChatPromptInput)
 }
 
 // ChatCompletions implements Client.ChatCompletions
-func (c *PlatformOpenAIClient) ChatCompletions(prompt *ChatPromptInput) *ChatCompletionsOutput {
+func (c *PlatformOpenAIClient) ChatCompletions(prompt *ChatPromptInput, opts ...Option) *ChatCompletionsOutput {
+	c = c.forCall(opts)
 	apiUrl := c.buildUrlChatCompletions(prompt)
 	header := c.buildRequestHeaders()
 	prompt = c.prepareChatPrompt(prompt)
-	resp := c.gjrc.PostJson(apiUrl, prompt, gjrc.RequestMeta{Header: header})
-	return c.buildChatCompletionsOutput(resp)
+	if err := c.validateChatPrompt(prompt); err != nil {
+		return &ChatCompletionsOutput{BaseResponse: BaseResponse{Error: err}, ModelRequested: prompt.Model}
+	}
+	resp := c.postJson(apiUrl, header, prompt)
+	return c.recordChatCompletions(prompt, c.checkResponseSchema(prompt, c.buildChatCompletionsOutput(resp, prompt.Model)))
+}
+
+// ChatCompletionsBatch implements Client.ChatCompletionsBatch
+func (c *PlatformOpenAIClient) ChatCompletionsBatch(prompts []*ChatPromptInput, opts ...Option) []*ChatCompletionsOutput {
+	return chatCompletionsBatch(c, prompts, opts...)
+}
+
+// ChatCompletionsStream implements Client.ChatCompletionsStream
+func (c *PlatformOpenAIClient) ChatCompletionsStream(prompt *ChatPromptInput, opts ...Option) *ChatCompletionsStreamOutput {
+	c = c.forCall(opts)
+	prompt = c.prepareChatPrompt(prompt)
+	apiUrl := c.buildUrlChatCompletions(prompt)
+	header := c.buildRequestHeaders()
+	return c.streamChatCompletions(apiUrl, header, prompt)
 }
 
 func (c *PlatformOpenAIClient) buildUrlEmbeddings(input *EmbeddingsInput) string {
@@ -569,37 +1934,44 @@ func (c *PlatformOpenAIClient) buildUrlEmbeddings(input *EmbeddingsInput) string
 }
 
 // Embeddings implements Client.Embeddings
-func (c *PlatformOpenAIClient) Embeddings(input *EmbeddingsInput) *EmbeddingsOutput {
+func (c *PlatformOpenAIClient) Embeddings(input *EmbeddingsInput, opts ...Option) *EmbeddingsOutput {
+	c = c.forCall(opts)
+	input = c.prepareEmbeddingsInput(input)
+	if cached := c.lookupEmbeddingsCache(input); cached != nil {
+		return c.normalizeEmbeddingsOutput(cached)
+	}
 	apiUrl := c.buildUrlEmbeddings(input)
 	header := c.buildRequestHeaders()
-	resp := c.gjrc.PostJson(apiUrl, input, gjrc.RequestMeta{Header: header})
-	return c.buildEmbeddingsOutput(resp)
+	resp := c.postJson(apiUrl, header, input)
+	return c.normalizeEmbeddingsOutput(c.updateEmbeddingsCache(input, c.buildEmbeddingsOutput(resp, input.Model)))
 }
 
 /*----------------------------------------------------------------------*/
 
-// CountTokens returnes the number of BPE tokens for an input string. If error, -1 is returned.
+// CountTokens returnes the number of BPE tokens for an input string. If error, -1 is returned (see CountTokensE).
+//
+// The encoding is selected by the "model" option (e.g. o200k_base for gpt-4o, cl100k_base for gpt-4 and gpt-3.5-turbo),
+// or else the "encoding" option (e.g. "p50k_base"), defaulting to cl100k_base.
 func CountTokens(input string, opts ...Option) int {
-	var optList OptionList = opts
-	var enc tokenizer.Codec
-
-	if model, err := optList.GetString("model"); model != "" && err == nil {
-		enc, err = tokenizer.ForModel(tokenizer.Model(model))
+	n, err := CountTokensE(input, opts...)
+	if err != nil {
+		return -1
 	}
-	if enc == nil {
-		if encoding, err := optList.GetString("encoding"); encoding != "" && err == nil {
-			enc, err = tokenizer.Get(tokenizer.Encoding(encoding))
-		}
-	}
-	if enc == nil {
-		enc, _ = tokenizer.Get(tokenizer.P50kBase)
-		if enc == nil {
-			return -1
-		}
+	return n
+}
+
+// CountTokensE is like CountTokens, but returns an error (e.g. ErrEncodingNotFound) instead of -1 on failure.
+func CountTokensE(input string, opts ...Option) (int, error) {
+	enc, err := selectCodec(opts...)
+	if err != nil {
+		return 0, err
 	}
 
-	ids, _, _ := enc.Encode(input)
-	return len(ids)
+	ids, _, err := enc.Encode(input)
+	if err != nil {
+		return 0, fmt.Errorf("cannot encode input with encoding <%s>: %w", enc.GetName(), err)
+	}
+	return len(ids), nil
 }
 
 // EstimateTokens estimates the number of tokes for an input string.
@@ -1,10 +1,32 @@
 package oaiaux
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
+// newTestPlatformClient creates a PlatformOpenAI client pointing to a test server served by handler.
+func newTestPlatformClient(t *testing.T, handler http.HandlerFunc, opts ...Option) (Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	opts = append([]Option{{Key: OptOpenAIApiKey, Value: "test-key"}, {Key: OptOpenAIBaseUrl, Value: server.URL}}, opts...)
+	client, err := NewClient(PlatformOpenAI, opts...)
+	if err != nil {
+		server.Close()
+		t.Fatalf("cannot create client: %s", err)
+	}
+	return client, server
+}
+
 func TestCountTokens(t *testing.T) {
 	testName := "TestCountTokens"
 	testData := []struct {
@@ -33,6 +55,20 @@ func TestCountTokens(t *testing.T) {
 	}
 }
 
+func TestCountTokensE(t *testing.T) {
+	testName := "TestCountTokensE"
+	if n, err := CountTokensE("Hello world", Option{Key: "model", Value: "gpt-4"}); err != nil || n != 2 {
+		t.Fatalf("%s failed: expected 2 but received %#v / %s", testName, n, err)
+	}
+	n, err := CountTokensE("Hello world", Option{Key: "encoding", Value: "no-such-encoding"})
+	if !errors.Is(err, ErrEncodingNotFound) || !strings.Contains(err.Error(), "no-such-encoding") {
+		t.Fatalf("%s failed: expected ErrEncodingNotFound but received %#v / %v", testName, n, err)
+	}
+	if n = CountTokens("Hello world", Option{Key: "encoding", Value: "no-such-encoding"}); n != -1 {
+		t.Fatalf("%s failed: expected -1 but received %#v", testName, n)
+	}
+}
+
 func TestEstimateTokens(t *testing.T) {
 	testName := "TestEstimateTokens"
 	testData := []struct {
@@ -58,3 +94,915 @@ func TestEstimateTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestChatPromptInput_Fingerprint(t *testing.T) {
+	testName := "TestChatPromptInput_Fingerprint"
+	prompt1 := &ChatPromptInput{
+		Model:     "gpt-3.5-turbo",
+		Messages:  []ChatMessage{{Role: "user", Content: "Hello"}},
+		LogitBias: map[string]int{"1234": 10, "5678": -10, "9012": 5},
+	}
+	prompt2 := &ChatPromptInput{
+		Model:     "gpt-3.5-turbo",
+		Messages:  []ChatMessage{{Role: "user", Content: "Hello"}},
+		LogitBias: map[string]int{"9012": 5, "5678": -10, "1234": 10},
+		N:         1,
+		TopP:      1.0,
+	}
+	fp1, fp2 := prompt1.Fingerprint(), prompt2.Fingerprint()
+	if fp1 != fp2 {
+		t.Fatalf("%s failed: expected identical fingerprints but received %#v vs %#v", testName, fp1, fp2)
+	}
+	if prompt1.N != 0 || prompt1.Temperature != 0 {
+		t.Fatalf("%s failed: prompt should not be modified", testName)
+	}
+
+	prompt2.Messages = []ChatMessage{{Role: "user", Content: "Hello!"}}
+	if fp3 := prompt2.Fingerprint(); fp3 == fp1 {
+		t.Fatalf("%s failed: expected different fingerprints for different prompts", testName)
+	}
+}
+
+func TestOptStrictOptions(t *testing.T) {
+	testName := "TestOptStrictOptions"
+	opts := []Option{
+		{Key: OptAzureResourceName, Value: "my-resource"},
+		{Key: OptAzureApiKey, Value: "my-key"},
+		{Key: "azure-api-verison", Value: "2024-02-01"},
+	}
+	if _, err := NewClient(AzureOpenAI, opts...); err != nil {
+		t.Fatalf("%s failed: unknown settings should be ignored by default, but received error %s", testName, err)
+	}
+	opts = append(opts, Option{Key: OptStrictOptions, Value: true})
+	_, err := NewClient(AzureOpenAI, opts...)
+	if err == nil || !strings.Contains(err.Error(), "<azure-api-verison>") {
+		t.Fatalf("%s failed: expected error listing unknown setting but received %#v", testName, err)
+	}
+}
+
+func TestOptNormalizeEmbeddings(t *testing.T) {
+	testName := "TestOptNormalizeEmbeddings"
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[3.0,4.0]}]}`))
+	}
+	input := &EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"}
+	for _, normalize := range []bool{false, true} {
+		client, server := newTestPlatformClient(t, handler, Option{Key: OptNormalizeEmbeddings, Value: normalize})
+		output := client.Embeddings(input)
+		server.Close()
+		if output.Error != nil || len(output.Data) != 1 {
+			t.Fatalf("%s failed: unexpected output %#v", testName, output)
+		}
+		expected := 5.0
+		if normalize {
+			expected = 1.0
+		}
+		if length := output.Data[0].Embedding.Length(); math.Abs(length-expected) > 1e-9 {
+			t.Fatalf("%s failed: expected vector length %#v but received %#v", testName, expected, length)
+		}
+	}
+}
+
+func TestOptStrictDecoding(t *testing.T) {
+	testName := "TestOptStrictDecoding"
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}],"unknown_field":true}`))
+	}
+	input := &EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"}
+	for _, strict := range []bool{false, true} {
+		client, server := newTestPlatformClient(t, handler, Option{Key: OptStrictDecoding, Value: strict})
+		output := client.Embeddings(input)
+		server.Close()
+		if strict && (output.Error == nil || !strings.Contains(output.Error.Error(), "unknown_field")) {
+			t.Fatalf("%s failed: expected decode error in strict mode but received %#v", testName, output.Error)
+		}
+		if !strict && output.Error != nil {
+			t.Fatalf("%s failed: expected no error in lenient mode but received %s", testName, output.Error)
+		}
+	}
+}
+
+func TestOptExposeRawResponse(t *testing.T) {
+	testName := "TestOptExposeRawResponse"
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Custom-Header", "custom-value")
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`))
+	}
+	prompt := &ChatPromptInput{Model: "gpt-3.5-turbo", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}}
+	for _, expose := range []bool{false, true} {
+		client, server := newTestPlatformClient(t, handler, Option{Key: OptExposeRawResponse, Value: expose})
+		output := client.ChatCompletions(prompt)
+		server.Close()
+		if output.Error != nil || output.StatusCode != 200 {
+			t.Fatalf("%s failed: %#v / %s", testName, output.StatusCode, output.Error)
+		}
+		if !expose && output.RawResponse != nil {
+			t.Fatalf("%s failed: raw response should not be retained by default", testName)
+		}
+		if expose && (output.RawResponse == nil || output.RawResponse.HttpResponse().Header.Get("X-Custom-Header") != "custom-value") {
+			t.Fatalf("%s failed: expected raw response header to be accessible", testName)
+		}
+	}
+}
+
+func TestChatCompletionsOutput_ModelRequested(t *testing.T) {
+	testName := "TestChatCompletionsOutput_ModelRequested"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"chat.completion","model":"gpt-4o-2024-08-06","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`))
+	})
+	defer server.Close()
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}})
+	if output.Error != nil || output.Model != "gpt-4o-2024-08-06" || output.ModelRequested != "gpt-4o" {
+		t.Fatalf("%s failed: expected served/requested models %#v/%#v but received %#v/%#v", testName, "gpt-4o-2024-08-06", "gpt-4o", output.Model, output.ModelRequested)
+	}
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestAzureOpenAIClient_Failover(t *testing.T) {
+	testName := "TestAzureOpenAIClient_Failover"
+	hosts := make([]string, 0)
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		recorder := httptest.NewRecorder()
+		switch {
+		case req.URL.Host == "primary.openai.azure.com":
+			recorder.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = recorder.WriteString(`{"error":{"message":"service unavailable"}}`)
+		case req.URL.Host == "secondary.openai.azure.com" && req.Header.Get("api-key") == "secondary-key":
+			_, _ = recorder.WriteString(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+		default:
+			recorder.WriteHeader(http.StatusUnauthorized)
+		}
+		return recorder.Result(), nil
+	})
+	client, err := NewClient(AzureOpenAI,
+		Option{Key: OptAzureResourceName, Value: "primary"},
+		Option{Key: OptAzureApiKey, Value: "primary-key"},
+		Option{Key: OptAzureFailoverResources, Value: []AzureResource{{Name: "secondary", ApiKey: "secondary-key"}}},
+		Option{Key: OptHttpClient, Value: &http.Client{Transport: transport}},
+	)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-35-turbo", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}})
+	if output.Error != nil || output.StatusCode != 200 || output.Choices[0].Message.Content != "Hi" {
+		t.Fatalf("%s failed: %#v / %s", testName, output.StatusCode, output.Error)
+	}
+	if len(hosts) != 2 || hosts[0] != "primary.openai.azure.com" || hosts[1] != "secondary.openai.azure.com" {
+		t.Fatalf("%s failed: unexpected request sequence %#v", testName, hosts)
+	}
+}
+
+func TestEmbeddingsOutput_Vectors(t *testing.T) {
+	testName := "TestEmbeddingsOutput_Vectors"
+	output := &EmbeddingsOutput{Data: []EmbeddingsData{
+		{Index: 2, Embedding: Vector{2.0}},
+		{Index: 0, Embedding: Vector{0.0}},
+		{Index: 1, Embedding: Vector{1.0}},
+	}}
+	vectors := output.Vectors()
+	if len(vectors) != 3 {
+		t.Fatalf("%s failed: expected 3 vectors but received %#v", testName, len(vectors))
+	}
+	for i, v := range vectors {
+		if v[0] != float64(i) {
+			t.Fatalf("%s failed: expected vector %#v at position %#v but received %#v", testName, i, i, v)
+		}
+		if vi, ok := output.VectorByIndex(i); !ok || vi[0] != float64(i) {
+			t.Fatalf("%s failed: unexpected vector at index %#v: %#v", testName, i, vi)
+		}
+	}
+	for _, i := range []int{-1, 3} {
+		if _, ok := output.VectorByIndex(i); ok {
+			t.Fatalf("%s failed: expected no vector at index %#v", testName, i)
+		}
+	}
+	for i, v := range output.Vectors32() {
+		if v[0] != float32(i) {
+			t.Fatalf("%s failed: expected vector32 %#v at position %#v but received %#v", testName, i, i, v)
+		}
+	}
+}
+
+func TestOptTimeout(t *testing.T) {
+	testName := "TestOptTimeout"
+	testData := []struct {
+		name     string
+		value    interface{}
+		expected time.Duration
+	}{
+		{name: "default", value: nil, expected: 60 * time.Second},
+		{name: "duration", value: 5 * time.Minute, expected: 5 * time.Minute},
+		{name: "seconds", value: 120, expected: 120 * time.Second},
+		{name: "string", value: "1m30s", expected: 90 * time.Second},
+		{name: "invalid", value: -1, expected: 60 * time.Second},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			opts := []Option{{Key: OptOpenAIApiKey, Value: "test-key"}}
+			if testCase.value != nil {
+				opts = append(opts, Option{Key: OptTimeout, Value: testCase.value})
+			}
+			client, err := NewClient(PlatformOpenAI, opts...)
+			if err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			if timeout := client.(*PlatformOpenAIClient).httpClient.Timeout; timeout != testCase.expected {
+				t.Fatalf("%s failed: expected %s but received %s", testName+"/"+testCase.name, testCase.expected, timeout)
+			}
+		})
+	}
+}
+
+func TestEmbeddingsInput_Inputs(t *testing.T) {
+	testName := "TestEmbeddingsInput_Inputs"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"object":"list","data":[` +
+			`{"index":1,"object":"embedding","embedding":[0,1]},{"index":0,"object":"embedding","embedding":[1,0]}]}`))
+	})
+	defer server.Close()
+
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Inputs: []string{"first", "second"}})
+	if output.Error != nil || output.StatusCode != 200 {
+		t.Fatalf("%s failed: %#v / %#v", testName, output.Error, output.StatusCode)
+	}
+	if inputs, ok := received["input"].([]interface{}); !ok || len(inputs) != 2 || inputs[0] != "first" || inputs[1] != "second" {
+		t.Fatalf("%s failed: unexpected input %#v", testName, received["input"])
+	}
+	if v, ok := output.VectorByIndex(0); !ok || v[0] != 1 {
+		t.Fatalf("%s failed: unexpected vector at index 0 %#v", testName, v)
+	}
+
+	js, _ := json.Marshal(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "single"})
+	if string(js) != `{"model":"text-embedding-3-small","input":"single"}` {
+		t.Fatalf("%s failed: unexpected single-input serialization %s", testName, js)
+	}
+}
+
+func TestPromptInput_Prompts(t *testing.T) {
+	testName := "TestPromptInput_Prompts"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","object":"text_completion","choices":[` +
+			`{"text":" negative","index":1,"finish_reason":"stop"},{"text":" positive","index":0,"finish_reason":"stop"}]}`))
+	})
+	defer server.Close()
+
+	output := client.Completions(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompts: []string{"I love it:", "I hate it:"}})
+	if output.Error != nil || output.StatusCode != 200 || len(output.Choices) != 2 {
+		t.Fatalf("%s failed: %#v / %#v", testName, output.Error, output.StatusCode)
+	}
+	if prompts, ok := received["prompt"].([]interface{}); !ok || len(prompts) != 2 || prompts[0] != "I love it:" || prompts[1] != "I hate it:" {
+		t.Fatalf("%s failed: unexpected prompt %#v", testName, received["prompt"])
+	}
+	n, expected := int(received["n"].(float64)), []string{" positive", " negative"}
+	for _, choice := range output.Choices {
+		if choice.Text != expected[choice.Index/n] {
+			t.Fatalf("%s failed: expected %q for choice %d but received %q", testName, expected[choice.Index/n], choice.Index, choice.Text)
+		}
+	}
+
+	js, _ := json.Marshal(&PromptInput{Prompt: "single"})
+	if !strings.Contains(string(js), `"prompt":"single"`) {
+		t.Fatalf("%s failed: unexpected single-prompt serialization %s", testName, js)
+	}
+}
+
+func TestChatPromptInput_Seed(t *testing.T) {
+	testName := "TestChatPromptInput_Seed"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"object":"chat.completion","system_fingerprint":"fp_44709d6fcb","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`))
+	})
+	defer server.Close()
+
+	prompt := &ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}}
+	output := client.ChatCompletions(prompt)
+	if _, ok := received["seed"]; ok {
+		t.Fatalf("%s failed: seed should be omitted when unset", testName)
+	}
+	if output.SystemFingerprint != "fp_44709d6fcb" {
+		t.Fatalf("%s failed: expected system fingerprint %#v but received %#v", testName, "fp_44709d6fcb", output.SystemFingerprint)
+	}
+	seed := 0
+	prompt.Seed = &seed
+	client.ChatCompletions(prompt)
+	if value, ok := received["seed"]; !ok || value != 0.0 {
+		t.Fatalf("%s failed: expected seed 0 but received %#v", testName, value)
+	}
+}
+
+func TestPrepareSampling(t *testing.T) {
+	testName := "TestPrepareSampling"
+	testData := []struct {
+		name                              string
+		temperature, topP                 float64
+		expectedTemperature, expectedTopP float64
+	}{
+		{name: "unset", temperature: 0, topP: 0, expectedTemperature: 0, expectedTopP: 1},
+		{name: "both-set", temperature: 0.3, topP: 0.9, expectedTemperature: 0.3, expectedTopP: 0.9},
+		{name: "temperature-only", temperature: 0.3, topP: 0, expectedTemperature: 0.3, expectedTopP: 1},
+		{name: "zero-temperature", temperature: 0, topP: 1, expectedTemperature: 0, expectedTopP: 1},
+		{name: "high-temperature", temperature: 1.5, topP: 1, expectedTemperature: 1.5, expectedTopP: 1},
+		{name: "max-temperature", temperature: 2, topP: 0.5, expectedTemperature: 2, expectedTopP: 0.5},
+	}
+	bc := &BaseClient{}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			chat := bc.prepareChatPrompt(&ChatPromptInput{Temperature: testCase.temperature, TopP: testCase.topP})
+			prompt := bc.preparePrompt(&PromptInput{Temperature: testCase.temperature, TopP: testCase.topP})
+			for _, received := range [][2]float64{{chat.Temperature, chat.TopP}, {prompt.Temperature, prompt.TopP}} {
+				if received[0] != testCase.expectedTemperature || received[1] != testCase.expectedTopP {
+					t.Fatalf("%s failed: expected %#v/%#v but received %#v/%#v", testName+"/"+testCase.name,
+						testCase.expectedTemperature, testCase.expectedTopP, received[0], received[1])
+				}
+			}
+		})
+	}
+}
+
+func TestOptDefaultMaxTokens(t *testing.T) {
+	testName := "TestOptDefaultMaxTokens"
+	var received map[string]interface{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`))
+	}
+	testData := []struct {
+		name      string
+		opts      []Option
+		maxTokens int
+		expected  interface{}
+	}{
+		{name: "omitted", expected: nil},
+		{name: "explicit", maxTokens: 50, expected: 50.0},
+		{name: "default", opts: []Option{{Key: OptDefaultMaxTokens, Value: 100}}, expected: 100.0},
+		{name: "explicit-over-default", opts: []Option{{Key: OptDefaultMaxTokens, Value: 100}}, maxTokens: 50, expected: 50.0},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, server := newTestPlatformClient(t, handler, testCase.opts...)
+			defer server.Close()
+			client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Hello"}}, MaxTokens: testCase.maxTokens})
+			if received["max_tokens"] != testCase.expected {
+				t.Fatalf("%s failed: expected max_tokens %#v but received %#v", testName+"/"+testCase.name, testCase.expected, received["max_tokens"])
+			}
+		})
+	}
+}
+
+func TestOptAzureDeployments(t *testing.T) {
+	testName := "TestOptAzureDeployments"
+	paths := make([]string, 0)
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.Path)
+		recorder := httptest.NewRecorder()
+		_, _ = recorder.WriteString(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`)
+		return recorder.Result(), nil
+	})
+	client, err := NewClient(AzureOpenAI,
+		Option{Key: OptAzureResourceName, Value: "my-resource"},
+		Option{Key: OptAzureApiKey, Value: "my-key"},
+		Option{Key: OptAzureDeployments, Value: map[string]string{"gpt-3.5-turbo": "gpt-35-turbo-prod"}},
+		Option{Key: OptHttpClient, Value: &http.Client{Transport: transport}},
+	)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	for _, model := range []string{"gpt-3.5-turbo", "gpt-4"} {
+		client.ChatCompletions(&ChatPromptInput{Model: model, Messages: []ChatMessage{{Role: "user", Content: "Hello"}}})
+	}
+	expected := []string{"/openai/deployments/gpt-35-turbo-prod/chat/completions", "/openai/deployments/gpt-4/chat/completions"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("%s failed: expected %#v but received %#v", testName, expected, paths)
+	}
+
+	_, err = NewClient(AzureOpenAI, Option{Key: OptAzureResourceName, Value: "my-resource"}, Option{Key: OptAzureApiKey, Value: "my-key"},
+		Option{Key: OptAzureDeployments, Value: "gpt-3.5-turbo=gpt-35-turbo"})
+	if err == nil {
+		t.Fatalf("%s failed: expected error for invalid setting value", testName)
+	}
+}
+
+func TestOptAzureBaseUrl(t *testing.T) {
+	testName := "TestOptAzureBaseUrl"
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path + "?" + r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(AzureOpenAI,
+		Option{Key: OptAzureBaseUrl, Value: server.URL + "/"},
+		Option{Key: OptAzureApiKey, Value: "my-key"},
+		Option{Key: OptAzureApiVersion, Value: "2024-02-01"},
+	)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+	if expected := "/openai/deployments/text-embedding-ada-002/embeddings?api-version=2024-02-01"; output.Error != nil || path != expected {
+		t.Fatalf("%s failed: expected %#v but received %#v / %s", testName, expected, path, output.Error)
+	}
+
+	azureClient := &AzureOpenAIClient{baseUrl: "https://{azure-resource-name}.openai.azure.us"}
+	if url := azureClient.buildBaseUrl("my-resource"); url != "https://my-resource.openai.azure.us" {
+		t.Fatalf("%s failed: unexpected base URL %#v", testName, url)
+	}
+	if _, err = NewClient(AzureOpenAI, Option{Key: OptAzureApiKey, Value: "my-key"}); err == nil {
+		t.Fatalf("%s failed: expected error when neither resource name nor base URL is specified", testName)
+	}
+}
+
+func TestOptAzureApiVersion(t *testing.T) {
+	testName := "TestOptAzureApiVersion"
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "my-key"})
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	input := &EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"}
+	if client.Embeddings(input); query != "api-version="+DefaultAzureApiVersion {
+		t.Fatalf("%s failed: expected default api-version but received %q", testName, query)
+	}
+	if client.Embeddings(input, WithAzureApiVersion("2025-01-01-preview")); query != "api-version=2025-01-01-preview" {
+		t.Fatalf("%s failed: expected per-call api-version but received %q", testName, query)
+	}
+	if client.Embeddings(input, WithAzureApiVersion("latest")); query != "api-version="+DefaultAzureApiVersion {
+		t.Fatalf("%s failed: expected invalid per-call api-version to be ignored but received %q", testName, query)
+	}
+
+	for _, version := range []string{"2024-10-21", "2024-12-01-preview"} {
+		if _, err = NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "my-key"},
+			WithAzureApiVersion(version)); err != nil {
+			t.Fatalf("%s failed: expected api-version %q to be accepted but received %s", testName, version, err)
+		}
+	}
+	for _, version := range []string{"2024-10", "v1", "2024-10-21-beta", "2024-10-21 "} {
+		if _, err = NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "my-key"},
+			WithAzureApiVersion(version)); err == nil {
+			t.Fatalf("%s failed: expected error for api-version %q", testName, version)
+		}
+	}
+}
+
+func TestEmbeddingsInput_Dimensions(t *testing.T) {
+	testName := "TestEmbeddingsInput_Dimensions"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	})
+	defer server.Close()
+
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world", Dimensions: 2})
+	if output.Error != nil || received["dimensions"] != 2.0 || len(output.Data[0].Embedding) != 2 {
+		t.Fatalf("%s failed: unexpected request %#v / %s", testName, received, output.Error)
+	}
+	client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"})
+	if _, ok := received["dimensions"]; ok {
+		t.Fatalf("%s failed: dimensions should be omitted when unset", testName)
+	}
+}
+
+func TestCallOptions(t *testing.T) {
+	testName := "TestCallOptions"
+	// the handler of a timed-out request may still be running while the next request is served
+	var mutex sync.Mutex
+	var lastHeader http.Header
+	received := func() http.Header {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return lastHeader
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		lastHeader = r.Header
+		mutex.Unlock()
+		if r.Header.Get("X-Slow") != "" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}, Option{Key: OptTimeout, Value: 100 * time.Millisecond})
+	defer server.Close()
+
+	output := client.ListModels(Option{Key: OptHeaders, Value: map[string]string{"X-Title": "my-app", "Authorization": "Bearer other-key"}})
+	if output.Error != nil || received().Get("X-Title") != "my-app" || received().Get("Authorization") != "Bearer test-key" {
+		t.Fatalf("%s failed: unexpected request headers %#v / %s", testName, received(), output.Error)
+	}
+	if client.ListModels(); received().Get("X-Title") != "" {
+		t.Fatalf("%s failed: per-call headers should not persist", testName)
+	}
+
+	slow := Option{Key: OptHeaders, Value: http.Header{"X-Slow": []string{"1"}}}
+	if output = client.ListModels(slow); output.Error == nil {
+		t.Fatalf("%s failed: expected timeout error", testName)
+	}
+	if output = client.ListModels(slow, Option{Key: OptTimeout, Value: 2 * time.Second}); output.Error != nil {
+		t.Fatalf("%s failed: per-call timeout not applied: %s", testName, output.Error)
+	}
+}
+
+func TestOptHeaders(t *testing.T) {
+	testName := "TestOptHeaders"
+	var received http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}
+	client, server := newTestPlatformClient(t, handler, Option{Key: OptHeaders, Value: map[string]string{
+		"HTTP-Referer": "https://example.com", "X-Title": "my-app", "Authorization": "Bearer gateway-key"}})
+	defer server.Close()
+
+	output := client.ListModels()
+	if output.Error != nil || received.Get("HTTP-Referer") != "https://example.com" || received.Get("X-Title") != "my-app" ||
+		received.Get("Authorization") != "Bearer test-key" {
+		t.Fatalf("%s failed: unexpected request headers %#v / %s", testName, received, output.Error)
+	}
+	client.ListModels(Option{Key: OptHeaders, Value: map[string]string{"X-Title": "other-app", "X-Extra": "1"}})
+	if received.Get("HTTP-Referer") != "https://example.com" || received.Get("X-Title") != "other-app" || received.Get("X-Extra") != "1" {
+		t.Fatalf("%s failed: unexpected request headers %#v", testName, received)
+	}
+
+	azureServer := httptest.NewServer(http.HandlerFunc(handler))
+	defer azureServer.Close()
+	azureClient, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: azureServer.URL},
+		Option{Key: OptAzureApiKey, Value: "azure-key"}, Option{Key: OptHeaders, Value: http.Header{"Api-Key": []string{"other"}, "X-Title": []string{"my-app"}}})
+	azureClient.ListModels()
+	if received.Get("api-key") != "azure-key" || received.Get("X-Title") != "my-app" {
+		t.Fatalf("%s failed: unexpected request headers %#v", testName, received)
+	}
+
+	if _, err := NewClient(PlatformOpenAI, Option{Key: OptOpenAIApiKey, Value: "test-key"}, Option{Key: OptHeaders, Value: "invalid"}); err == nil {
+		t.Fatalf("%s failed: expected error for invalid setting", testName)
+	}
+}
+
+func TestValidatePenalties(t *testing.T) {
+	testName := "TestValidatePenalties"
+	calls := 0
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","choices":[]}`))
+	})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "Hi"}}
+	for _, penalty := range []float64{-2.5, 2.01} {
+		if output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: messages, PresencePenalty: penalty}); !errors.Is(output.Error, ErrInvalidParameter) {
+			t.Fatalf("%s failed: expected ErrInvalidParameter but received %v", testName, output.Error)
+		}
+		if output := client.Completions(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompt: "Hi", FrequencyPenalty: penalty}); !errors.Is(output.Error, ErrInvalidParameter) {
+			t.Fatalf("%s failed: expected ErrInvalidParameter but received %v", testName, output.Error)
+		}
+		stream := client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-4o", Messages: messages, FrequencyPenalty: penalty})
+		if _, ok := <-stream.Chunks; ok || !errors.Is(stream.Error, ErrInvalidParameter) {
+			t.Fatalf("%s failed: expected ErrInvalidParameter but received %v", testName, stream.Error)
+		}
+	}
+	if calls != 0 {
+		t.Fatalf("%s failed: invalid prompts should not be sent (%d calls)", testName, calls)
+	}
+	if output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: messages, PresencePenalty: 2, FrequencyPenalty: -2}); output.Error != nil || calls != 1 {
+		t.Fatalf("%s failed: unexpected error %v", testName, output.Error)
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	testName := "TestValidateSampling"
+	calls := 0
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","choices":[]}`))
+	})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "Hi"}}
+	testData := []struct {
+		name              string
+		temperature, topP float64
+	}{
+		{name: "temperature-too-high", temperature: 2.5, topP: 1},
+		{name: "temperature-negative", temperature: -0.1, topP: 1},
+		{name: "top_p-too-high", temperature: 1, topP: 1.5},
+		{name: "top_p-negative", temperature: 1, topP: -0.5},
+	}
+	for _, testCase := range testData {
+		if output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: messages, Temperature: testCase.temperature, TopP: testCase.topP}); !errors.Is(output.Error, ErrInvalidParameter) {
+			t.Fatalf("%s failed: expected ErrInvalidParameter but received %v", testName+"/"+testCase.name, output.Error)
+		}
+		if output := client.Completions(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompt: "Hi", Temperature: testCase.temperature, TopP: testCase.topP}); !errors.Is(output.Error, ErrInvalidParameter) {
+			t.Fatalf("%s failed: expected ErrInvalidParameter but received %v", testName+"/"+testCase.name, output.Error)
+		}
+	}
+	if calls != 0 {
+		t.Fatalf("%s failed: invalid prompts should not be sent (%d calls)", testName, calls)
+	}
+	if output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: messages, Temperature: 0}); output.Error != nil || calls != 1 {
+		t.Fatalf("%s failed: unexpected error %v", testName, output.Error)
+	}
+}
+
+func TestStopSequences(t *testing.T) {
+	testName := "TestStopSequences"
+	chatPrompt := (&ChatPromptInput{Model: "gpt-4o"}).StopSequences("\n")
+	js, _ := json.Marshal(chatPrompt)
+	if !strings.Contains(string(js), `"stop":["\n"]`) {
+		t.Fatalf("%s failed: unexpected JSON %s", testName, js)
+	}
+	if js, _ = json.Marshal(chatPrompt.StopSequences()); strings.Contains(string(js), `"stop"`) {
+		t.Fatalf("%s failed: stop should be omitted when empty %s", testName, js)
+	}
+	prompt := (&PromptInput{Model: "gpt-3.5-turbo-instruct"}).StopSequences("END", "###")
+	if !reflect.DeepEqual(prompt.Stop, []string{"END", "###"}) {
+		t.Fatalf("%s failed: unexpected stop sequences %#v", testName, prompt.Stop)
+	}
+}
+
+func TestOptDefaultModel(t *testing.T) {
+	testName := "TestOptDefaultModel"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}, Option{Key: OptDefaultModel, Value: "gpt-4o-mini"}, Option{Key: OptDefaultEmbeddingsModel, Value: "text-embedding-3-small"})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "Hi"}}
+	if client.ChatCompletions(&ChatPromptInput{Messages: messages}); received["model"] != "gpt-4o-mini" {
+		t.Fatalf("%s failed: expected default model but received %#v", testName, received["model"])
+	}
+	if client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: messages}); received["model"] != "gpt-4o" {
+		t.Fatalf("%s failed: explicit model should win but received %#v", testName, received["model"])
+	}
+	if client.Completions(&PromptInput{Prompt: "Hi"}); received["model"] != "gpt-4o-mini" {
+		t.Fatalf("%s failed: expected default model but received %#v", testName, received["model"])
+	}
+	input := &EmbeddingsInput{Input: "Hello"}
+	if output := client.Embeddings(input); received["model"] != "text-embedding-3-small" || output.ModelRequested != "text-embedding-3-small" || input.Model != "" {
+		t.Fatalf("%s failed: expected default embeddings model but received %#v", testName, received["model"])
+	}
+}
+
+func TestOptOpenAIProject(t *testing.T) {
+	testName := "TestOptOpenAIProject"
+	var received http.Header
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}, WithOpenAIOrganization("org-123"), WithOpenAIProject("proj_abc"))
+	defer server.Close()
+
+	if client.ListModels(); received.Get("OpenAI-Organization") != "org-123" || received.Get("OpenAI-Project") != "proj_abc" {
+		t.Fatalf("%s failed: unexpected request headers %#v", testName, received)
+	}
+	client, server2 := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	})
+	defer server2.Close()
+	if client.ListModels(); received.Get("OpenAI-Project") != "" || received.Get("OpenAI-Organization") != "" {
+		t.Fatalf("%s failed: unexpected request headers %#v", testName, received)
+	}
+}
+
+func TestOptOpenAIOrganization_PerCall(t *testing.T) {
+	testName := "TestOptOpenAIOrganization_PerCall"
+	var received http.Header
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}, WithOpenAIOrganization("org-default"), WithOpenAIProject("proj_default"))
+	defer server.Close()
+
+	if client.ListModels(WithOpenAIOrganization("org-tenant"), WithOpenAIProject("proj_tenant")); received.Get("OpenAI-Organization") != "org-tenant" ||
+		received.Get("OpenAI-Project") != "proj_tenant" {
+		t.Fatalf("%s failed: expected per-call organization/project but received %#v", testName, received)
+	}
+	if client.ListModels(WithOpenAIOrganization("org-tenant")); received.Get("OpenAI-Organization") != "org-tenant" ||
+		received.Get("OpenAI-Project") != "proj_default" {
+		t.Fatalf("%s failed: expected per-call organization and default project but received %#v", testName, received)
+	}
+	if client.ListModels(); received.Get("OpenAI-Organization") != "org-default" || received.Get("OpenAI-Project") != "proj_default" {
+		t.Fatalf("%s failed: expected client-level organization/project but received %#v", testName, received)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	testName := "TestClient_Close"
+	closed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	server.Start()
+	defer server.Close()
+	client, _ := NewClient(PlatformOpenAI, Option{Key: OptOpenAIApiKey, Value: "test-key"}, Option{Key: OptOpenAIBaseUrl, Value: server.URL})
+
+	if models := client.ListModels(); models.Error != nil {
+		t.Fatalf("%s failed: %s", testName, models.Error)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s failed: idle connection was not closed", testName)
+	}
+	if models := client.ListModels(); models.Error != nil {
+		t.Fatalf("%s failed: client is not usable after Close: %s", testName, models.Error)
+	}
+
+	// the connections of a supplied http.Client are owned by the caller
+	client, _ = NewClient(PlatformOpenAI, Option{Key: OptOpenAIApiKey, Value: "test-key"}, Option{Key: OptHttpClient, Value: &http.Client{}})
+	if err := client.Close(); err != nil || client.(*PlatformOpenAIClient).ownedTransport != nil {
+		t.Fatalf("%s failed: expected no-op Close for supplied http.Client", testName)
+	}
+}
+
+func TestChatPromptInput_N(t *testing.T) {
+	testName := "TestChatPromptInput_N"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[` +
+			`{"index":2,"message":{"role":"assistant","content":"C"},"finish_reason":"stop"},` +
+			`{"index":0,"message":{"role":"assistant","content":"A"},"finish_reason":"stop"},` +
+			`{"index":1,"message":{"role":"assistant","content":"B"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}`))
+	})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "user", Content: "Say a letter"}}
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o-mini", N: 3, Messages: messages})
+	if output.Error != nil || received["n"] != 3.0 || len(output.Choices) != 3 {
+		t.Fatalf("%s failed: %#v / n=%#v / %d choices", testName, output.Error, received["n"], len(output.Choices))
+	}
+	for i, choice := range output.Choices {
+		if choice.Index != i || choice.Message.Content != string(rune('A'+i)) {
+			t.Fatalf("%s failed: expected choices sorted by index but received %#v at %d", testName, choice, i)
+		}
+	}
+
+	client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o-mini", Messages: messages})
+	if received["n"] != 1.0 {
+		t.Fatalf("%s failed: expected n=1 by default but received %#v", testName, received["n"])
+	}
+}
+
+func TestChatPromptInput_Clone(t *testing.T) {
+	testName := "TestChatPromptInput_Clone"
+	seed := 42
+	prompt := &ChatPromptInput{Model: "gpt-4o", Stop: []string{"\n"}, LogitBias: map[string]int{"50256": -100}, Seed: &seed,
+		Messages: []ChatMessage{{Role: "user", ContentParts: []ChatContentPart{ImageUrlPart("https://example.com/cat.png", "low")}},
+			{Role: "assistant", ToolCalls: []ToolCall{{Id: "call_1", Type: "function"}}}},
+		ResponseFormat: ResponseFormatJsonSchema("answer", map[string]interface{}{"type": "object"}, true)}
+	clone := prompt.Clone()
+	if !reflect.DeepEqual(clone, prompt) {
+		t.Fatalf("%s failed: expected %#v but received %#v", testName, prompt, clone)
+	}
+	clone.Stop[0], clone.LogitBias["50256"], *clone.Seed = "END", 100, 7
+	clone.Messages[0].ContentParts[0].ImageUrl.Url = "https://example.com/dog.png"
+	clone.Messages[1].ToolCalls[0].Id = "call_2"
+	clone.ResponseFormat.JsonSchema.Name = "other"
+	if prompt.Stop[0] != "\n" || prompt.LogitBias["50256"] != -100 || seed != 42 || prompt.Messages[0].ContentParts[0].ImageUrl.Url != "https://example.com/cat.png" ||
+		prompt.Messages[1].ToolCalls[0].Id != "call_1" || prompt.ResponseFormat.JsonSchema.Name != "answer" {
+		t.Fatalf("%s failed: modifying the clone modified the prompt %#v", testName, prompt)
+	}
+	if (*ChatPromptInput)(nil).Clone() != nil || (*PromptInput)(nil).Clone() != nil {
+		t.Fatalf("%s failed: expected nil clone of nil prompt", testName)
+	}
+
+	completionsPrompt := &PromptInput{Model: "gpt-3.5-turbo-instruct", Prompts: []string{"a", "b"}, Stop: []string{"\n"}}
+	completionsClone := completionsPrompt.Clone()
+	completionsClone.Prompts[0], completionsClone.Stop[0] = "c", "END"
+	if completionsPrompt.Prompts[0] != "a" || completionsPrompt.Stop[0] != "\n" {
+		t.Fatalf("%s failed: modifying the clone modified the prompt %#v", testName, completionsPrompt)
+	}
+}
+
+// run with -race to detect concurrent writes to the shared prompt
+func TestPrepareChatPrompt_Concurrent(t *testing.T) {
+	testName := "TestPrepareChatPrompt_Concurrent"
+	bc := &BaseClient{defaultModel: "o1-mini", defaultMaxTokens: 256}
+	shared := &ChatPromptInput{Messages: []ChatMessage{{Role: "user", Content: "Hello"}}, TopLogprobs: 2}
+	done := make(chan *ChatPromptInput)
+	for i := 0; i < 8; i++ {
+		go func() {
+			done <- bc.prepareChatPrompt(shared)
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if prepared := <-done; prepared.Model != "o1-mini" || prepared.MaxCompletionTokens != 256 || !prepared.Logprobs {
+			t.Fatalf("%s failed: unexpected prepared prompt %#v", testName, prepared)
+		}
+	}
+	if shared.Model != "" || shared.MaxCompletionTokens != 0 || shared.N != 0 || shared.Logprobs || shared.Temperature != 0 {
+		t.Fatalf("%s failed: expected the shared prompt not to be modified but received %#v", testName, shared)
+	}
+}
+
+func TestClient_InputsNotModified(t *testing.T) {
+	testName := "TestClient_InputsNotModified"
+	var received []map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body)
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"cmpl-1","choices":[]}`))
+	}, Option{Key: OptDefaultModel, Value: "o1-mini"}, Option{Key: OptDefaultMaxTokens, Value: 128})
+	defer server.Close()
+
+	chatPrompt := &ChatPromptInput{Messages: []ChatMessage{{Role: "user", Content: "Hello"}}, MaxTokens: 64, Temperature: 0.5}
+	expectedChatPrompt := chatPrompt.Clone()
+	client.ChatCompletions(chatPrompt)
+	for range client.ChatCompletionsStream(chatPrompt).Chunks {
+	}
+	if !reflect.DeepEqual(chatPrompt, expectedChatPrompt) {
+		t.Fatalf("%s failed: expected the prompt not to be modified but received %#v", testName, chatPrompt)
+	}
+	prompt := &PromptInput{Prompt: "Hello", Temperature: 0.5}
+	expectedPrompt := prompt.Clone()
+	client.Completions(prompt)
+	for range client.CompletionsStream(prompt).Chunks {
+	}
+	if !reflect.DeepEqual(prompt, expectedPrompt) {
+		t.Fatalf("%s failed: expected the prompt not to be modified but received %#v", testName, prompt)
+	}
+
+	// the sent prompts have the defaults applied
+	if len(received) != 4 || received[0]["model"] != "o1-mini" || received[0]["max_completion_tokens"] != 64.0 || received[1]["stream"] != true ||
+		received[2]["max_tokens"] != 128.0 || received[3]["stream"] != true {
+		t.Fatalf("%s failed: unexpected sent prompts %#v", testName, received)
+	}
+}
+
+func TestBaseResponse_RequestId(t *testing.T) {
+	testName := "TestBaseResponse_RequestId"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_"+strings.TrimPrefix(r.URL.Path, "/models/"))
+		switch r.URL.Path {
+		case "/models/unknown":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"The model does not exist","type":"invalid_request_error"}}`))
+		case "/models/garbled":
+			_, _ = w.Write([]byte(`{"id":`))
+		case "/chat/completions":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+		default:
+			_, _ = w.Write([]byte(`{"id":"gpt-4o","object":"model"}`))
+		}
+	})
+	defer server.Close()
+
+	for _, model := range []string{"gpt-4o", "unknown", "garbled"} {
+		if output := client.RetrieveModel(model); output.RequestId != "req_"+model || (model != "gpt-4o" && output.Error == nil) {
+			t.Fatalf("%s failed: expected request id %q but received %q / %#v", testName, "req_"+model, output.RequestId, output.Error)
+		}
+	}
+	stream := client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Hi"}}})
+	for range stream.Chunks {
+	}
+	if stream.RequestId != "req_/chat/completions" {
+		t.Fatalf("%s failed: unexpected stream request id %q", testName, stream.RequestId)
+	}
+
+	if id := requestId(http.Header{"Apim-Request-Id": []string{"0b8f2d1e"}}); id != "0b8f2d1e" {
+		t.Fatalf("%s failed: expected Azure request id but received %q", testName, id)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Package oaiauxtest provides utilities to test code working with oaiaux, without making real API calls.
+*/
+package oaiauxtest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/btnguyen2k/oaiaux"
+)
+
+// DeterministicVector generates a pseudo-random unit vector of dim dimensions from a seed.
+//
+// The same seed and dim always produce the same vector, which makes it suitable as a stable fixture for embeddings.
+func DeterministicVector(seed string, dim int) oaiaux.Vector {
+	if dim <= 0 {
+		return oaiaux.Vector{}
+	}
+	result := make(oaiaux.Vector, dim)
+	var block [sha256.Size]byte
+	length := 0.0
+	for i := 0; i < dim; i++ {
+		if i%(sha256.Size/8) == 0 {
+			counter := make([]byte, 8)
+			binary.BigEndian.PutUint64(counter, uint64(i))
+			block = sha256.Sum256(append([]byte(seed), counter...))
+		}
+		offset := (i % (sha256.Size / 8)) * 8
+		// map the 64-bit value to [-1, 1)
+		result[i] = float64(binary.BigEndian.Uint64(block[offset:offset+8])>>11)/float64(1<<52) - 1.0
+		length += result[i] * result[i]
+	}
+	length = math.Sqrt(length)
+	if length > 0 {
+		for i := range result {
+			result[i] /= length
+		}
+	}
+	return result
+}
+
+// AssertCosineClose fails the test if the cosine-similarity of a and b is not within tol of 1.0.
+func AssertCosineClose(t testing.TB, a, b oaiaux.Vector, tol float64) {
+	t.Helper()
+	if len(a) != len(b) {
+		t.Fatalf("vectors have different dimensions: %d vs %d", len(a), len(b))
+	}
+	if cosine := a.Cosine(b); math.IsNaN(cosine) || 1.0-cosine > tol {
+		t.Fatalf("vectors are not close: cosine-similarity %f, expected at least %f", cosine, 1.0-tol)
+	}
+}
+
+// RoundTripFunc is an http.RoundTripper implemented by a function, used to stub API responses: supply it as the
+// transport of the client's http.Client (see oaiaux.OptHttpClient) to test code calling oaiaux without network access.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// JsonResponse builds an HTTP response with the given status code and JSON body, for use with RoundTripFunc.
+func JsonResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode:    statusCode,
+		Status:        http.StatusText(statusCode),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+// NewStubClient creates an oaiaux.Client (of the PlatformOpenAI flavor, with a dummy API key) whose API calls are all
+// served by fn. Additional settings can be supplied via opts.
+func NewStubClient(fn RoundTripFunc, opts ...oaiaux.Option) (oaiaux.Client, error) {
+	opts = append([]oaiaux.Option{
+		oaiaux.WithOpenAIApiKey("stub-api-key"),
+		oaiaux.WithHttpClient(&http.Client{Transport: fn}),
+	}, opts...)
+	return oaiaux.NewClient(oaiaux.PlatformOpenAI, opts...)
+}
@@ -0,0 +1,74 @@
+package oaiauxtest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/btnguyen2k/oaiaux"
+)
+
+func TestDeterministicVector(t *testing.T) {
+	testName := "TestDeterministicVector"
+	v1, v2 := DeterministicVector("hello", 1536), DeterministicVector("hello", 1536)
+	if len(v1) != 1536 {
+		t.Fatalf("%s failed: expected 1536 dimensions but received %#v", testName, len(v1))
+	}
+	for i := range v1 {
+		if v1[i] != v2[i] {
+			t.Fatalf("%s failed: same seed should produce the same vector", testName)
+		}
+	}
+	if length := v1.Length(); math.Abs(length-1.0) > 1e-9 {
+		t.Fatalf("%s failed: expected unit vector but received length %#v", testName, length)
+	}
+	if v3 := DeterministicVector("world", 1536); v1.Cosine(v3) > 0.5 {
+		t.Fatalf("%s failed: different seeds should produce distinct vectors", testName)
+	}
+}
+
+func TestAssertCosineClose(t *testing.T) {
+	v := DeterministicVector("hello", 8)
+	nearby := make([]float64, len(v))
+	for i := range v {
+		nearby[i] = v[i] * 1.5
+	}
+	nearby[0] += 0.001
+	AssertCosineClose(t, v, nearby, 1e-3)
+	AssertCosineClose(t, v, DeterministicVector("hello", 8), 0)
+}
+
+func ExampleNewStubClient() {
+	client, _ := NewStubClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path != "/v1/chat/completions" {
+			return JsonResponse(http.StatusNotFound, `{"error":{"message":"not found","type":"invalid_request_error"}}`), nil
+		}
+		return JsonResponse(http.StatusOK, `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-mini",
+			"choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`), nil
+	})
+	output := client.ChatCompletions(&oaiaux.ChatPromptInput{
+		Model:    "gpt-4o-mini",
+		Messages: []oaiaux.ChatMessage{{Role: "user", Content: "Hi"}},
+	})
+	fmt.Println(output.StatusCode, output.Choices[0].Message.Content, output.Usage.TotalTokens)
+	// Output: 200 Hello! 11
+}
+
+func TestNewStubClient(t *testing.T) {
+	testName := "TestNewStubClient"
+	calls := 0
+	client, err := NewStubClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return JsonResponse(http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","type":"requests"}}`), nil
+	}, oaiaux.WithMaxRetries(1), oaiaux.WithRetryBaseDelay(time.Millisecond))
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	output := client.Embeddings(&oaiaux.EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello"})
+	if output.StatusCode != http.StatusTooManyRequests || output.Error == nil || calls != 2 {
+		t.Fatalf("%s failed: unexpected output %d / %v (%d calls)", testName, output.StatusCode, output.Error, calls)
+	}
+}
@@ -0,0 +1,220 @@
+package oaiaux
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// The With* helpers build the Option for a client setting, offering compile-time type checking and discoverability
+// over the equivalent Option{Key: ..., Value: ...} literals, e.g.
+//
+//	client, err := oaiaux.NewClient(oaiaux.AzureOpenAI,
+//		oaiaux.WithAzureResourceName("my-resource"),
+//		oaiaux.WithAzureApiKey(os.Getenv("AZURE_OPENAI_API_KEY")),
+//		oaiaux.WithTimeout(30*time.Second),
+//	)
+//
+// NewClient keeps its variadic signature: there is no separate functional-options constructor, the helpers simply
+// build Option values. Every public Opt* setting has a With* helper, including the options of ChatCompletionsBatch and
+// SummarizeConversation.
+
+// WithAzureResourceName builds the OptAzureResourceName setting.
+func WithAzureResourceName(resourceName string) Option {
+	return Option{Key: OptAzureResourceName, Value: resourceName}
+}
+
+// WithAzureApiVersion builds the OptAzureApiVersion setting.
+func WithAzureApiVersion(apiVersion string) Option {
+	return Option{Key: OptAzureApiVersion, Value: apiVersion}
+}
+
+// WithAzureApiKey builds the OptAzureApiKey setting.
+func WithAzureApiKey(apiKey string) Option {
+	return Option{Key: OptAzureApiKey, Value: apiKey}
+}
+
+// WithAzureFailoverResources builds the OptAzureFailoverResources setting.
+func WithAzureFailoverResources(resources ...AzureResource) Option {
+	return Option{Key: OptAzureFailoverResources, Value: resources}
+}
+
+// WithAzureADToken builds the OptAzureADToken setting.
+func WithAzureADToken(token string) Option {
+	return Option{Key: OptAzureADToken, Value: token}
+}
+
+// WithAzureTokenProvider builds the OptAzureTokenProvider setting.
+func WithAzureTokenProvider(provider AzureTokenProvider) Option {
+	return Option{Key: OptAzureTokenProvider, Value: provider}
+}
+
+// WithAzureDeployments builds the OptAzureDeployments setting.
+func WithAzureDeployments(deployments map[string]string) Option {
+	return Option{Key: OptAzureDeployments, Value: deployments}
+}
+
+// WithAzureBaseUrl builds the OptAzureBaseUrl setting.
+func WithAzureBaseUrl(baseUrl string) Option {
+	return Option{Key: OptAzureBaseUrl, Value: baseUrl}
+}
+
+// WithOpenAIApiKey builds the OptOpenAIApiKey setting.
+func WithOpenAIApiKey(apiKey string) Option {
+	return Option{Key: OptOpenAIApiKey, Value: apiKey}
+}
+
+// WithOpenAIOrganization builds the OptOpenAIOrganization setting.
+func WithOpenAIOrganization(organization string) Option {
+	return Option{Key: OptOpenAIOrganization, Value: organization}
+}
+
+// WithOpenAIProject builds the OptOpenAIProject setting.
+func WithOpenAIProject(project string) Option {
+	return Option{Key: OptOpenAIProject, Value: project}
+}
+
+// WithOpenAIBaseUrl builds the OptOpenAIBaseUrl setting.
+func WithOpenAIBaseUrl(baseUrl string) Option {
+	return Option{Key: OptOpenAIBaseUrl, Value: baseUrl}
+}
+
+// WithTimeout builds the OptTimeout setting.
+func WithTimeout(timeout time.Duration) Option {
+	return Option{Key: OptTimeout, Value: timeout}
+}
+
+// WithHttpClient builds the OptHttpClient setting.
+func WithHttpClient(httpClient *http.Client) Option {
+	return Option{Key: OptHttpClient, Value: httpClient}
+}
+
+// WithHeaders builds the OptHeaders setting.
+func WithHeaders(header http.Header) Option {
+	return Option{Key: OptHeaders, Value: header}
+}
+
+// WithMaxRetries builds the OptMaxRetries setting.
+func WithMaxRetries(maxRetries int) Option {
+	return Option{Key: OptMaxRetries, Value: maxRetries}
+}
+
+// WithRetryBaseDelay builds the OptRetryBaseDelay setting.
+func WithRetryBaseDelay(delay time.Duration) Option {
+	return Option{Key: OptRetryBaseDelay, Value: delay}
+}
+
+// WithContext builds the OptContext per-call setting.
+func WithContext(ctx context.Context) Option {
+	return Option{Key: OptContext, Value: ctx}
+}
+
+// WithDefaultMaxTokens builds the OptDefaultMaxTokens setting.
+func WithDefaultMaxTokens(maxTokens int) Option {
+	return Option{Key: OptDefaultMaxTokens, Value: maxTokens}
+}
+
+// WithDefaultModel builds the OptDefaultModel setting.
+func WithDefaultModel(model string) Option {
+	return Option{Key: OptDefaultModel, Value: model}
+}
+
+// WithDefaultEmbeddingsModel builds the OptDefaultEmbeddingsModel setting.
+func WithDefaultEmbeddingsModel(model string) Option {
+	return Option{Key: OptDefaultEmbeddingsModel, Value: model}
+}
+
+// WithEmbeddingsCache builds the OptEmbeddingsCache setting.
+func WithEmbeddingsCache(store EmbeddingsStore) Option {
+	return Option{Key: OptEmbeddingsCache, Value: store}
+}
+
+// WithRequestSigner builds the OptRequestSigner setting.
+func WithRequestSigner(signer RequestSigner) Option {
+	return Option{Key: OptRequestSigner, Value: signer}
+}
+
+// WithRateLimiter builds the OptRateLimiter setting.
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return Option{Key: OptRateLimiter, Value: limiter}
+}
+
+// WithLogger builds the OptLogger setting.
+func WithLogger(logger Logger) Option {
+	return Option{Key: OptLogger, Value: logger}
+}
+
+// WithPromptCompressor builds the OptPromptCompressor setting.
+func WithPromptCompressor(compressor PromptCompressor) Option {
+	return Option{Key: OptPromptCompressor, Value: compressor}
+}
+
+// WithRecorder builds the OptRecorder setting.
+func WithRecorder(recorder *FineTuningRecorder) Option {
+	return Option{Key: OptRecorder, Value: recorder}
+}
+
+// WithNormalizeEmbeddings builds the OptNormalizeEmbeddings setting.
+func WithNormalizeEmbeddings(normalize bool) Option {
+	return Option{Key: OptNormalizeEmbeddings, Value: normalize}
+}
+
+// WithValidateContextWindow builds the OptValidateContextWindow setting.
+func WithValidateContextWindow(validate bool) Option {
+	return Option{Key: OptValidateContextWindow, Value: validate}
+}
+
+// WithValidateJsonSchema builds the OptValidateJsonSchema setting.
+func WithValidateJsonSchema(validate bool) Option {
+	return Option{Key: OptValidateJsonSchema, Value: validate}
+}
+
+// WithStrictDecoding builds the OptStrictDecoding setting.
+func WithStrictDecoding(strict bool) Option {
+	return Option{Key: OptStrictDecoding, Value: strict}
+}
+
+// WithExposeRawResponse builds the OptExposeRawResponse setting.
+func WithExposeRawResponse(expose bool) Option {
+	return Option{Key: OptExposeRawResponse, Value: expose}
+}
+
+// WithStrictOptions builds the OptStrictOptions setting.
+func WithStrictOptions(strict bool) Option {
+	return Option{Key: OptStrictOptions, Value: strict}
+}
+
+// WithBatchConcurrency builds the OptBatchConcurrency option of ChatCompletionsBatch.
+func WithBatchConcurrency(concurrency int) Option {
+	return Option{Key: OptBatchConcurrency, Value: concurrency}
+}
+
+// WithBatchInterval builds the OptBatchInterval option of ChatCompletionsBatch.
+func WithBatchInterval(interval time.Duration) Option {
+	return Option{Key: OptBatchInterval, Value: interval}
+}
+
+// WithBatchFailFast builds the OptBatchFailFast option of ChatCompletionsBatch.
+func WithBatchFailFast(failFast bool) Option {
+	return Option{Key: OptBatchFailFast, Value: failFast}
+}
+
+// WithSummarizeModel builds the OptSummarizeModel option of SummarizeConversation.
+func WithSummarizeModel(model string) Option {
+	return Option{Key: OptSummarizeModel, Value: model}
+}
+
+// WithSummarizeThreshold builds the OptSummarizeThreshold option of SummarizeConversation.
+func WithSummarizeThreshold(threshold int) Option {
+	return Option{Key: OptSummarizeThreshold, Value: threshold}
+}
+
+// WithSummarizeTurns builds the OptSummarizeTurns option of SummarizeConversation.
+func WithSummarizeTurns(turns int) Option {
+	return Option{Key: OptSummarizeTurns, Value: turns}
+}
+
+// WithSummarizePrompt builds the OptSummarizePrompt option of SummarizeConversation.
+func WithSummarizePrompt(prompt string) Option {
+	return Option{Key: OptSummarizePrompt, Value: prompt}
+}
@@ -0,0 +1,73 @@
+package oaiaux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithOptions(t *testing.T) {
+	testName := "TestWithOptions"
+	client, err := NewClient(AzureOpenAI,
+		WithAzureResourceName("my-resource"),
+		WithAzureApiKey("test-key"),
+		WithAzureApiVersion("2024-02-01"),
+		WithAzureDeployments(map[string]string{"gpt-3.5-turbo": "gpt-35-turbo"}),
+		WithTimeout(5*time.Second),
+		WithMaxRetries(2),
+		WithStrictOptions(true),
+	)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	c := client.(*AzureOpenAIClient)
+	if c.resourceName != "my-resource" || c.apiKey != "test-key" || c.apiVersion != "2024-02-01" ||
+		c.deploymentName("gpt-3.5-turbo") != "gpt-35-turbo" || c.httpClient.Timeout != 5*time.Second || c.maxRetries != 2 {
+		t.Fatalf("%s failed: settings not applied %#v", testName, c)
+	}
+
+	if _, err = NewClient(PlatformOpenAI, WithOpenAIApiKey("test-key"), WithOpenAIOrganization("my-org"),
+		WithOpenAIBaseUrl("http://localhost:5123"), WithHeaders(http.Header{"X-Title": []string{"test"}}),
+		WithDefaultModel("gpt-4o-mini"), WithNormalizeEmbeddings(true), WithValidateJsonSchema(true),
+		WithExposeRawResponse(true), WithStrictOptions(true)); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+}
+
+func TestOptHttpClient(t *testing.T) {
+	testName := "TestOptHttpClient"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	calls := 0
+	httpClient := &http.Client{Timeout: 7 * time.Second, Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	signer := func(req *http.Request, body []byte) error { return nil }
+	client, err := NewClient(PlatformOpenAI, WithOpenAIApiKey("test-key"), WithOpenAIBaseUrl(server.URL),
+		WithHttpClient(httpClient), Option{Key: OptRequestSigner, Value: signer})
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	if output := client.ListModels(); output.Error != nil || calls != 1 {
+		t.Fatalf("%s failed: custom client not used (%d calls) / %s", testName, calls, output.Error)
+	}
+	if c := client.(*PlatformOpenAIClient); c.httpClient == httpClient || c.httpClient.Timeout != 7*time.Second {
+		t.Fatalf("%s failed: expected a copy of the supplied client", testName)
+	}
+	if _, ok := httpClient.Transport.(roundTripperFunc); !ok {
+		t.Fatalf("%s failed: supplied client should not be modified", testName)
+	}
+
+	client, _ = NewClient(PlatformOpenAI, WithOpenAIApiKey("test-key"), WithHttpClient(httpClient), WithTimeout(3*time.Second))
+	if c := client.(*PlatformOpenAIClient); c.httpClient.Timeout != 3*time.Second || httpClient.Timeout != 7*time.Second {
+		t.Fatalf("%s failed: OptTimeout should override the client's timeout", testName)
+	}
+	if _, err = NewClient(PlatformOpenAI, WithOpenAIApiKey("test-key"), Option{Key: OptHttpClient, Value: "invalid"}); err == nil {
+		t.Fatalf("%s failed: expected error for invalid setting", testName)
+	}
+}
@@ -0,0 +1,67 @@
+package oaiaux
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Preset is a named bundle of sampling parameters (see ApplyPreset). Nil fields are left untouched when applied.
+type Preset struct {
+	Temperature      *float64
+	TopP             *float64
+	PresencePenalty  *float64
+	FrequencyPenalty *float64
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+const (
+	PresetCreative = "creative"
+	PresetBalanced = "balanced"
+	PresetPrecise  = "precise"
+)
+
+var (
+	presetsLock sync.RWMutex
+	presets     = map[string]Preset{
+		PresetCreative: {Temperature: float64Ptr(0.9), TopP: float64Ptr(1.0), PresencePenalty: float64Ptr(0.6), FrequencyPenalty: float64Ptr(0.3)},
+		PresetBalanced: {Temperature: float64Ptr(0.7), TopP: float64Ptr(1.0), PresencePenalty: float64Ptr(0.0), FrequencyPenalty: float64Ptr(0.0)},
+		PresetPrecise:  {Temperature: float64Ptr(0.2), TopP: float64Ptr(1.0), PresencePenalty: float64Ptr(0.0), FrequencyPenalty: float64Ptr(0.0)},
+	}
+)
+
+// RegisterPreset registers a custom preset, or overrides an existing one. Preset names are case-insensitive.
+func RegisterPreset(name string, preset Preset) {
+	presetsLock.Lock()
+	defer presetsLock.Unlock()
+	presets[strings.ToLower(name)] = preset
+}
+
+// ApplyPreset sets the sampling parameters of the prompt from a named preset.
+//
+// Built-in presets are PresetCreative, PresetBalanced and PresetPrecise; custom ones can be added via RegisterPreset.
+// Only parameters defined by the preset are overridden, other fields of the prompt are kept as-is.
+func ApplyPreset(prompt *ChatPromptInput, preset string) error {
+	presetsLock.RLock()
+	p, ok := presets[strings.ToLower(preset)]
+	presetsLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown preset <%s>", preset)
+	}
+	if p.Temperature != nil {
+		prompt.Temperature = *p.Temperature
+	}
+	if p.TopP != nil {
+		prompt.TopP = *p.TopP
+	}
+	if p.PresencePenalty != nil {
+		prompt.PresencePenalty = *p.PresencePenalty
+	}
+	if p.FrequencyPenalty != nil {
+		prompt.FrequencyPenalty = *p.FrequencyPenalty
+	}
+	return nil
+}
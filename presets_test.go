@@ -0,0 +1,36 @@
+package oaiaux
+
+import "testing"
+
+func TestApplyPreset(t *testing.T) {
+	testName := "TestApplyPreset"
+	testData := []struct {
+		preset                                 string
+		temperature, topP, presence, frequency float64
+	}{
+		{preset: PresetCreative, temperature: 0.9, topP: 1.0, presence: 0.6, frequency: 0.3},
+		{preset: PresetBalanced, temperature: 0.7, topP: 1.0, presence: 0.0, frequency: 0.0},
+		{preset: PresetPrecise, temperature: 0.2, topP: 1.0, presence: 0.0, frequency: 0.0},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.preset, func(t *testing.T) {
+			prompt := &ChatPromptInput{Model: "gpt-3.5-turbo", Temperature: 0.5, PresencePenalty: 1.5, MaxTokens: 200}
+			if err := ApplyPreset(prompt, testCase.preset); err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.preset, err)
+			}
+			if prompt.Temperature != testCase.temperature || prompt.TopP != testCase.topP ||
+				prompt.PresencePenalty != testCase.presence || prompt.FrequencyPenalty != testCase.frequency || prompt.MaxTokens != 200 {
+				t.Fatalf("%s failed: unexpected prompt %#v", testName+"/"+testCase.preset, prompt)
+			}
+		})
+	}
+
+	RegisterPreset("deterministic", Preset{Temperature: float64Ptr(0.0)})
+	prompt := &ChatPromptInput{Temperature: 0.5, TopP: 0.9}
+	if err := ApplyPreset(prompt, "Deterministic"); err != nil || prompt.Temperature != 0.0 || prompt.TopP != 0.9 {
+		t.Fatalf("%s failed: custom preset should only override defined parameters, received %#v / %s", testName, prompt, err)
+	}
+	if err := ApplyPreset(prompt, "unknown"); err == nil {
+		t.Fatalf("%s failed: expected error for unknown preset", testName)
+	}
+}
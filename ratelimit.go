@@ -0,0 +1,197 @@
+package oaiaux
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token-bucket allowing debt: a request larger than the available amount is granted, but subsequent
+// requests have to wait until the debt is paid back.
+type tokenBucket struct {
+	capacity  float64
+	available float64
+	rate      float64 // refill rate, per second
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	rate := float64(perMinute) / 60.0
+	capacity := rate // allow bursts of one second worth of the limit
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tokenBucket{capacity: capacity, available: capacity, rate: rate, last: time.Now()}
+}
+
+// reserve takes n from the bucket and returns how long the caller must wait before proceeding.
+func (b *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+	b.available += now.Sub(b.last).Seconds() * b.rate
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+	b.last = now
+	b.available -= n
+	if b.available >= 0 {
+		return 0
+	}
+	return time.Duration(-b.available / b.rate * float64(time.Second))
+}
+
+// RateLimiter paces API calls to stay within a requests-per-minute (RPM) and tokens-per-minute (TPM) budget.
+//
+// A RateLimiter is safe for concurrent use and can be shared by multiple clients (see OptRateLimiter) so that they
+// collectively respect the budget of one API key. Like OpenAI's own enforcement, limits are applied over short periods:
+// for example, 60 RPM allows one request per second rather than 60 requests at once.
+type RateLimiter struct {
+	lock     sync.Mutex
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// NewRateLimiter creates a new RateLimiter instance. A non-positive limit means "unlimited".
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	return &RateLimiter{requests: newTokenBucket(requestsPerMinute), tokens: newTokenBucket(tokensPerMinute)}
+}
+
+// limitsTokens returns true if this limiter enforces a TPM budget (callers can skip estimating tokens otherwise).
+func (rl *RateLimiter) limitsTokens() bool {
+	return rl.tokens != nil
+}
+
+// Wait blocks until a request consuming the estimated number of tokens can be made within the budget, or until ctx is
+// done (a nil ctx is treated as context.Background()), in which case the context's error is returned. The budget is
+// reserved in either case.
+func (rl *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rl.lock.Lock()
+	now := time.Now()
+	var delay time.Duration
+	if rl.requests != nil {
+		delay = rl.requests.reserve(1, now)
+	}
+	if rl.tokens != nil {
+		if d := rl.tokens.reserve(float64(tokens), now); d > delay {
+			delay = d
+		}
+	}
+	rl.lock.Unlock()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// estimateRequestTokens estimates the number of tokens consumed by a request (prompt tokens plus max completion tokens
+// of every generated completion).
+func estimateRequestTokens(body interface{}) int {
+	switch input := body.(type) {
+	case *ChatPromptInput:
+		n := input.N
+		if n < 1 {
+			n = 1
+		}
+		return CountChatTokens(input.Messages, Option{Key: "model", Value: input.Model}) + (input.MaxTokens+input.MaxCompletionTokens)*n
+	case *PromptInput:
+		bestOf := input.BestOf
+		if bestOf < 1 {
+			bestOf = 1
+		}
+		if len(input.Prompts) > 0 {
+			return CountTokens(strings.Join(input.Prompts, "\n"), Option{Key: "model", Value: input.Model}) +
+				input.MaxTokens*bestOf*len(input.Prompts)
+		}
+		return CountTokens(input.Prompt, Option{Key: "model", Value: input.Model}) + input.MaxTokens*bestOf
+	case *EmbeddingsInput:
+		if len(input.Inputs) > 0 {
+			return CountTokens(strings.Join(input.Inputs, "\n"), Option{Key: "model", Value: input.Model})
+		}
+		return CountTokens(input.Input, Option{Key: "model", Value: input.Model})
+	}
+	return 0
+}
+
+// waitRateLimiter blocks until the request is allowed by the client's rate limiter, if any, or until ctx is done (nil
+// means the call's context, see OptContext). The context's error is returned in the latter case.
+func (bc *BaseClient) waitRateLimiter(ctx context.Context, body interface{}) error {
+	if bc.rateLimiter == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = bc.ctx
+	}
+	tokens := 0
+	if bc.rateLimiter.limitsTokens() {
+		tokens = estimateRequestTokens(body)
+	}
+	return bc.rateLimiter.Wait(ctx, tokens)
+}
+
+/*----------------------------------------------------------------------*/
+
+// RateLimitInfo captures the rate-limit status reported by the API via "x-ratelimit-*" response headers.
+//
+// Fields are zero if the corresponding header is absent (e.g. Azure OpenAI reports remaining requests/tokens only).
+type RateLimitInfo struct {
+	LimitRequests     int
+	LimitTokens       int
+	RemainingRequests int
+	RemainingTokens   int
+	// ResetRequests is the time until the request limit resets to its initial state.
+	ResetRequests time.Duration
+	// ResetTokens is the time until the token limit resets to its initial state.
+	ResetTokens time.Duration
+}
+
+// RateLimit parses the rate-limit status from the response headers. nil is returned if the response has no
+// rate-limit headers.
+func (r BaseResponse) RateLimit() *RateLimitInfo {
+	if r.Headers == nil {
+		return nil
+	}
+	found := false
+	getInt := func(name string) int {
+		value := r.Headers.Get(name)
+		if value == "" {
+			return 0
+		}
+		found = true
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+	getDuration := func(name string) time.Duration {
+		value := r.Headers.Get(name)
+		if value == "" {
+			return 0
+		}
+		found = true
+		d, _ := time.ParseDuration(value)
+		return d
+	}
+	info := &RateLimitInfo{
+		LimitRequests:     getInt("x-ratelimit-limit-requests"),
+		LimitTokens:       getInt("x-ratelimit-limit-tokens"),
+		RemainingRequests: getInt("x-ratelimit-remaining-requests"),
+		RemainingTokens:   getInt("x-ratelimit-remaining-tokens"),
+		ResetRequests:     getDuration("x-ratelimit-reset-requests"),
+		ResetTokens:       getDuration("x-ratelimit-reset-tokens"),
+	}
+	if !found {
+		return nil
+	}
+	return info
+}
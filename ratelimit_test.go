@@ -0,0 +1,105 @@
+package oaiaux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_SharedByClients(t *testing.T) {
+	testName := "TestRateLimiter_SharedByClients"
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}
+	limiter := NewRateLimiter(3000, 0) // 50 requests per second
+	client1, server1 := newTestPlatformClient(t, handler, Option{Key: OptRateLimiter, Value: limiter})
+	defer server1.Close()
+	client2, server2 := newTestPlatformClient(t, handler, Option{Key: OptRateLimiter, Value: limiter})
+	defer server2.Close()
+
+	const numRequestsPerClient = 50
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for _, client := range []Client{client1, client2} {
+		for i := 0; i < numRequestsPerClient; i++ {
+			wg.Add(1)
+			go func(client Client) {
+				defer wg.Done()
+				client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+			}(client)
+		}
+	}
+	wg.Wait()
+	// 100 requests at 50 rps with a burst of 50: at least 1 second
+	if elapsed := time.Since(start); elapsed < 950*time.Millisecond {
+		t.Fatalf("%s failed: combined rate exceeded the budget (100 requests in %s)", testName, elapsed)
+	}
+}
+
+func TestRateLimiter_Tokens(t *testing.T) {
+	testName := "TestRateLimiter_Tokens"
+	limiter := NewRateLimiter(0, 6000) // 100 tokens per second, burst of 100
+	start := time.Now()
+	_ = limiter.Wait(context.Background(), 100)
+	_ = limiter.Wait(context.Background(), 50)
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("%s failed: expected about 500ms of wait but received %s", testName, elapsed)
+	}
+
+	// waits are bounded by the context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := limiter.Wait(ctx, 1000); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Fatalf("%s failed: expected context.DeadlineExceeded but received %v after %s", testName, err, time.Since(start))
+	}
+}
+
+func TestEstimateRequestTokens(t *testing.T) {
+	testName := "TestEstimateRequestTokens"
+	messages := []ChatMessage{{Role: "user", Content: "Hello"}}
+	promptTokens := CountChatTokens(messages, Option{Key: "model", Value: "gpt-4o"})
+	if tokens := estimateRequestTokens(&ChatPromptInput{Model: "gpt-4o", Messages: messages, MaxTokens: 100, N: 3}); tokens != promptTokens+300 {
+		t.Fatalf("%s failed: expected %d tokens for 3 choices but received %d", testName, promptTokens+300, tokens)
+	}
+	promptTokens = CountTokens("Hello", Option{Key: "model", Value: "gpt-3.5-turbo-instruct"})
+	if tokens := estimateRequestTokens(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompt: "Hello", MaxTokens: 100}); tokens != promptTokens+100 {
+		t.Fatalf("%s failed: expected %d tokens when BestOf is unset but received %d", testName, promptTokens+100, tokens)
+	}
+}
+
+func TestBaseResponse_RateLimit(t *testing.T) {
+	testName := "TestBaseResponse_RateLimit"
+	status := http.StatusOK
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req-123")
+		w.Header().Set("x-ratelimit-limit-requests", "500")
+		w.Header().Set("x-ratelimit-remaining-requests", "499")
+		w.Header().Set("x-ratelimit-remaining-tokens", "29000")
+		w.Header().Set("x-ratelimit-reset-tokens", "6m0s")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			_, _ = w.Write([]byte(`{"error":{"message":"Bad request","type":"invalid_request_error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}, Option{Key: OptMaxRetries, Value: 0})
+	defer server.Close()
+
+	for _, status = range []int{http.StatusOK, http.StatusBadRequest} {
+		output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"})
+		if output.StatusCode != status || output.Headers.Get("x-request-id") != "req-123" {
+			t.Fatalf("%s failed: unexpected status %d / headers %#v", testName, output.StatusCode, output.Headers)
+		}
+		expected := RateLimitInfo{LimitRequests: 500, RemainingRequests: 499, RemainingTokens: 29000, ResetTokens: 6 * time.Minute}
+		if rl := output.RateLimit(); rl == nil || *rl != expected {
+			t.Fatalf("%s failed: expected %#v but received %#v", testName, expected, rl)
+		}
+	}
+	if rl := (BaseResponse{}).RateLimit(); rl != nil {
+		t.Fatalf("%s failed: expected nil but received %#v", testName, rl)
+	}
+}
@@ -0,0 +1,103 @@
+package oaiaux
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// rawRequest sends a request with a JSON body (none if body is nil) and unmarshals the JSON response body into out
+// (unless out is nil or the response body is empty), see Client.RawRequest.
+func (bc *BaseClient) rawRequest(ctx context.Context, method, apiUrl string, header http.Header, body, out interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var reader io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(js)
+	}
+	if err := bc.waitRateLimiter(ctx, body); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiUrl, reader)
+	if err != nil {
+		return err
+	}
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := bc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if apiErr := parseAPIErrorBody(respBody); apiErr != nil {
+			return apiErr
+		}
+		return fmt.Errorf("%s %s failed with status %d", method, req.URL.Path, resp.StatusCode)
+	}
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// rawPath normalizes the path of a RawRequest call to start with "/".
+func rawPath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+/*----------------------------------------------------------------------*/
+
+// buildUrlRaw builds the url of a RawRequest call: path is relative to "{azure-base-url}/openai", and the api-version
+// query parameter is added unless path specifies it.
+func (c *AzureOpenAIClient) buildUrlRaw(resourceName, path string) string {
+	apiUrl := c.buildBaseUrl(resourceName) + "/openai" + rawPath(path)
+	if u, err := url.Parse(apiUrl); err == nil && u.Query().Get("api-version") != "" {
+		return apiUrl
+	}
+	if strings.Contains(apiUrl, "?") {
+		return apiUrl + "&api-version=" + url.QueryEscape(c.apiVersion)
+	}
+	return apiUrl + "?api-version=" + url.QueryEscape(c.apiVersion)
+}
+
+// RawRequest implements Client.RawRequest
+//
+// Note: path is relative to "{azure-base-url}/openai" (e.g. "/vector_stores"), and raw requests are sent to the
+// primary resource only (no failover).
+func (c *AzureOpenAIClient) RawRequest(ctx context.Context, method, path string, body, out interface{}, opts ...Option) error {
+	c = c.forCall(opts)
+	return c.rawRequest(ctx, method, c.buildUrlRaw(c.resourceName, path), c.buildRequestHeaders(), body, out)
+}
+
+/*----------------------------------------------------------------------*/
+
+// RawRequest implements Client.RawRequest
+//
+// Note: path is relative to the base url (see OptOpenAIBaseUrl), e.g. "/vector_stores".
+func (c *PlatformOpenAIClient) RawRequest(ctx context.Context, method, path string, body, out interface{}, opts ...Option) error {
+	c = c.forCall(opts)
+	return c.rawRequest(ctx, method, c.baseUrl+rawPath(path), c.buildRequestHeaders(), body, out)
+}
@@ -0,0 +1,85 @@
+package oaiaux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPlatformOpenAIClient_RawRequest(t *testing.T) {
+	testName := "TestPlatformOpenAIClient_RawRequest"
+	var request string
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		request = r.Method + " " + r.URL.Path
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("%s failed: unexpected headers %#v", testName, r.Header)
+		}
+		if r.URL.Path == "/vector_stores/unknown" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"No vector store found","type":"invalid_request_error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"vs_abc123","object":"vector_store","name":"Support FAQ"}`))
+	})
+	defer server.Close()
+
+	var out struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	err := client.RawRequest(context.Background(), http.MethodPost, "vector_stores", map[string]interface{}{"name": "Support FAQ"}, &out)
+	if err != nil || out.Id != "vs_abc123" || request != "POST /vector_stores" || received["name"] != "Support FAQ" {
+		t.Fatalf("%s failed: %#v / %#v / %s / %#v", testName, err, out, request, received)
+	}
+	if err = client.RawRequest(context.Background(), http.MethodDelete, "/vector_stores/vs_abc123", nil, nil); err != nil || request != "DELETE /vector_stores/vs_abc123" {
+		t.Fatalf("%s failed: %#v / %s", testName, err, request)
+	}
+	var apiErr *APIError
+	if err = client.RawRequest(context.Background(), http.MethodGet, "/vector_stores/unknown", nil, &out); !errors.As(err, &apiErr) {
+		t.Fatalf("%s failed: expected APIError but received %#v", testName, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = client.RawRequest(ctx, http.MethodGet, "/vector_stores", nil, &out); !errors.Is(err, context.Canceled) {
+		t.Fatalf("%s failed: expected context.Canceled but received %#v", testName, err)
+	}
+}
+
+func TestAzureOpenAIClient_RawRequest(t *testing.T) {
+	testName := "TestAzureOpenAIClient_RawRequest"
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		if r.Header.Get("api-key") != "azure-key" {
+			t.Errorf("%s failed: unexpected headers %#v", testName, r.Header)
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureBaseUrl, Value: server.URL}, Option{Key: OptAzureApiKey, Value: "azure-key"},
+		Option{Key: OptAzureApiVersion, Value: "2024-10-21"})
+
+	_ = client.RawRequest(context.Background(), http.MethodGet, "/vector_stores", nil, nil)
+	_ = client.RawRequest(context.Background(), http.MethodGet, "/vector_stores?limit=2", nil, nil)
+	_ = client.RawRequest(context.Background(), http.MethodGet, "/vector_stores?api-version=2025-01-01-preview", nil, nil)
+	expected := []string{
+		"GET /openai/vector_stores?api-version=2024-10-21",
+		"GET /openai/vector_stores?limit=2&api-version=2024-10-21",
+		"GET /openai/vector_stores?api-version=2025-01-01-preview",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("%s failed: expected %d requests but received %#v", testName, len(expected), requests)
+	}
+	for i, r := range requests {
+		if r != expected[i] {
+			t.Fatalf("%s failed: expected request %q but received %q", testName, expected[i], r)
+		}
+	}
+}
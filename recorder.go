@@ -0,0 +1,80 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// RecordFilter decides whether a chat-completions call should be recorded by a FineTuningRecorder.
+type RecordFilter func(prompt *ChatPromptInput, output *ChatCompletionsOutput) bool
+
+// FineTuningRecorder captures chat-completions prompt/response pairs as a JSONL dataset in the OpenAI fine-tuning
+// format: one {"messages": [...]} object per line, the last message being the assistant's reply (see OptRecorder).
+//
+// A FineTuningRecorder is safe for concurrent use; each record is written as a single line.
+type FineTuningRecorder struct {
+	lock   sync.Mutex
+	w      io.Writer
+	filter RecordFilter
+}
+
+// NewFineTuningRecorder creates a new FineTuningRecorder writing records to w.
+//
+// If filter is not nil, only calls for which the filter returns true are recorded.
+func NewFineTuningRecorder(w io.Writer, filter RecordFilter) *FineTuningRecorder {
+	return &FineTuningRecorder{w: w, filter: filter}
+}
+
+// NewFileFineTuningRecorder creates a new FineTuningRecorder appending records to a file (created if not exists).
+func NewFileFineTuningRecorder(path string, filter RecordFilter) (*FineTuningRecorder, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewFineTuningRecorder(f, filter), nil
+}
+
+type fineTuningRecord struct {
+	Messages []ChatMessage `json:"messages"`
+}
+
+// Record writes a prompt/response pair. Failed calls, calls without choices and calls rejected by the filter are skipped.
+func (r *FineTuningRecorder) Record(prompt *ChatPromptInput, output *ChatCompletionsOutput) error {
+	if isFailedOutput(output.BaseResponse) || len(output.Choices) == 0 {
+		return nil
+	}
+	if r.filter != nil && !r.filter(prompt, output) {
+		return nil
+	}
+	record := fineTuningRecord{Messages: make([]ChatMessage, 0, len(prompt.Messages)+1)}
+	record.Messages = append(record.Messages, prompt.Messages...)
+	record.Messages = append(record.Messages, output.Choices[0].Message)
+	js, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_, err = r.w.Write(append(js, '\n'))
+	return err
+}
+
+// Close closes the underlying writer, if it is an io.Closer.
+func (r *FineTuningRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if closer, ok := r.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// recordChatCompletions records the call with the client's recorder, if any.
+func (bc *BaseClient) recordChatCompletions(prompt *ChatPromptInput, output *ChatCompletionsOutput) *ChatCompletionsOutput {
+	if bc.recorder != nil {
+		_ = bc.recorder.Record(prompt, output)
+	}
+	return output
+}
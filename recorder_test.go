@@ -0,0 +1,58 @@
+package oaiaux
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFineTuningRecorder(t *testing.T) {
+	testName := "TestFineTuningRecorder"
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	filter := func(prompt *ChatPromptInput, output *ChatCompletionsOutput) bool {
+		return prompt.User != "excluded"
+	}
+	recorder, err := NewFileFineTuningRecorder(path, filter)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"GPT is a language model."}}]}`))
+	}, Option{Key: OptRecorder, Value: recorder})
+	defer server.Close()
+
+	messages := []ChatMessage{{Role: "system", Content: "You are a friendly assistant."}, {Role: "user", Content: "What is GPT?"}}
+	client.ChatCompletions(&ChatPromptInput{Model: "gpt-3.5-turbo", Messages: messages})
+	client.ChatCompletions(&ChatPromptInput{Model: "gpt-3.5-turbo", Messages: messages, User: "excluded"})
+	if err = recorder.Close(); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	defer func() { _ = f.Close() }()
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 1 {
+		t.Fatalf("%s failed: expected 1 record but received %#v", testName, len(lines))
+	}
+	var record map[string][]map[string]interface{}
+	if err = json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	if len(record) != 1 || len(record["messages"]) != 3 {
+		t.Fatalf("%s failed: unexpected record %s", testName, lines[0])
+	}
+	last := record["messages"][2]
+	if last["role"] != "assistant" || last["content"] != "GPT is a language model." {
+		t.Fatalf("%s failed: unexpected last message %#v", testName, last)
+	}
+}
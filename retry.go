@@ -0,0 +1,134 @@
+package oaiaux
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+const (
+	defaultRetryBaseDelay = 1 * time.Second
+	maxRetryDelay         = 60 * time.Second
+)
+
+// sendWithRetries calls send, applying rate limiting (see OptRateLimiter) before each attempt, and retries the failures
+// accepted by isRetryable (isRetryableResponse or isRateLimitedResponse) up to OptMaxRetries times. body is the
+// request's payload, used to estimate the number of tokens.
+func (bc *BaseClient) sendWithRetries(body interface{}, isRetryable func(*gjrc.GjrcResponse) bool, send func() *gjrc.GjrcResponse) *gjrc.GjrcResponse {
+	for attempt := 0; ; attempt++ {
+		// if the call's context is done while waiting, the request is aborted right away (see contextTransport)
+		_ = bc.waitRateLimiter(nil, body)
+		resp := send()
+		awaitResponse(resp)
+		if attempt >= bc.maxRetries || !isRetryable(resp) {
+			return resp
+		}
+		if !bc.sleep(bc.retryDelay(resp, attempt)) {
+			// the call's context is done: give up retrying, the last response is returned
+			return resp
+		}
+	}
+}
+
+// sleep waits for the delay, or until the call's context (see OptContext) is done. false is returned if the context
+// is done.
+func (bc *BaseClient) sleep(delay time.Duration) bool {
+	if bc.ctx == nil {
+		time.Sleep(delay)
+		return true
+	}
+	if bc.ctx.Err() != nil {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-bc.ctx.Done():
+		return false
+	}
+}
+
+// isRetryableResponse returns true if the request failed with a transient error (connection error, 429, 500, 502, 503
+// or 504). Other errors (e.g. 400, 401 or 501) are not retryable. It is shared by retries and Azure failover.
+func isRetryableResponse(resp *gjrc.GjrcResponse) bool {
+	if responseError(resp) != nil || resp.HttpResponse() == nil {
+		return true
+	}
+	switch resp.StatusCode() {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isRateLimitedResponse returns true if the request was rejected with 429, i.e. without being processed. Unlike
+// isRetryableResponse, connection errors and 5xx are not retryable: the server may have processed the request already,
+// hence replaying a non-idempotent request (e.g. creating a batch) could duplicate its effects.
+func isRateLimitedResponse(resp *gjrc.GjrcResponse) bool {
+	return resp.HttpResponse() != nil && resp.StatusCode() == http.StatusTooManyRequests
+}
+
+// parseRetryAfter returns the delay requested by the server, if any: the "retry-after-ms" header (in milliseconds,
+// sent by OpenAI) takes precedence over the standard Retry-After header (in seconds, or an HTTP-date).
+func parseRetryAfter(resp *gjrc.GjrcResponse) (time.Duration, bool) {
+	if resp.HttpResponse() == nil {
+		return 0, false
+	}
+	return parseRetryAfterHeader(resp.HttpResponse().Header, time.Now())
+}
+
+// parseRetryAfterHeader parses the delay requested by the server (see parseRetryAfter), capped at maxRetryDelay.
+func parseRetryAfterHeader(header http.Header, now time.Time) (time.Duration, bool) {
+	delay, ok := parseRetryAfterValue(header, now)
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay, ok
+}
+
+func parseRetryAfterValue(header http.Header, now time.Time) (time.Duration, bool) {
+	if value := strings.TrimSpace(header.Get("retry-after-ms")); value != "" {
+		if ms, err := strconv.ParseFloat(value, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// retryDelay calculates the delay before the next attempt: exponential backoff with jitter, unless the server
+// specifies a Retry-After delay. Either way, the delay is capped at maxRetryDelay.
+func (bc *BaseClient) retryDelay(resp *gjrc.GjrcResponse, attempt int) time.Duration {
+	if delay, ok := parseRetryAfter(resp); ok {
+		return delay
+	}
+	delay := bc.retryBaseDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// jitter: randomize the delay in the range [delay/2, delay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
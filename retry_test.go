@@ -0,0 +1,161 @@
+package oaiaux
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOptMaxRetries(t *testing.T) {
+	testName := "TestOptMaxRetries"
+	testData := []struct {
+		name             string
+		statusCodes      []int
+		maxRetries       int
+		expectedStatus   int
+		expectedAttempts int32
+	}{
+		{name: "retry-5xx", statusCodes: []int{500, 503, 200}, maxRetries: 3, expectedStatus: 200, expectedAttempts: 3},
+		{name: "retry-429", statusCodes: []int{429, 200}, maxRetries: 3, expectedStatus: 200, expectedAttempts: 2},
+		{name: "exhausted", statusCodes: []int{502, 502, 502, 502}, maxRetries: 2, expectedStatus: 502, expectedAttempts: 3},
+		{name: "no-retry-400", statusCodes: []int{400, 200}, maxRetries: 3, expectedStatus: 400, expectedAttempts: 1},
+		{name: "no-retry-401", statusCodes: []int{401, 200}, maxRetries: 3, expectedStatus: 401, expectedAttempts: 1},
+		{name: "disabled", statusCodes: []int{500, 200}, maxRetries: 0, expectedStatus: 500, expectedAttempts: 1},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			var attempts int32
+			client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+				i := atomic.AddInt32(&attempts, 1) - 1
+				status := testCase.statusCodes[i]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+			}, Option{Key: OptMaxRetries, Value: testCase.maxRetries}, Option{Key: OptRetryBaseDelay, Value: time.Millisecond})
+			defer server.Close()
+
+			output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+			if output.StatusCode != testCase.expectedStatus || attempts != testCase.expectedAttempts {
+				t.Fatalf("%s failed: expected status %#v after %#v attempts but received %#v after %#v attempts",
+					testName+"/"+testCase.name, testCase.expectedStatus, testCase.expectedAttempts, output.StatusCode, attempts)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterHeader(t *testing.T) {
+	testName := "TestParseRetryAfterHeader"
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	testData := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "absent", header: http.Header{}, ok: false},
+		{name: "seconds", header: http.Header{"Retry-After": {"2"}}, expected: 2 * time.Second, ok: true},
+		{name: "fractional-seconds", header: http.Header{"Retry-After": {"0.5"}}, expected: 500 * time.Millisecond, ok: true},
+		{name: "http-date", header: http.Header{"Retry-After": {"Wed, 01 May 2024 10:00:07 GMT"}}, expected: 7 * time.Second, ok: true},
+		{name: "past-http-date", header: http.Header{"Retry-After": {"Wed, 01 May 2024 09:59:00 GMT"}}, expected: 0, ok: true},
+		{name: "milliseconds", header: http.Header{"Retry-After": {"2"}, "Retry-After-Ms": {"1500"}}, expected: 1500 * time.Millisecond, ok: true},
+		{name: "invalid", header: http.Header{"Retry-After": {"soon"}}, ok: false},
+		{name: "negative", header: http.Header{"Retry-After": {"-1"}}, ok: false},
+		{name: "capped", header: http.Header{"Retry-After": {"3600"}}, expected: maxRetryDelay, ok: true},
+		{name: "capped-milliseconds", header: http.Header{"Retry-After-Ms": {"120000"}}, expected: maxRetryDelay, ok: true},
+	}
+	for _, testCase := range testData {
+		delay, ok := parseRetryAfterHeader(testCase.header, now)
+		if delay != testCase.expected || ok != testCase.ok {
+			t.Fatalf("%s failed: expected %#v/%#v but received %#v/%#v", testName+"/"+testCase.name, testCase.expected, testCase.ok, delay, ok)
+		}
+	}
+}
+
+func TestOptContext(t *testing.T) {
+	testName := "TestOptContext"
+	var attempts int32
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`))
+	}, Option{Key: OptMaxRetries, Value: 3})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"}, WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("%s failed: waiting for Retry-After was not cancelled (%s)", testName, elapsed)
+	}
+	if output.StatusCode != http.StatusTooManyRequests || output.Error == nil || atomic.LoadInt32(&attempts) != 1 {
+		t.Fatalf("%s failed: expected the 429 response of the only attempt but received %#v / %#v after %d attempts",
+			testName, output.StatusCode, output.Error, attempts)
+	}
+
+	// in-flight requests are aborted too
+	slowClient, slowServer := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	defer slowServer.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	output = slowClient.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"}, WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > time.Second || !errors.Is(output.Error, context.DeadlineExceeded) {
+		t.Fatalf("%s failed: expected the in-flight request to be aborted but received %#v after %s", testName, output.Error, elapsed)
+	}
+}
+
+func TestPostJsonOnce(t *testing.T) {
+	testName := "TestPostJsonOnce"
+	var attempts int32
+	statusCodes := []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusOK}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		i := atomic.AddInt32(&attempts, 1) - 1
+		if statusCodes[i] == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(statusCodes[i])
+		_, _ = w.Write([]byte(`{"id":"batch_abc123","object":"batch"}`))
+	}, Option{Key: OptMaxRetries, Value: 3}, Option{Key: OptRetryBaseDelay, Value: time.Millisecond})
+	defer server.Close()
+
+	// the 429 attempt was not processed, hence is retried; the 5xx one may have been, hence is not replayed
+	bc := client.(*PlatformOpenAIClient).BaseClient
+	resp := bc.postJsonOnce(server.URL+"/batches", bc.newRequestHeader(), map[string]interface{}{"input_file_id": "file-abc123"})
+	if resp.StatusCode() != http.StatusInternalServerError || atomic.LoadInt32(&attempts) != 2 {
+		t.Fatalf("%s failed: expected status 500 after 2 attempts but received %d after %d attempts", testName, resp.StatusCode(), attempts)
+	}
+}
+
+// assertNotReplayed checks that each of the calls, failing with 503 on a client retrying transient failures, is sent
+// only once (see postJsonOnce).
+func assertNotReplayed(t *testing.T, testName string, calls map[string]func(client Client)) {
+	var attempts int32
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":{"message":"The server is overloaded","type":"server_error"}}`))
+	}, Option{Key: OptMaxRetries, Value: 3}, Option{Key: OptRetryBaseDelay, Value: time.Millisecond})
+	defer server.Close()
+
+	for name, call := range calls {
+		atomic.StoreInt32(&attempts, 0)
+		call(client)
+		if n := atomic.LoadInt32(&attempts); n != 1 {
+			t.Fatalf("%s failed: expected 1 attempt but received %d", testName+"/"+name, n)
+		}
+	}
+}
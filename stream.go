@@ -0,0 +1,362 @@
+package oaiaux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sseDone is the data payload signalling the end of an OpenAI event stream.
+const sseDone = "[DONE]"
+
+// SseReader decodes a stream of server-sent events (SSE), such as the responses of streamed API calls, e.g. to build
+// streaming on top of endpoints not wrapped by this package.
+//
+// Only the "data:" fields of events are retained: the data of an event spanning multiple "data:" lines are joined with
+// "\n". Comment lines (starting with ":") and other fields are ignored. SseReader is not safe for concurrent use.
+type SseReader struct {
+	scanner *bufio.Scanner
+}
+
+// NewSseReader creates a new SseReader reading events from r (events of up to 16MB are supported).
+func NewSseReader(r io.Reader) *SseReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &SseReader{scanner: scanner}
+}
+
+// Next returns the data of the next event. io.EOF is returned when the stream ends or the "[DONE]" event is received.
+func (r *SseReader) Next() (string, error) {
+	var data []string
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if len(data) == 0 {
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment line
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", io.EOF
+	}
+	event := strings.Join(data, "\n")
+	if event == sseDone {
+		return "", io.EOF
+	}
+	return event, nil
+}
+
+/*----------------------------------------------------------------------*/
+
+// StreamOptions configures a streamed 'chat-completions' API call.
+type StreamOptions struct {
+	// IncludeUsage, if true, makes the API send the token usage of the call in a final chunk, whose Choices is empty
+	// (see ChatCompletionsStreamOutput.Usage).
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ChatCompletionsChunk is a chunk of a streamed 'chat-completions' API call.
+//
+// Usage is only set in the final chunk, if requested via StreamOptions.
+type ChatCompletionsChunk struct {
+	Id                string `json:"id"`
+	Object            string `json:"object"`
+	Created           int64  `json:"created"`
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Delta        ChatMessage   `json:"delta"`
+		Index        int           `json:"index"`
+		FinishReason string        `json:"finish_reason"`
+		Logprobs     *ChatLogprobs `json:"logprobs"`
+	} `json:"choices"`
+	Usage      *ChatCompletionsUsage `json:"usage"`
+	receivedAt time.Time
+}
+
+// ChatCompletionsStreamOutput captures the output of a streamed 'chat-completions' API call.
+//
+// Chunks are delivered via the Chunks channel, which is closed when the stream ends. Error is updated if the stream
+// fails mid-way, hence it should be checked again after Chunks is closed. Chunks must be drained to release the
+// underlying connection.
+//
+// Usage is set when the chunk carrying the token usage is received (see StreamOptions.IncludeUsage), hence it should
+// be read after Chunks is closed.
+type ChatCompletionsStreamOutput struct {
+	BaseResponse
+	Chunks    <-chan *ChatCompletionsChunk
+	Usage     *ChatCompletionsUsage
+	startTime time.Time
+	endTime   time.Time
+}
+
+// StreamStats captures timing metrics of a streamed API call.
+type StreamStats struct {
+	// TimeToFirstToken is the duration from sending the request until the first content token is received.
+	TimeToFirstToken time.Duration
+	// TotalDuration is the duration from sending the request until the stream ends.
+	TotalDuration time.Duration
+	// TokenCount is the number of received chunks carrying content (each chunk normally carries one token).
+	TokenCount int
+	// InterTokenLatencies holds the durations between consecutive content tokens.
+	InterTokenLatencies []time.Duration
+	// MeanInterTokenLatency is the average of InterTokenLatencies.
+	MeanInterTokenLatency time.Duration
+}
+
+func (stats *StreamStats) addToken(startTime, lastTokenTime, receivedAt time.Time) {
+	if stats.TokenCount == 0 {
+		stats.TimeToFirstToken = receivedAt.Sub(startTime)
+	} else {
+		stats.InterTokenLatencies = append(stats.InterTokenLatencies, receivedAt.Sub(lastTokenTime))
+	}
+	stats.TokenCount++
+}
+
+func (stats *StreamStats) finish(startTime, endTime time.Time) *StreamStats {
+	stats.TotalDuration = endTime.Sub(startTime)
+	if n := len(stats.InterTokenLatencies); n > 0 {
+		var total time.Duration
+		for _, d := range stats.InterTokenLatencies {
+			total += d
+		}
+		stats.MeanInterTokenLatency = total / time.Duration(n)
+	}
+	return stats
+}
+
+// CollectChatStream reads the stream until it ends and assembles the chunks into a ChatCompletionsOutput: content and
+// tool-call deltas are merged (tool-call arguments are concatenated by index). Timing metrics of the stream, counting
+// both content and tool-call deltas as tokens, are returned along with the output.
+func CollectChatStream(stream *ChatCompletionsStreamOutput) (*ChatCompletionsOutput, *StreamStats) {
+	output := &ChatCompletionsOutput{Object: "chat.completion"}
+	stats := &StreamStats{}
+	var lastTokenTime time.Time
+	for chunk := range stream.Chunks {
+		output.Id, output.Created, output.Model = chunk.Id, chunk.Created, chunk.Model
+		output.SystemFingerprint = chunk.SystemFingerprint
+		if chunk.Usage != nil {
+			output.Usage = chunk.Usage
+		}
+		hasContent := false
+		for _, choice := range chunk.Choices {
+			for len(output.Choices) <= choice.Index {
+				output.Choices = append(output.Choices, ChatCompletionsChoice{Index: len(output.Choices)})
+			}
+			c := &output.Choices[choice.Index]
+			if choice.Delta.Role != "" {
+				c.Message.Role = choice.Delta.Role
+			}
+			c.Message.Content += choice.Delta.Content
+			c.Message.Refusal += choice.Delta.Refusal
+			for i, delta := range choice.Delta.ToolCalls {
+				index := i
+				if delta.Index != nil {
+					index = *delta.Index
+				}
+				for len(c.Message.ToolCalls) <= index {
+					c.Message.ToolCalls = append(c.Message.ToolCalls, ToolCall{})
+				}
+				toolCall := &c.Message.ToolCalls[index]
+				if delta.Id != "" {
+					toolCall.Id = delta.Id
+				}
+				if delta.Type != "" {
+					toolCall.Type = delta.Type
+				}
+				toolCall.Function.Name += delta.Function.Name
+				toolCall.Function.Arguments += delta.Function.Arguments
+			}
+			if choice.FinishReason != "" {
+				c.FinishReason = choice.FinishReason
+			}
+			if choice.Logprobs != nil {
+				if c.Logprobs == nil {
+					c.Logprobs = &ChatLogprobs{}
+				}
+				c.Logprobs.Content = append(c.Logprobs.Content, choice.Logprobs.Content...)
+				c.Logprobs.Refusal = append(c.Logprobs.Refusal, choice.Logprobs.Refusal...)
+			}
+			hasContent = hasContent || choice.Delta.Content != "" || choice.Delta.Refusal != "" || len(choice.Delta.ToolCalls) > 0
+		}
+		if hasContent {
+			stats.addToken(stream.startTime, lastTokenTime, chunk.receivedAt)
+			lastTokenTime = chunk.receivedAt
+		}
+	}
+	output.BaseResponse = stream.BaseResponse
+	return output, stats.finish(stream.startTime, stream.endTime)
+}
+
+/*----------------------------------------------------------------------*/
+
+// openStream sends a JSON POST request expecting a streamed response (by default, an event-stream; supply an "Accept"
+// header to expect another content type). The caller is responsible for closing the response body.
+func (bc *BaseClient) openStream(apiUrl string, header http.Header, body interface{}) (*http.Response, error) {
+	if err := bc.waitRateLimiter(nil, body); err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, apiUrl, bytes.NewReader(js))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	return bc.httpClient.Do(req)
+}
+
+func (bc *BaseClient) streamChatCompletions(apiUrl string, header http.Header, prompt *ChatPromptInput) *ChatCompletionsStreamOutput {
+	streamPrompt := *prompt
+	streamPrompt.Stream = true
+	ch := make(chan *ChatCompletionsChunk)
+	stream := &ChatCompletionsStreamOutput{Chunks: ch, startTime: time.Now()}
+	err := bc.validateChatPrompt(prompt)
+	var resp *http.Response
+	if err == nil {
+		resp, err = bc.openStream(apiUrl, header, &streamPrompt)
+	}
+	if err != nil {
+		stream.Error, stream.endTime = err, time.Now()
+		close(ch)
+		return stream
+	}
+	stream.StatusCode, stream.Headers, stream.RequestId = resp.StatusCode, resp.Header, requestId(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		stream.Error, stream.endTime = streamError(resp), time.Now()
+		close(ch)
+		return stream
+	}
+	go func() {
+		defer close(ch)
+		stream.Error, stream.endTime = readEvents(resp.Body, func(data string, now time.Time) error {
+			chunk := &ChatCompletionsChunk{receivedAt: now}
+			if err := json.Unmarshal([]byte(data), chunk); err != nil {
+				return err
+			}
+			if chunk.Usage != nil {
+				stream.Usage = chunk.Usage
+			}
+			ch <- chunk
+			return nil
+		})
+	}()
+	return stream
+}
+
+// streamError reads and closes the body of a stream that failed to open (non-200 status), returning the API error it
+// carries, or a generic error if the body is not an API error.
+func streamError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if apiErr := parseAPIErrorBody(body); apiErr != nil {
+		return apiErr
+	}
+	return fmt.Errorf("status %d", resp.StatusCode)
+}
+
+// readEvents reads the event stream until it ends, passing the data of each event to handle, then closes body.
+// The error (nil if the stream ended normally) and the time the stream ended are returned.
+func readEvents(body io.ReadCloser, handle func(data string, now time.Time) error) (error, time.Time) {
+	defer func() { _ = body.Close() }()
+	reader := NewSseReader(body)
+	for {
+		data, err := reader.Next()
+		now := time.Now()
+		if err == io.EOF {
+			return nil, now
+		}
+		if err == nil {
+			err = handle(data, now)
+		}
+		if err != nil {
+			return err, now
+		}
+	}
+}
+
+/*----------------------------------------------------------------------*/
+
+// CompletionsChunk is a chunk of a streamed 'completions' API call.
+type CompletionsChunk struct {
+	Id      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Text         string               `json:"text"`
+		Index        int                  `json:"index"`
+		FinishReason string               `json:"finish_reason"`
+		LogProbs     *CompletionsLogprobs `json:"logprobs"`
+	} `json:"choices"`
+	receivedAt time.Time
+}
+
+// CompletionsStreamOutput captures the output of a streamed 'completions' API call.
+//
+// Chunks are delivered via the Chunks channel, which is closed when the stream ends; each chunk carries the next
+// piece of text of its choice. Like ChatCompletionsStreamOutput, Error should be checked again after Chunks is closed,
+// and Chunks must be drained to release the underlying connection.
+type CompletionsStreamOutput struct {
+	BaseResponse
+	Chunks    <-chan *CompletionsChunk
+	startTime time.Time
+	endTime   time.Time
+}
+
+func (bc *BaseClient) streamCompletions(apiUrl string, header http.Header, prompt *PromptInput) *CompletionsStreamOutput {
+	streamPrompt := *prompt
+	streamPrompt.Stream = true
+	ch := make(chan *CompletionsChunk)
+	stream := &CompletionsStreamOutput{Chunks: ch, startTime: time.Now()}
+	err := bc.validatePrompt(prompt)
+	var resp *http.Response
+	if err == nil {
+		resp, err = bc.openStream(apiUrl, header, &streamPrompt)
+	}
+	if err != nil {
+		stream.Error, stream.endTime = err, time.Now()
+		close(ch)
+		return stream
+	}
+	stream.StatusCode, stream.Headers, stream.RequestId = resp.StatusCode, resp.Header, requestId(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		stream.Error, stream.endTime = streamError(resp), time.Now()
+		close(ch)
+		return stream
+	}
+	go func() {
+		defer close(ch)
+		stream.Error, stream.endTime = readEvents(resp.Body, func(data string, now time.Time) error {
+			chunk := &CompletionsChunk{receivedAt: now}
+			if err := json.Unmarshal([]byte(data), chunk); err != nil {
+				return err
+			}
+			ch <- chunk
+			return nil
+		})
+	}()
+	return stream
+}
@@ -0,0 +1,202 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectChatStream(t *testing.T) {
+	testName := "TestCollectChatStream"
+	tokens := []string{"Hello", " world", ",", " this", " is", " GPT"}
+	interval := 50 * time.Millisecond
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		time.Sleep(interval)
+		_, _ = fmt.Fprintf(w, ": keep-alive\n\n")
+		_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-3.5-turbo\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\"}}]}\n\n")
+		for i, token := range tokens {
+			if i > 0 {
+				time.Sleep(interval)
+			}
+			_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-3.5-turbo\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", token)
+			flusher.Flush()
+		}
+		_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-3.5-turbo\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
+	})
+	defer server.Close()
+
+	stream := client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-3.5-turbo", Messages: []ChatMessage{{Role: "user", Content: "Hi"}}})
+	if stream.Error != nil || stream.StatusCode != 200 {
+		t.Fatalf("%s failed: %#v / %s", testName, stream.StatusCode, stream.Error)
+	}
+	output, stats := CollectChatStream(stream)
+	if output.Error != nil {
+		t.Fatalf("%s failed: %s", testName, output.Error)
+	}
+	if len(output.Choices) != 1 || output.Choices[0].Message.Content != "Hello world, this is GPT" || output.Choices[0].Message.Role != "assistant" || output.Choices[0].FinishReason != "stop" {
+		t.Fatalf("%s failed: unexpected output %#v", testName, output.Choices)
+	}
+	if stats.TokenCount != len(tokens) || len(stats.InterTokenLatencies) != len(tokens)-1 {
+		t.Fatalf("%s failed: expected %#v tokens but received %#v", testName, len(tokens), stats.TokenCount)
+	}
+	if stats.TimeToFirstToken < interval || stats.TimeToFirstToken > 4*interval {
+		t.Fatalf("%s failed: unexpected time-to-first-token %s", testName, stats.TimeToFirstToken)
+	}
+	if stats.MeanInterTokenLatency < interval*8/10 || stats.MeanInterTokenLatency > 2*interval {
+		t.Fatalf("%s failed: unexpected mean inter-token latency %s", testName, stats.MeanInterTokenLatency)
+	}
+	if stats.TotalDuration < time.Duration(len(tokens))*interval {
+		t.Fatalf("%s failed: unexpected total duration %s", testName, stats.TotalDuration)
+	}
+}
+
+func TestCollectChatStream_ToolCalls(t *testing.T) {
+	testName := "TestCollectChatStream_ToolCalls"
+	chunks := []string{
+		`{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}`,
+		`{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}`,
+		`{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}`,
+		`{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}`,
+		`{"index":0,"delta":{},"finish_reason":"tool_calls"}`,
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"choices\":[%s]}\n\n", chunk)
+		}
+		_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
+	})
+	defer server.Close()
+
+	stream := client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-4o-mini", Messages: []ChatMessage{{Role: "user", Content: "Weather and time in Paris?"}}})
+	output, stats := CollectChatStream(stream)
+	if output.Error != nil {
+		t.Fatalf("%s failed: %s", testName, output.Error)
+	}
+	expected := []ToolCall{
+		{Id: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		{Id: "call_2", Type: "function", Function: FunctionCall{Name: "get_time", Arguments: `{}`}},
+	}
+	if len(output.Choices) != 1 || !reflect.DeepEqual(output.Choices[0].Message.ToolCalls, expected) || output.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("%s failed: unexpected output %#v", testName, output.Choices)
+	}
+	if stats.TokenCount != 4 || len(stats.InterTokenLatencies) != 3 {
+		t.Fatalf("%s failed: expected 4 tokens but received %#v", testName, stats.TokenCount)
+	}
+}
+
+func TestChatCompletionsStream_Usage(t *testing.T) {
+	testName := "TestChatCompletionsStream_Usage"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hi\"},\"finish_reason\":\"stop\"}],\"usage\":null}\n\n")
+		_, _ = fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-1\",\"choices\":[],\"usage\":{\"prompt_tokens\":8,\"completion_tokens\":1,\"total_tokens\":9}}\n\n")
+		_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
+	})
+	defer server.Close()
+
+	stream := client.ChatCompletionsStream(&ChatPromptInput{Model: "gpt-4o-mini", Messages: []ChatMessage{{Role: "user", Content: "Hi"}},
+		StreamOptions: &StreamOptions{IncludeUsage: true}})
+	output, _ := CollectChatStream(stream)
+	if opts, ok := received["stream_options"].(map[string]interface{}); !ok || opts["include_usage"] != true {
+		t.Fatalf("%s failed: unexpected stream_options %#v", testName, received["stream_options"])
+	}
+	expected := ChatCompletionsUsage{PromptTokens: 8, CompletionTokens: 1, TotalTokens: 9}
+	if stream.Usage == nil || *stream.Usage != expected || output.Usage == nil || *output.Usage != expected {
+		t.Fatalf("%s failed: expected usage %#v but received %#v / %#v", testName, expected, stream.Usage, output.Usage)
+	}
+	if len(output.Choices) != 1 || output.Choices[0].Message.Content != "Hi" {
+		t.Fatalf("%s failed: unexpected output %#v", testName, output.Choices)
+	}
+}
+
+func TestCompletionsStream(t *testing.T) {
+	testName := "TestCompletionsStream"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: {\"id\":\"cmpl-1\",\"object\":\"text_completion\",\"choices\":[{\"text\":\"Hello\",\"index\":0,\"finish_reason\":null}]}\n\n")
+		_, _ = fmt.Fprintf(w, "data: {\"id\":\"cmpl-1\",\"object\":\"text_completion\",\"choices\":[{\"text\":\" world\",\"index\":0,\"finish_reason\":\"stop\"}]}\n\n")
+		_, _ = fmt.Fprintf(w, "data: [DONE]\n\n")
+	})
+	defer server.Close()
+
+	stream := client.CompletionsStream(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompt: "Say hello"})
+	text, finishReason := "", ""
+	for chunk := range stream.Chunks {
+		for _, choice := range chunk.Choices {
+			text += choice.Text
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if stream.Error != nil || stream.StatusCode != 200 || received["stream"] != true {
+		t.Fatalf("%s failed: %#v / %d / %#v", testName, stream.Error, stream.StatusCode, received)
+	}
+	if text != "Hello world" || finishReason != "stop" {
+		t.Fatalf("%s failed: unexpected text %q / finish reason %q", testName, text, finishReason)
+	}
+}
+
+func TestStream_Error(t *testing.T) {
+	testName := "TestStream_Error"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"Invalid model","type":"invalid_request_error","code":"model_not_found"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html>Bad Gateway</html>"))
+	})
+	defer server.Close()
+
+	chatStream := client.ChatCompletionsStream(&ChatPromptInput{Model: "unknown", Messages: []ChatMessage{{Role: "user", Content: "Hi"}}})
+	for range chatStream.Chunks {
+	}
+	var apiErr *APIError
+	if chatStream.StatusCode != http.StatusBadRequest || !errors.As(chatStream.Error, &apiErr) || apiErr.Code != "model_not_found" {
+		t.Fatalf("%s failed: expected APIError but received %d / %#v", testName, chatStream.StatusCode, chatStream.Error)
+	}
+
+	stream := client.CompletionsStream(&PromptInput{Model: "gpt-3.5-turbo-instruct", Prompt: "Say hello"})
+	for range stream.Chunks {
+	}
+	if stream.StatusCode != http.StatusBadGateway || stream.Error == nil || stream.Error.Error() != "status 502" {
+		t.Fatalf("%s failed: expected status error but received %d / %#v", testName, stream.StatusCode, stream.Error)
+	}
+}
+
+func TestSseReader(t *testing.T) {
+	testName := "TestSseReader"
+	input := ": keep-alive\n\nevent: message\ndata: {\"a\":1}\n\ndata: line1\ndata:line2\n\n\n\ndata: last\n\ndata: [DONE]\n\ndata: ignored\n\n"
+	reader := NewSseReader(strings.NewReader(input))
+	for _, expected := range []string{`{"a":1}`, "line1\nline2", "last"} {
+		if data, err := reader.Next(); err != nil || data != expected {
+			t.Fatalf("%s failed: expected %#v but received %#v / %v", testName, expected, data, err)
+		}
+	}
+	if data, err := reader.Next(); err != io.EOF {
+		t.Fatalf("%s failed: expected io.EOF but received %#v / %v", testName, data, err)
+	}
+	reader = NewSseReader(strings.NewReader("data: unterminated"))
+	if data, err := reader.Next(); err != nil || data != "unterminated" {
+		t.Fatalf("%s failed: unexpected event %#v / %v", testName, data, err)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatalf("%s failed: expected io.EOF but received %v", testName, err)
+	}
+}
@@ -0,0 +1,349 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoJsonFound = errors.New("no JSON object or array found")
+
+	// ErrSchemaViolation is returned (wrapped) in ChatCompletionsOutput.Error when a reply does not conform to the
+	// JSON schema of the prompt's response format (see OptValidateJsonSchema).
+	ErrSchemaViolation = errors.New("reply does not conform to the JSON schema")
+)
+
+// ResponseFormat specifies the format of a chat-completions reply (see ChatPromptInput.ResponseFormat).
+type ResponseFormat struct {
+	// Type is one of "text", "json_object" (JSON mode) or "json_schema" (structured outputs).
+	Type       string            `json:"type"`
+	JsonSchema *JsonSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JsonSchemaFormat is the JSON schema the reply must adhere to when using structured outputs.
+type JsonSchemaFormat struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Schema is the JSON schema, e.g. a map[string]interface{} or a json.RawMessage.
+	Schema interface{} `json:"schema,omitempty"`
+	// Strict, if true, enables strict schema adherence.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// ResponseFormatJsonObject builds the ResponseFormat enabling JSON mode: the reply is guaranteed to be a valid JSON object.
+//
+// Note: OpenAI requires the word "JSON" to appear in the messages when JSON mode is enabled.
+func ResponseFormatJsonObject() *ResponseFormat {
+	return &ResponseFormat{Type: "json_object"}
+}
+
+// ResponseFormatJsonSchema builds the ResponseFormat enabling structured outputs: the reply adheres to the schema.
+func ResponseFormatJsonSchema(name string, schema interface{}, strict bool) *ResponseFormat {
+	return &ResponseFormat{Type: "json_schema", JsonSchema: &JsonSchemaFormat{Name: name, Schema: schema, Strict: strict}}
+}
+
+// RepairJSON extracts clean, parseable JSON from a model reply.
+//
+// It strips markdown code fences and surrounding prose, extracts the first balanced JSON object/array,
+// and removes trailing commas before closing braces/brackets. An error is returned if the result is still not valid JSON.
+func RepairJSON(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if json.Valid([]byte(content)) {
+		return content, nil
+	}
+	content = stripCodeFence(content)
+	extracted, err := extractBalancedJSON(content)
+	if err != nil {
+		return "", err
+	}
+	repaired := removeTrailingCommas(extracted)
+	if !json.Valid([]byte(repaired)) {
+		return "", fmt.Errorf("cannot repair JSON: %s", repaired)
+	}
+	return repaired, nil
+}
+
+// stripCodeFence returns the content of the first markdown code block, or the input if there is none.
+func stripCodeFence(content string) string {
+	start := strings.Index(content, "```")
+	if start < 0 {
+		return content
+	}
+	body := content[start+3:]
+	if nl := strings.Index(body, "\n"); nl >= 0 {
+		body = body[nl+1:] // skip the language tag, e.g. ```json
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
+
+// extractBalancedJSON returns the first balanced JSON object or array found in the content.
+func extractBalancedJSON(content string) (string, error) {
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return "", ErrNoJsonFound
+	}
+	stack := make([]byte, 0)
+	inString, escaped := false, false
+	for i := start; i < len(content); i++ {
+		c := content[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return "", fmt.Errorf("unbalanced JSON at position %d", i)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return content[start : i+1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unbalanced JSON: missing %s", string(stack))
+}
+
+// removeTrailingCommas removes commas directly preceding (ignoring whitespaces) a closing brace/bracket.
+func removeTrailingCommas(js string) string {
+	result := strings.Builder{}
+	inString, escaped := false, false
+	for i := 0; i < len(js); i++ {
+		c := js[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		} else if c == '"' {
+			inString = true
+		} else if c == ',' {
+			j := i + 1
+			for j < len(js) && strings.IndexByte(" \t\r\n", js[j]) >= 0 {
+				j++
+			}
+			if j < len(js) && (js[j] == '}' || js[j] == ']') {
+				continue
+			}
+		}
+		result.WriteByte(c)
+	}
+	return result.String()
+}
+
+// OptRepairJSON, if true, makes UnmarshalContent attempt to repair malformed JSON content (see RepairJSON) before failing.
+const OptRepairJSON = "repair-json"
+
+// UnmarshalContent parses the JSON content of the first choice's message and puts the result to v.
+//
+// Available options: OptRepairJSON.
+//
+// Note: v must be a pointer.
+func (o *ChatCompletionsOutput) UnmarshalContent(v interface{}, opts ...Option) error {
+	if len(o.Choices) == 0 {
+		return errors.New("no choice in the output")
+	}
+	content := o.Choices[0].Message.Content
+	err := json.Unmarshal([]byte(content), v)
+	if err == nil {
+		return nil
+	}
+	var optList OptionList = opts
+	if repair, _ := optList.GetBool(OptRepairJSON); repair {
+		if repaired, errRepair := RepairJSON(content); errRepair == nil {
+			return json.Unmarshal([]byte(repaired), v)
+		}
+	}
+	return fmt.Errorf("cannot parse message content as JSON: %w", err)
+}
+
+// checkResponseSchema validates the replies of a chat-completions output against the JSON schema of the prompt's
+// response format, setting the output's Error if a reply does not conform (see OptValidateJsonSchema). Refused replies
+// carry no JSON document and are not validated.
+func (bc *BaseClient) checkResponseSchema(prompt *ChatPromptInput, output *ChatCompletionsOutput) *ChatCompletionsOutput {
+	if !bc.validateJsonSchema || output.Error != nil || prompt.ResponseFormat == nil || prompt.ResponseFormat.Type != "json_schema" ||
+		prompt.ResponseFormat.JsonSchema == nil || prompt.ResponseFormat.JsonSchema.Schema == nil {
+		return output
+	}
+	schema, err := normalizeJson(prompt.ResponseFormat.JsonSchema.Schema)
+	if err != nil {
+		output.Error = fmt.Errorf("cannot parse JSON schema: %w", err)
+		return output
+	}
+	for _, choice := range output.Choices {
+		if choice.Message.Refusal != "" {
+			continue
+		}
+		if choice.Message.Content == "" && (len(choice.Message.ToolCalls) > 0 || choice.Message.FunctionCall != nil) {
+			continue
+		}
+		if err = ValidateJsonSchema(choice.Message.Content, schema); err != nil {
+			output.Error = fmt.Errorf("choice %d: %w", choice.Index, err)
+			return output
+		}
+	}
+	return output
+}
+
+// normalizeJson converts a JSON-serializable value (e.g. a struct or a json.RawMessage) to its generic form
+// (map[string]interface{}, []interface{}, string, float64, bool or nil).
+func normalizeJson(v interface{}) (interface{}, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(js, &result)
+	return result, err
+}
+
+// ValidateJsonSchema checks that content is a JSON document conforming to schema (e.g. a map[string]interface{} or a
+// json.RawMessage), returning an error wrapping ErrSchemaViolation that describes the first mismatch found.
+//
+// This is a lightweight validator covering the subset of JSON schema supported by structured outputs: "type"
+// (including type lists), "properties", "required", "additionalProperties": false, "items", "enum" and "const".
+// "$ref", "anyOf" and other combinators are not checked.
+func ValidateJsonSchema(content string, schema interface{}) error {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return fmt.Errorf("%w: invalid JSON: %s", ErrSchemaViolation, err)
+	}
+	normalized, err := normalizeJson(schema)
+	if err != nil {
+		return fmt.Errorf("cannot parse JSON schema: %w", err)
+	}
+	if msg := validateJsonValue("$", doc, normalized); msg != "" {
+		return fmt.Errorf("%w: %s", ErrSchemaViolation, msg)
+	}
+	return nil
+}
+
+// validateJsonValue validates value (at path) against schema, returning the description of the first mismatch or ""
+// if the value conforms.
+func validateJsonValue(path string, value, schema interface{}) string {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if t, ok := s["type"]; ok && !matchJsonType(value, t) {
+		return fmt.Sprintf("%s: expected type %v but received %s", path, t, jsonTypeOf(value))
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(value, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(value, c) {
+		return fmt.Sprintf("%s: expected %v but received %v", path, c, value)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := s["properties"].(map[string]interface{})
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, exists := v[name]; !exists {
+						return fmt.Sprintf("%s: missing required property %q", path, name)
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propSchema, known := properties[name]
+			if !known {
+				if additional, ok := s["additionalProperties"].(bool); ok && !additional {
+					return fmt.Sprintf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if msg := validateJsonValue(path+"."+name, v[name], propSchema); msg != "" {
+				return msg
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				if msg := validateJsonValue(fmt.Sprintf("%s[%d]", path, i), item, items); msg != "" {
+					return msg
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// matchJsonType returns true if value is of the JSON schema type t (a type name or a list of type names).
+func matchJsonType(value, t interface{}) bool {
+	switch types := t.(type) {
+	case string:
+		actual := jsonTypeOf(value)
+		return actual == types || (types == "number" && actual == "integer")
+	case []interface{}:
+		for _, typ := range types {
+			if matchJsonType(value, typ) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// jsonTypeOf returns the JSON schema type of a generic JSON value ("integer" for whole numbers).
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,156 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	testName := "TestRepairJSON"
+	testData := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "valid", input: ` {"a":1} `, expected: `{"a":1}`},
+		{name: "fenced", input: "```json\n{\"a\": 1, \"b\": [1, 2]}\n```", expected: `{"a": 1, "b": [1, 2]}`},
+		{name: "prose", input: `Sure! Here is the result: {"name": "x"} Let me know if you need more.`, expected: `{"name": "x"}`},
+		{name: "trailing-commas", input: "{\"a\": [1, 2, ], \"b\": {\"c\": 3,\n},}", expected: `{"a": [1, 2 ], "b": {"c": 3
+}}`},
+		{name: "braces-in-string", input: `Result: {"text": "a } b, ]", "n": 1,}`, expected: `{"text": "a } b, ]", "n": 1}`},
+		{name: "array", input: "The list:\n[\"x\", \"y\",]\nDone.", expected: `["x", "y"]`},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			output, err := RepairJSON(testCase.input)
+			if err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			if output != testCase.expected {
+				t.Fatalf("%s failed: expected %#v but received %#v", testName+"/"+testCase.name, testCase.expected, output)
+			}
+		})
+	}
+	for _, input := range []string{"no json here", `{"a": 1`} {
+		if _, err := RepairJSON(input); err == nil {
+			t.Fatalf("%s failed: expected error for input %#v", testName, input)
+		}
+	}
+}
+
+func TestChatCompletionsOutput_UnmarshalContent(t *testing.T) {
+	testName := "TestChatCompletionsOutput_UnmarshalContent"
+	output := &ChatCompletionsOutput{}
+	_ = json.Unmarshal([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"`+"```json\\n{\\\"answer\\\": 42,}\\n```"+`"}}]}`), output)
+	var result struct {
+		Answer int `json:"answer"`
+	}
+	if err := output.UnmarshalContent(&result); err == nil {
+		t.Fatalf("%s failed: expected error without repair", testName)
+	}
+	if err := output.UnmarshalContent(&result, Option{Key: OptRepairJSON, Value: true}); err != nil || result.Answer != 42 {
+		t.Fatalf("%s failed: expected repaired content but received %#v / %s", testName, result, err)
+	}
+}
+
+func TestChatPromptInput_ResponseFormat(t *testing.T) {
+	testName := "TestChatPromptInput_ResponseFormat"
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"answer": map[string]interface{}{"type": "string"}}}
+	testData := []struct {
+		name     string
+		format   *ResponseFormat
+		expected string
+	}{
+		{name: "unset", format: nil, expected: ``},
+		{name: "json_object", format: ResponseFormatJsonObject(), expected: `{"type":"json_object"}`},
+		{name: "json_schema", format: ResponseFormatJsonSchema("answer", schema, true),
+			expected: `{"type":"json_schema","json_schema":{"name":"answer","schema":{"properties":{"answer":{"type":"string"}},"type":"object"},"strict":true}}`},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			js, _ := json.Marshal(&ChatPromptInput{Model: "gpt-4o", ResponseFormat: testCase.format})
+			var decoded map[string]json.RawMessage
+			_ = json.Unmarshal(js, &decoded)
+			if string(decoded["response_format"]) != testCase.expected {
+				t.Fatalf("%s failed: expected %s but received %s", testName+"/"+testCase.name, testCase.expected, decoded["response_format"])
+			}
+		})
+	}
+}
+
+func TestValidateJsonSchema(t *testing.T) {
+	testName := "TestValidateJsonSchema"
+	schema := json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"},
+		"tags":{"type":"array","items":{"type":"string"}},"level":{"type":["string","null"],"enum":["low","high",null]}},
+		"required":["name","age"],"additionalProperties":false}`)
+	testData := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{name: "valid", content: `{"name":"Alice","age":30,"tags":["a"],"level":null}`, valid: true},
+		{name: "invalid_json", content: `{"name":"Alice"`},
+		{name: "missing_required", content: `{"name":"Alice"}`},
+		{name: "wrong_type", content: `{"name":"Alice","age":30.5}`},
+		{name: "wrong_item_type", content: `{"name":"Alice","age":30,"tags":[1]}`},
+		{name: "not_in_enum", content: `{"name":"Alice","age":30,"level":"medium"}`},
+		{name: "additional_property", content: `{"name":"Alice","age":30,"email":"alice@example.com"}`},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := ValidateJsonSchema(testCase.content, schema)
+			if testCase.valid && err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			if !testCase.valid && !errors.Is(err, ErrSchemaViolation) {
+				t.Fatalf("%s failed: expected ErrSchemaViolation but received %#v", testName+"/"+testCase.name, err)
+			}
+		})
+	}
+}
+
+func TestOptValidateJsonSchema(t *testing.T) {
+	testName := "TestOptValidateJsonSchema"
+	content := `{\"name\":\"Alice\"}`
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"` + content + `"},"finish_reason":"stop"}]}`))
+	}, Option{Key: OptValidateJsonSchema, Value: true})
+	defer server.Close()
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}}, "required": []string{"name"}}
+	prompt := func(format *ResponseFormat) *ChatPromptInput {
+		return &ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Who?"}}, ResponseFormat: format}
+	}
+	if output := client.ChatCompletions(prompt(ResponseFormatJsonSchema("person", schema, true))); output.Error != nil {
+		t.Fatalf("%s failed: %s", testName, output.Error)
+	}
+	content = `{\"nickname\":\"Al\"}`
+	if output := client.ChatCompletions(prompt(ResponseFormatJsonSchema("person", schema, true))); !errors.Is(output.Error, ErrSchemaViolation) ||
+		!strings.Contains(output.Error.Error(), `"name"`) {
+		t.Fatalf("%s failed: expected ErrSchemaViolation but received %#v", testName, output.Error)
+	}
+	if output := client.ChatCompletions(prompt(ResponseFormatJsonObject())); output.Error != nil {
+		t.Fatalf("%s failed: expected no validation without JSON schema but received %s", testName, output.Error)
+	}
+}
+
+func TestOptValidateJsonSchema_Refusal(t *testing.T) {
+	testName := "TestOptValidateJsonSchema_Refusal"
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":null,"refusal":"I'm sorry, I cannot help with that."},"finish_reason":"stop"}]}`))
+	}, Option{Key: OptValidateJsonSchema, Value: true})
+	defer server.Close()
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}}, "required": []string{"name"}}
+	output := client.ChatCompletions(&ChatPromptInput{Model: "gpt-4o", Messages: []ChatMessage{{Role: "user", Content: "Who?"}},
+		ResponseFormat: ResponseFormatJsonSchema("person", schema, true)})
+	if output.Error != nil {
+		t.Fatalf("%s failed: refused reply should not be validated but received %s", testName, output.Error)
+	}
+	if len(output.Choices) != 1 || output.Choices[0].Message.Refusal != "I'm sorry, I cannot help with that." {
+		t.Fatalf("%s failed: unexpected choices %#v", testName, output.Choices)
+	}
+}
@@ -0,0 +1,238 @@
+package oaiaux
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+var (
+	ErrEncodingNotFound = errors.New("cannot resolve tokenizer encoding")
+)
+
+// codecs caches resolved tokenizer codecs (building a codec compiles its regular expressions), keyed by
+// "model:<name>" and "encoding:<name>". Models sharing an encoding share the same codec instance.
+var codecs sync.Map
+
+// cachedCodec returns the cached codec for the key, resolving it with get on first use. nil is returned if the codec
+// cannot be resolved (failures are not cached).
+func cachedCodec(key string, get func() (tokenizer.Codec, error)) tokenizer.Codec {
+	if v, ok := codecs.Load(key); ok {
+		return v.(tokenizer.Codec)
+	}
+	enc, err := get()
+	if err != nil || enc == nil {
+		return nil
+	}
+	if v, ok := codecs.Load("encoding:" + enc.GetName()); ok {
+		enc = v.(tokenizer.Codec)
+	} else {
+		// Decode lazily builds the reverse vocabulary: build it now, so that the shared codec is read-only afterwards
+		// and safe for concurrent use.
+		_, _ = enc.Decode(nil)
+		v, _ = codecs.LoadOrStore("encoding:"+enc.GetName(), enc)
+		enc = v.(tokenizer.Codec)
+	}
+	v, _ := codecs.LoadOrStore(key, enc)
+	return v.(tokenizer.Codec)
+}
+
+// modelEncodings maps model name prefixes to their encodings, for model families not (or wrongly) resolved by the
+// tokenizer library. More specific prefixes come first. Azure OpenAI model names ("gpt-35-turbo") are included.
+var modelEncodings = []struct {
+	prefix   string
+	encoding tokenizer.Encoding
+}{
+	{"gpt-4o", tokenizer.O200kBase},
+	{"chatgpt-4o", tokenizer.O200kBase},
+	{"gpt-4.1", tokenizer.O200kBase},
+	{"gpt-4.5", tokenizer.O200kBase},
+	{"o1", tokenizer.O200kBase},
+	{"o3", tokenizer.O200kBase},
+	{"o4", tokenizer.O200kBase},
+	{"gpt-4", tokenizer.Cl100kBase},
+	{"gpt-3.5-turbo", tokenizer.Cl100kBase},
+	{"gpt-35-turbo", tokenizer.Cl100kBase},
+	{"text-embedding-3", tokenizer.Cl100kBase},
+	{"text-embedding-ada-002", tokenizer.Cl100kBase},
+	{"davinci-002", tokenizer.Cl100kBase},
+	{"babbage-002", tokenizer.Cl100kBase},
+}
+
+// modelEncoding returns the encoding of a model, or "" if the model is not known. Fine-tuned models
+// ("ft:<base-model>:...") use the encoding of their base model.
+func modelEncoding(model string) tokenizer.Encoding {
+	model = strings.TrimPrefix(model, "ft:")
+	for _, e := range modelEncodings {
+		if strings.HasPrefix(model, e.prefix) {
+			return e.encoding
+		}
+	}
+	return ""
+}
+
+// selectCodec returns the tokenizer codec of the "model" option if the model is known, or else the codec of the
+// "encoding" option, or else the cl100k_base codec. An unsupported "encoding" option is an error (ErrEncodingNotFound).
+// Codecs are cached and safe for concurrent use.
+func selectCodec(opts ...Option) (tokenizer.Codec, error) {
+	var optList OptionList = opts
+	var enc tokenizer.Codec
+
+	if model, err := optList.GetString("model"); model != "" && err == nil {
+		enc = cachedCodec("model:"+model, func() (tokenizer.Codec, error) {
+			if encoding := modelEncoding(model); encoding != "" {
+				return tokenizer.Get(encoding)
+			}
+			return tokenizer.ForModel(tokenizer.Model(model))
+		})
+	}
+	if enc == nil {
+		if encoding, err := optList.GetString("encoding"); encoding != "" && err == nil {
+			enc = cachedCodec("encoding:"+encoding, func() (tokenizer.Codec, error) { return tokenizer.Get(tokenizer.Encoding(encoding)) })
+			if enc == nil {
+				return nil, fmt.Errorf("%w: unsupported encoding <%s>", ErrEncodingNotFound, encoding)
+			}
+		}
+	}
+	if enc == nil {
+		enc = cachedCodec("encoding:"+string(tokenizer.Cl100kBase), func() (tokenizer.Codec, error) { return tokenizer.Get(tokenizer.Cl100kBase) })
+		if enc == nil {
+			return nil, ErrEncodingNotFound
+		}
+	}
+	return enc, nil
+}
+
+// chatTokensOverhead returns the number of tokens added to each message and to each message name by chat models.
+func chatTokensOverhead(model string) (perMessage, perName int) {
+	if strings.HasPrefix(model, "gpt-3.5-turbo-0301") || strings.HasPrefix(model, "gpt-35-turbo-0301") {
+		// every message follows <|start|>{role/name}\n{content}<|end|>\n; if there's a name, the role is omitted
+		return 4, -1
+	}
+	return 3, 1
+}
+
+// CountChatTokens returns the number of prompt tokens of a list of chat messages, following OpenAI's counting rules:
+// the tokens of each message's fields, plus a fixed per-message (and per-name) formatting overhead, plus the tokens
+// priming the assistant's reply. If error, -1 is returned.
+//
+// The encoding is selected based on the "model" option (see CountTokens); the result is accurate for gpt-3.5-turbo,
+// gpt-4 and gpt-4o families and an approximation for other models. Only text content parts are counted, not images.
+func CountChatTokens(messages []ChatMessage, opts ...Option) int {
+	var optList OptionList = opts
+	model, _ := optList.GetString("model")
+	perMessage, perName := chatTokensOverhead(model)
+	total := 3 // every reply is primed with <|start|>assistant<|message|>
+	for _, msg := range messages {
+		total += perMessage
+		values := []string{msg.Role, msg.Content, msg.Name}
+		for _, part := range msg.ContentParts {
+			values = append(values, part.Text)
+		}
+		for _, value := range values {
+			if value == "" {
+				continue
+			}
+			n := CountTokens(value, opts...)
+			if n < 0 {
+				return -1
+			}
+			total += n
+		}
+		if msg.Name != "" {
+			total += perName
+		}
+	}
+	return total
+}
+
+// TruncateToTokens truncates the input string to at most maxTokens BPE tokens (the codec is selected the same way as
+// CountTokens), returning the truncated string and its number of tokens. If error, the input is returned as is with -1.
+//
+// The cut never splits a multi-byte character: tokens ending in the middle of a character are dropped.
+func TruncateToTokens(input string, maxTokens int, opts ...Option) (string, int) {
+	enc, err := selectCodec(opts...)
+	if err != nil {
+		return input, -1
+	}
+	ids, _, err := enc.Encode(input)
+	if err != nil {
+		return input, -1
+	}
+	if len(ids) <= maxTokens {
+		return input, len(ids)
+	}
+	if maxTokens < 0 {
+		maxTokens = 0
+	}
+	for n := maxTokens; n > 0; n-- {
+		text, err := enc.Decode(ids[:n])
+		if err != nil {
+			return input, -1
+		}
+		if r, size := utf8.DecodeLastRuneInString(text); r != utf8.RuneError || size > 1 {
+			return text, n
+		}
+	}
+	return "", 0
+}
+
+// DecodeTokens converts BPE token ids back to text. The codec is selected the same way as CountTokens.
+func DecodeTokens(ids []uint, opts ...Option) (string, error) {
+	enc, err := selectCodec(opts...)
+	if err != nil {
+		return "", err
+	}
+	return enc.Decode(ids)
+}
+
+// LogitBiasFromStrings builds a logit_bias map (keyed by token ids, see ChatPromptInput.LogitBias) from a map keyed by
+// strings. The codec is selected the same way as CountTokens, hence the "model" option should match the model of the
+// prompt.
+//
+// A string spanning multiple tokens has its bias applied to every one of its tokens (which may affect other words
+// sharing these tokens). If several strings share a token, the bias of the largest magnitude is kept. Note that
+// tokenization depends on the leading space: "hello" and " hello" (a word in the middle of a sentence) are different
+// tokens, hence include both forms to steer a word wherever it appears. Biases must be within [-100, 100].
+func LogitBiasFromStrings(bias map[string]int, opts ...Option) (map[string]int, error) {
+	enc, err := selectCodec(opts...)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(bias))
+	for key := range bias {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make(map[string]int)
+	for _, key := range keys {
+		value := bias[key]
+		if value < -100 || value > 100 {
+			return nil, fmt.Errorf("%w: logit bias of <%s> must be within [-100, 100] but received %d", ErrInvalidParameter, key, value)
+		}
+		ids, _, err := enc.Encode(key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode <%s> with encoding <%s>: %w", key, enc.GetName(), err)
+		}
+		for _, id := range ids {
+			tokenId := strconv.FormatUint(uint64(id), 10)
+			if existing, ok := result[tokenId]; !ok || absInt(value) > absInt(existing) {
+				result[tokenId] = value
+			}
+		}
+	}
+	return result, nil
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
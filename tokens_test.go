@@ -0,0 +1,164 @@
+package oaiaux
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCountChatTokens(t *testing.T) {
+	testName := "TestCountChatTokens"
+	// example from OpenAI's cookbook "How to count tokens with tiktoken"
+	messages := []ChatMessage{
+		{Role: "system", Content: "You are a helpful, pattern-following assistant that translates corporate jargon into plain English."},
+		{Role: "system", Name: "example_user", Content: "New synergies will help drive top-line growth."},
+		{Role: "system", Name: "example_assistant", Content: "Things working well together will increase revenue."},
+		{Role: "system", Name: "example_user", Content: "Let's circle back when we have more bandwidth to touch base on opportunities for increased leverage."},
+		{Role: "system", Name: "example_assistant", Content: "Let's talk later when we're less busy about how to do better."},
+		{Role: "user", Content: "This late pivot means we don't have time to boil the ocean for the client deliverable."},
+	}
+	testData := []struct {
+		model    string
+		expected int
+	}{
+		{model: "gpt-3.5-turbo-0301", expected: 127},
+		{model: "gpt-3.5-turbo-0613", expected: 129},
+		{model: "gpt-3.5-turbo", expected: 129},
+		{model: "gpt-4", expected: 129},
+		{model: "gpt-4-0613", expected: 129},
+		{model: "gpt-4o", expected: 124},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.model, func(t *testing.T) {
+			value := CountChatTokens(messages, Option{Key: "model", Value: testCase.model})
+			if value != testCase.expected {
+				t.Fatalf("%s failed: expected %#v but received %#v", testName+"/"+testCase.model, testCase.expected, value)
+			}
+		})
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	testName := "TestTruncateToTokens"
+	opt := Option{Key: "model", Value: "gpt-4"}
+	testData := []struct {
+		name      string
+		input     string
+		maxTokens int
+	}{
+		{name: "English", input: "Hello world, this is so beautiful!", maxTokens: 3},
+		{name: "no_truncation", input: "Hello world", maxTokens: 100},
+		{name: "zero", input: "Hello world", maxTokens: 0},
+		{name: "Vietnamese", input: "Chào thế giới, điều này thật đẹp!", maxTokens: 5},
+		{name: "Chinese", input: "你好世界，这太漂亮了!", maxTokens: 3},
+		{name: "Japanese", input: "こんにちは世界、これはとても美しいです！", maxTokens: 7},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			text, n := TruncateToTokens(testCase.input, testCase.maxTokens, opt)
+			if n < 0 || n > testCase.maxTokens {
+				t.Fatalf("%s failed: expected at most %#v tokens but received %#v", testName+"/"+testCase.name, testCase.maxTokens, n)
+			}
+			if !strings.HasPrefix(testCase.input, text) || !utf8.ValidString(text) {
+				t.Fatalf("%s failed: <%s> is not a valid prefix of <%s>", testName+"/"+testCase.name, text, testCase.input)
+			}
+			if count := CountTokens(text, opt); count != n {
+				t.Fatalf("%s failed: expected %#v tokens but truncated text has %#v", testName+"/"+testCase.name, n, count)
+			}
+		})
+	}
+}
+
+func TestDecodeTokens(t *testing.T) {
+	testName := "TestDecodeTokens"
+	for _, encoding := range []string{"p50k_base", "cl100k_base", "o200k_base"} {
+		opt := Option{Key: "encoding", Value: encoding}
+		input := "Chào thế giới, điều này thật đẹp!"
+		enc, _ := selectCodec(opt)
+		ids, _, _ := enc.Encode(input)
+		text, err := DecodeTokens(ids, opt)
+		if err != nil || text != input {
+			t.Fatalf("%s failed for encoding %s: expected <%s> but received <%s> / %s", testName, encoding, input, text, err)
+		}
+	}
+	if _, err := DecodeTokens([]uint{1 << 30}, Option{Key: "model", Value: "gpt-4"}); err == nil {
+		t.Fatalf("%s failed: expected error for invalid token id", testName)
+	}
+}
+
+func TestSelectCodec_Cached(t *testing.T) {
+	testName := "TestSelectCodec_Cached"
+	enc1, _ := selectCodec(Option{Key: "model", Value: "gpt-4"})
+	enc2, _ := selectCodec(Option{Key: "model", Value: "gpt-3.5-turbo"})
+	enc3, _ := selectCodec(Option{Key: "encoding", Value: "cl100k_base"})
+	if enc1 == nil || enc1 != enc2 || enc1 != enc3 {
+		t.Fatalf("%s failed: expected the same cached codec instance", testName)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				opt := Option{Key: "model", Value: "gpt-4"}
+				if n := CountTokens("Hello world", opt); n != 2 {
+					t.Errorf("%s failed: expected 2 tokens but received %d", testName, n)
+				}
+				if text, err := DecodeTokens([]uint{9906, 1917}, opt); err != nil || text != "Hello world" {
+					t.Errorf("%s failed: unexpected decoded text %#v / %s", testName, text, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSelectCodec_ModelEncoding(t *testing.T) {
+	testName := "TestSelectCodec_ModelEncoding"
+	testCases := map[string]string{
+		"gpt-4o":                    "o200k_base",
+		"gpt-4o-mini-2024-07-18":    "o200k_base",
+		"o1":                        "o200k_base",
+		"o3-mini":                   "o200k_base",
+		"gpt-4.1-nano":              "o200k_base",
+		"ft:gpt-4o-mini:org::id":    "o200k_base",
+		"gpt-4-turbo":               "cl100k_base",
+		"gpt-35-turbo":              "cl100k_base",
+		"text-embedding-3-small":    "cl100k_base",
+		"text-davinci-003":          "p50k_base",
+		"my-azure-deployment":       "cl100k_base",
+		"ft:gpt-3.5-turbo:org::id2": "cl100k_base",
+	}
+	for model, expected := range testCases {
+		enc, err := selectCodec(Option{Key: "model", Value: model})
+		if err != nil || enc.GetName() != expected {
+			t.Fatalf("%s failed: expected %s for model %s but received %#v / %s", testName, expected, model, enc, err)
+		}
+	}
+	if enc, _ := selectCodec(Option{Key: "model", Value: "unknown"}, Option{Key: "encoding", Value: "r50k_base"}); enc.GetName() != "r50k_base" {
+		t.Fatalf("%s failed: expected r50k_base but received %s", testName, enc.GetName())
+	}
+	if enc, _ := selectCodec(); enc.GetName() != "cl100k_base" {
+		t.Fatalf("%s failed: expected cl100k_base but received %s", testName, enc.GetName())
+	}
+}
+
+func TestLogitBiasFromStrings(t *testing.T) {
+	testName := "TestLogitBiasFromStrings"
+	opt := Option{Key: "model", Value: "gpt-4"}
+	bias, err := LogitBiasFromStrings(map[string]int{"Hello": -100, " world": 5, "Hello world": 10}, opt)
+	// cl100k_base: "Hello" = 9906, " world" = 1917
+	if err != nil || !reflect.DeepEqual(bias, map[string]int{"9906": -100, "1917": 10}) {
+		t.Fatalf("%s failed: unexpected bias %#v / %s", testName, bias, err)
+	}
+	if _, err = LogitBiasFromStrings(map[string]int{"Hello": 101}, opt); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("%s failed: expected ErrInvalidParameter but received %v", testName, err)
+	}
+	if _, err = LogitBiasFromStrings(map[string]int{"Hello": 1}, Option{Key: "encoding", Value: "no-such-encoding"}); !errors.Is(err, ErrEncodingNotFound) {
+		t.Fatalf("%s failed: expected ErrEncodingNotFound but received %v", testName, err)
+	}
+}
@@ -0,0 +1,77 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FunctionDefinition describes a function the model may call.
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the function's arguments, e.g. a map[string]interface{} or a json.RawMessage.
+	Parameters interface{} `json:"parameters,omitempty"`
+}
+
+// Tool is a tool the model may call. Currently, only "function" tools are supported.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionTool is a convenient function to build a "function" Tool.
+func FunctionTool(name, description string, parameters interface{}) Tool {
+	return Tool{Type: "function", Function: FunctionDefinition{Name: name, Description: description, Parameters: parameters}}
+}
+
+// ToolChoiceFunction builds the ToolChoice value forcing the model to call the specified function.
+func ToolChoiceFunction(name string) map[string]interface{} {
+	return map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": name}}
+}
+
+// FunctionCall is the function (name and JSON-encoded arguments) the model requests to call.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCall is a tool call requested by the model.
+type ToolCall struct {
+	// Index is the position of the tool call in the message, set in streamed deltas only (see CollectChatStream).
+	Index    *int         `json:"index,omitempty"`
+	Id       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// UnmarshalToolArgs parses the JSON-encoded arguments of a tool call and puts the result to v.
+//
+// Note: v must be a pointer.
+func UnmarshalToolArgs(toolCall ToolCall, v interface{}) error {
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), v); err != nil {
+		return fmt.Errorf("cannot parse arguments of tool call <%s/%s>: %w", toolCall.Id, toolCall.Function.Name, err)
+	}
+	return nil
+}
+
+// ToolResultMessage builds the "tool" message carrying the result of a tool call, to be sent back to the model.
+//
+// result is JSON-encoded, unless it is already a string (or a json.RawMessage/[]byte), which is used as-is.
+func ToolResultMessage(toolCallId string, result interface{}) (ChatMessage, error) {
+	msg := ChatMessage{Role: "tool", ToolCallId: toolCallId}
+	switch v := result.(type) {
+	case string:
+		msg.Content = v
+	case json.RawMessage:
+		msg.Content = string(v)
+	case []byte:
+		msg.Content = string(v)
+	default:
+		js, err := json.Marshal(result)
+		if err != nil {
+			return msg, fmt.Errorf("cannot encode result of tool call <%s>: %w", toolCallId, err)
+		}
+		msg.Content = string(js)
+	}
+	return msg, nil
+}
@@ -0,0 +1,128 @@
+package oaiaux
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestUnmarshalToolArgs(t *testing.T) {
+	testName := "TestUnmarshalToolArgs"
+	recorded := `{"id":"call_abc123","type":"function","function":{"name":"get_weather","arguments":"{\"location\":\"Hanoi\",\"unit\":\"celsius\",\"days\":3}"}}`
+	var toolCall ToolCall
+	if err := json.Unmarshal([]byte(recorded), &toolCall); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	var args struct {
+		Location string `json:"location"`
+		Unit     string `json:"unit"`
+		Days     int    `json:"days"`
+	}
+	if err := UnmarshalToolArgs(toolCall, &args); err != nil {
+		t.Fatalf("%s failed: %s", testName, err)
+	}
+	if args.Location != "Hanoi" || args.Unit != "celsius" || args.Days != 3 {
+		t.Fatalf("%s failed: unexpected arguments %#v", testName, args)
+	}
+
+	toolCall.Function.Arguments = `{"location":"Hanoi"`
+	if err := UnmarshalToolArgs(toolCall, &args); err == nil {
+		t.Fatalf("%s failed: expected error for malformed arguments", testName)
+	}
+}
+
+func TestToolResultMessage(t *testing.T) {
+	testName := "TestToolResultMessage"
+	testData := []struct {
+		name     string
+		result   interface{}
+		expected string
+	}{
+		{name: "string", result: "22 degrees", expected: "22 degrees"},
+		{name: "struct", result: struct {
+			Temperature int    `json:"temperature"`
+			Unit        string `json:"unit"`
+		}{22, "celsius"}, expected: `{"temperature":22,"unit":"celsius"}`},
+		{name: "map", result: map[string]interface{}{"ok": true}, expected: `{"ok":true}`},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			msg, err := ToolResultMessage("call_abc123", testCase.result)
+			if err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			if msg.Role != "tool" || msg.ToolCallId != "call_abc123" || msg.Content != testCase.expected {
+				t.Fatalf("%s failed: unexpected message %#v", testName+"/"+testCase.name, msg)
+			}
+		})
+	}
+	if _, err := ToolResultMessage("call_abc123", func() {}); err == nil {
+		t.Fatalf("%s failed: expected error for non-encodable result", testName)
+	}
+}
+
+func TestChatCompletions_ToolCalls(t *testing.T) {
+	testName := "TestChatCompletions_ToolCalls"
+	var received map[string]interface{}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		_, _ = w.Write([]byte(`{"object":"chat.completion","choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":null,` +
+			`"tool_calls":[{"id":"call_abc123","type":"function","function":{"name":"get_weather","arguments":"{\"location\":\"Hanoi\"}"}}]}}]}`))
+	})
+	defer server.Close()
+
+	parameters := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"location": map[string]interface{}{"type": "string"}},
+		"required":   []string{"location"},
+	}
+	prompt := &ChatPromptInput{
+		Model:      "gpt-4o",
+		Messages:   []ChatMessage{{Role: "user", Content: "What's the weather in Hanoi?"}},
+		Tools:      []Tool{FunctionTool("get_weather", "Get the current weather", parameters)},
+		ToolChoice: ToolChoiceFunction("get_weather"),
+	}
+	output := client.ChatCompletions(prompt)
+	if output.Error != nil || output.StatusCode != 200 || len(output.Choices) != 1 {
+		t.Fatalf("%s failed: %#v / %#v", testName, output.Error, output.StatusCode)
+	}
+	tools, _ := received["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["type"] != "function" {
+		t.Fatalf("%s failed: unexpected tools %#v", testName, received["tools"])
+	}
+	if choice, _ := received["tool_choice"].(map[string]interface{}); choice["type"] != "function" {
+		t.Fatalf("%s failed: unexpected tool_choice %#v", testName, received["tool_choice"])
+	}
+	if _, ok := received["functions"]; ok {
+		t.Fatalf("%s failed: functions should be omitted when empty", testName)
+	}
+	toolCalls := output.Choices[0].Message.ToolCalls
+	if len(toolCalls) != 1 || toolCalls[0].Id != "call_abc123" || toolCalls[0].Function.Name != "get_weather" {
+		t.Fatalf("%s failed: unexpected tool calls %#v", testName, toolCalls)
+	}
+	var args struct {
+		Location string `json:"location"`
+	}
+	if err := UnmarshalToolArgs(toolCalls[0], &args); err != nil || args.Location != "Hanoi" {
+		t.Fatalf("%s failed: unexpected arguments %#v / %s", testName, args, err)
+	}
+}
+
+func TestChatPromptInput_ParallelToolCalls(t *testing.T) {
+	testName := "TestChatPromptInput_ParallelToolCalls"
+	prompt := &ChatPromptInput{Model: "gpt-4o", Tools: []Tool{FunctionTool("get_weather", "Get the weather", nil)}}
+	js, _ := json.Marshal(prompt)
+	var received map[string]interface{}
+	_ = json.Unmarshal(js, &received)
+	if _, ok := received["parallel_tool_calls"]; ok {
+		t.Fatalf("%s failed: parallel_tool_calls should be omitted when unset", testName)
+	}
+	disabled := false
+	prompt.ParallelToolCalls = &disabled
+	js, _ = json.Marshal(prompt)
+	received = nil
+	_ = json.Unmarshal(js, &received)
+	if v, ok := received["parallel_tool_calls"]; !ok || v != false {
+		t.Fatalf("%s failed: expected parallel_tool_calls false but received %#v", testName, v)
+	}
+}
@@ -0,0 +1,292 @@
+package oaiaux
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/btnguyen2k/consu/gjrc"
+)
+
+// RequestSigner is invoked just before a request is sent (see OptRequestSigner).
+//
+// The request already carries the client's own auth headers, and body is the raw request body (nil if the request
+// has no body). The signer is free to add or modify the request's headers, e.g. adding HMAC signatures or custom
+// auth headers required by API gateways.
+type RequestSigner func(req *http.Request, body []byte) error
+
+// signingTransport is a http.RoundTripper invoking a RequestSigner before passing requests to the underlying transport.
+type signingTransport struct {
+	base   http.RoundTripper
+	signer RequestSigner
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+	if err := t.signer(req, body); err != nil {
+		return nil, err
+	}
+	return t.transport().RoundTrip(req)
+}
+
+func (t *signingTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// AzureTokenProvider returns the Azure AD (Microsoft Entra ID) access token used to authenticate requests
+// (see OptAzureTokenProvider). It is invoked for every request, hence it should cache the token until it expires.
+type AzureTokenProvider func() (string, error)
+
+// bearerTokenTransport is a http.RoundTripper authenticating requests with a bearer token obtained from an
+// AzureTokenProvider. Requests already carrying an "api-key" header (e.g. failover resources with their own API key)
+// are passed as-is.
+type bearerTokenTransport struct {
+	base          http.RoundTripper
+	tokenProvider AzureTokenProvider
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("api-key") == "" {
+		token, err := t.tokenProvider()
+		if err != nil {
+			return nil, fmt.Errorf("cannot obtain Azure AD token: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return t.transport().RoundTrip(req)
+}
+
+func (t *bearerTokenTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// decompressingTransport is a http.RoundTripper requesting compressed responses (gzip or deflate) and transparently
+// decompressing them, e.g. to reduce the transfer time of large embeddings responses.
+//
+// Go's http.Transport already negotiates gzip, but only if the request has no "Accept-Encoding" header and
+// compression is not disabled on the transport; this transport also handles deflate, as well as requests whose
+// "Accept-Encoding" header is supplied by the caller (see OptHeaders), which are passed as-is.
+type decompressingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *decompressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+	}
+	resp, err := t.transport().RoundTrip(req)
+	if err != nil || resp.Body == nil || req.Method == http.MethodHead {
+		return resp, err
+	}
+	var decoder func(io.Reader) (io.ReadCloser, error)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		decoder = func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+	case "deflate":
+		decoder = newDeflateReader
+	default:
+		return resp, nil
+	}
+	resp.Body = &decompressingBody{body: resp.Body, decoder: decoder}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+func (t *decompressingTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// newDeflateReader decompresses a "deflate" body, which is zlib-wrapped as per RFC 9110 but sent as raw deflate by
+// some servers.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err != nil && len(header) < 2 {
+		return io.NopCloser(bytes.NewReader(header)), nil
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// decompressingBody decompresses a response body, lazily creating the decoder on the first read (so that the
+// response headers are returned without waiting for the body).
+type decompressingBody struct {
+	body    io.ReadCloser
+	decoder func(io.Reader) (io.ReadCloser, error)
+	reader  io.ReadCloser
+	err     error
+}
+
+// Read implements io.Reader.Read
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = b.decoder(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+// Close implements io.Closer.Close
+func (b *decompressingBody) Close() error {
+	if b.reader != nil {
+		_ = b.reader.Close()
+	}
+	return b.body.Close()
+}
+
+// pendingBodies tracks the bodies of responses not closed yet, keyed by *http.Response (see awaitResponse).
+var pendingBodies sync.Map
+
+// trackingTransport is a http.RoundTripper tracking response bodies until they are closed (see awaitResponse).
+type trackingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.transport().RoundTrip(req)
+	if err == nil && resp != nil && resp.Body != nil {
+		body := &trackedBody{ReadCloser: resp.Body, resp: resp, done: make(chan struct{})}
+		resp.Body = body
+		pendingBodies.Store(resp, body)
+	}
+	return resp, err
+}
+
+func (t *trackingTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// trackedBody wraps a response body, signaling when it is closed.
+type trackedBody struct {
+	io.ReadCloser
+	resp *http.Response
+	done chan struct{}
+	once sync.Once
+}
+
+// Close implements io.Closer.Close
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		pendingBodies.Delete(b.resp)
+		close(b.done)
+	})
+	return err
+}
+
+// awaitResponse waits until gjrc has finished reading (and closed) the response body in its background goroutine.
+//
+// gjrc reads the body concurrently and without synchronization with the accessors of the response (Error, Body,
+// Unmarshal...), hence responses must be awaited before anything inspects them. Once awaited, the body has been read
+// exactly once and all accessors return the captured data.
+func awaitResponse(resp *gjrc.GjrcResponse) {
+	if resp == nil || resp.HttpResponse() == nil {
+		return
+	}
+	if v, ok := pendingBodies.Load(resp.HttpResponse()); ok {
+		<-v.(*trackedBody).done
+	}
+}
+
+// contextTransport is a http.RoundTripper binding requests to the context of a call (see OptContext): once the
+// context is done, the in-flight request is aborted. The request's own context (e.g. the client's timeout) still
+// applies.
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(stop)
+			cancel()
+		})
+	}
+	resp, err := t.transport().RoundTrip(req.WithContext(ctx))
+	if err != nil || resp == nil || resp.Body == nil {
+		release()
+		if err != nil && t.ctx.Err() != nil {
+			err = t.ctx.Err()
+		}
+		return resp, err
+	}
+	// the context must outlive RoundTrip, until the response body is closed
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+func (t *contextTransport) transport() http.RoundTripper {
+	if t.base != nil {
+		return t.base
+	}
+	return http.DefaultTransport
+}
+
+// releasingBody wraps a response body, calling release once it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close implements io.Closer.Close
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,153 @@
+package oaiaux
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptRequestSigner(t *testing.T) {
+	testName := "TestOptRequestSigner"
+	secret := []byte("gateway-secret")
+	sign := func(body []byte) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	signer := func(req *http.Request, body []byte) error {
+		if req.Header.Get("Authorization") == "" {
+			t.Errorf("%s failed: auth header should be applied before signer", testName)
+		}
+		req.Header.Set("X-Signature", sign(body))
+		return nil
+	}
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Signature") != sign(body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"invalid signature"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`))
+	}, Option{Key: OptRequestSigner, Value: signer})
+	defer server.Close()
+
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+	if output.Error != nil || output.StatusCode != 200 {
+		t.Fatalf("%s failed: %#v / %s", testName, output.StatusCode, output.Error)
+	}
+}
+
+func TestOptAzureTokenProvider(t *testing.T) {
+	testName := "TestOptAzureTokenProvider"
+	calls := 0
+	provider := func() (string, error) {
+		calls++
+		if calls > 2 {
+			return "", errors.New("token expired")
+		}
+		return fmt.Sprintf("token-%d", calls), nil
+	}
+	testData := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{name: "provider", opts: []Option{{Key: OptAzureTokenProvider, Value: provider}}, expected: []string{"Bearer token-1", "Bearer token-2"}},
+		{name: "static", opts: []Option{{Key: OptAzureADToken, Value: "static-token"}}, expected: []string{"Bearer static-token", "Bearer static-token"}},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			received := make([]string, 0)
+			transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Header.Get("api-key") != "" {
+					t.Errorf("%s failed: unexpected api-key header", testName+"/"+testCase.name)
+				}
+				received = append(received, req.Header.Get("Authorization"))
+				recorder := httptest.NewRecorder()
+				_, _ = recorder.WriteString(`{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`)
+				return recorder.Result(), nil
+			})
+			opts := append([]Option{{Key: OptAzureResourceName, Value: "my-resource"}, {Key: OptHttpClient, Value: &http.Client{Transport: transport}}}, testCase.opts...)
+			client, err := NewClient(AzureOpenAI, opts...)
+			if err != nil {
+				t.Fatalf("%s failed: %s", testName+"/"+testCase.name, err)
+			}
+			for range testCase.expected {
+				client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+			}
+			if !reflect.DeepEqual(received, testCase.expected) {
+				t.Fatalf("%s failed: expected %#v but received %#v", testName+"/"+testCase.name, testCase.expected, received)
+			}
+		})
+	}
+
+	// provider errors are surfaced as request errors
+	client, _ := NewClient(AzureOpenAI, Option{Key: OptAzureResourceName, Value: "my-resource"}, Option{Key: OptAzureTokenProvider, Value: provider})
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-ada-002", Input: "Hello world"})
+	if output.Error == nil || !strings.Contains(output.Error.Error(), "token expired") {
+		t.Fatalf("%s failed: expected token error but received %#v", testName, output.Error)
+	}
+}
+
+func TestDecompressingTransport(t *testing.T) {
+	testName := "TestDecompressingTransport"
+	body := `{"object":"list","data":[{"index":0,"object":"embedding","embedding":[0.6,0.8]}]}`
+	var acceptEncoding string
+	client, server := newTestPlatformClient(t, func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		encoding := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/embeddings")
+		buf := &bytes.Buffer{}
+		var writer io.WriteCloser
+		switch encoding {
+		case "gzip":
+			writer = gzip.NewWriter(buf)
+		case "deflate":
+			writer = zlib.NewWriter(buf)
+		case "raw-deflate":
+			writer, _ = flate.NewWriter(buf, flate.DefaultCompression)
+			encoding = "deflate"
+		default:
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		_, _ = writer.Write([]byte(body))
+		_ = writer.Close()
+		w.Header().Set("Content-Encoding", encoding)
+		_, _ = w.Write(buf.Bytes())
+	}, Option{Key: OptExposeRawResponse, Value: true})
+	defer server.Close()
+	platformClient := client.(*PlatformOpenAIClient)
+	baseUrl := platformClient.baseUrl
+
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "none"} {
+		platformClient.baseUrl = baseUrl + "/" + encoding
+		output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"})
+		if output.Error != nil || len(output.Data) != 1 || acceptEncoding != "gzip, deflate" {
+			t.Fatalf("%s failed: unexpected output for encoding %s: %#v / %q", testName, encoding, output.Error, acceptEncoding)
+		}
+		if encoding != "none" && (output.Headers.Get("Content-Encoding") != "" || !output.RawResponse.HttpResponse().Uncompressed) {
+			t.Fatalf("%s failed: expected decompressed response for encoding %s but received %#v", testName, encoding, output.Headers)
+		}
+	}
+
+	// a caller-supplied Accept-Encoding header is sent as-is, compressed responses are still decompressed
+	platformClient.baseUrl = baseUrl + "/gzip"
+	output := client.Embeddings(&EmbeddingsInput{Model: "text-embedding-3-small", Input: "Hello world"},
+		Option{Key: OptHeaders, Value: map[string]string{"Accept-Encoding": "gzip"}})
+	if output.Error != nil || len(output.Data) != 1 || acceptEncoding != "gzip" {
+		t.Fatalf("%s failed: %#v / %q", testName, output.Error, acceptEncoding)
+	}
+}
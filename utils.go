@@ -1,10 +1,124 @@
 package oaiaux
 
-import "math"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
 
-// Vector represents an embeddings vector
+var (
+	ErrNoVectors         = errors.New("no vectors")
+	ErrDimensionMismatch = errors.New("vectors have different dimensions")
+)
+
+// Vector represents an embeddings vector.
+//
+// Operations combining two vectors (Dot, Cosine, distances...) require both vectors to have the same dimension.
+// If dimensions differ (e.g. embeddings generated by different models), they return NaN rather than panicking;
+// check the result with math.IsNaN. Likewise, arithmetic operations (Add, Subtract) return nil.
 type Vector []float64
 
+// UnmarshalJSON implements json.Unmarshaler: a vector is decoded from either an array of numbers, or a base64 string
+// of packed little-endian float32 values (as returned by the embeddings API with encoding_format "base64").
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return json.Unmarshal(data, (*[]float64)(v))
+	}
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	result, err := ParseVectorBase64(encoded)
+	if err != nil {
+		return err
+	}
+	*v = result
+	return nil
+}
+
+// ParseVectorBase64 decodes a vector from a base64 string of packed little-endian float32 values, the format of the
+// embeddings API with encoding_format "base64" (see Vector.Base64).
+func ParseVectorBase64(encoded string) (Vector, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode base64 vector: %w", err)
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("cannot decode base64 vector: %d bytes is not a multiple of 4", len(raw))
+	}
+	result := make(Vector, len(raw)/4)
+	for i := range result {
+		result[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:])))
+	}
+	return result, nil
+}
+
+// Base64 encodes this vector to a base64 string of packed little-endian float32 values (see ParseVectorBase64), a
+// compact text form at the cost of precision.
+func (v Vector) Base64() string {
+	raw := make([]byte, 4*len(v))
+	for i, e := range v {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(float32(e)))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler: the vector is encoded as its dimension (little-endian uint32)
+// followed by its elements as little-endian float64 values. Use Vector32.MarshalBinary to halve the size at the cost
+// of precision.
+func (v Vector) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 4+8*len(v))
+	binary.LittleEndian.PutUint32(data, uint32(len(v)))
+	for i, e := range v {
+		binary.LittleEndian.PutUint64(data[4+i*8:], math.Float64bits(e))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding vectors encoded by either Vector.MarshalBinary or
+// Vector32.MarshalBinary.
+func (v *Vector) UnmarshalBinary(data []byte) error {
+	n, width, err := binaryVectorLayout(data)
+	if err != nil {
+		return err
+	}
+	result := make(Vector, n)
+	for i := range result {
+		if width == 8 {
+			result[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[4+i*8:]))
+		} else {
+			result[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[4+i*4:])))
+		}
+	}
+	*v = result
+	return nil
+}
+
+// binaryVectorLayout returns the dimension and the element width (4 or 8 bytes) of a binary-encoded vector.
+func binaryVectorLayout(data []byte) (int, int, error) {
+	if len(data) < 4 {
+		return 0, 0, fmt.Errorf("cannot decode binary vector: expected at least 4 bytes but received %d", len(data))
+	}
+	n := int(binary.LittleEndian.Uint32(data))
+	switch size := len(data) - 4; {
+	case n == 0 && size == 0:
+		return 0, 8, nil
+	case size == 8*n:
+		return n, 8, nil
+	case size == 4*n:
+		return n, 4, nil
+	default:
+		return 0, 0, fmt.Errorf("cannot decode binary vector: %d bytes do not match dimension %d", size, n)
+	}
+}
+
 // Length calculates the Euclidean norm/length of this vector.
 func (v Vector) Length() float64 {
 	result := 0.0
@@ -14,8 +128,68 @@ func (v Vector) Length() float64 {
 	return math.Sqrt(result)
 }
 
+// Normalize returns a new vector of the same direction as this vector and of unit length.
+// The zero vector has no direction: a new zero vector is returned (no NaN).
+func (v Vector) Normalize() Vector {
+	result := make(Vector, len(v))
+	copy(result, v)
+	result.NormalizeInPlace()
+	return result
+}
+
+// NormalizeInPlace scales this vector to unit length without allocating a new vector.
+// The zero vector is left unchanged.
+func (v Vector) NormalizeInPlace() {
+	length := v.Length()
+	if length == 0 {
+		return
+	}
+	for i := range v {
+		v[i] /= length
+	}
+}
+
+// Add returns a new vector, the element-wise sum of this vector and another.
+// If the two vectors have different dimensions, nil is returned.
+func (v Vector) Add(other Vector) Vector {
+	if len(v) != len(other) {
+		return nil
+	}
+	result := make(Vector, len(v))
+	for i, e := range v {
+		result[i] = e + other[i]
+	}
+	return result
+}
+
+// Subtract returns a new vector, the element-wise difference of this vector and another.
+// If the two vectors have different dimensions, nil is returned.
+func (v Vector) Subtract(other Vector) Vector {
+	if len(v) != len(other) {
+		return nil
+	}
+	result := make(Vector, len(v))
+	for i, e := range v {
+		result[i] = e - other[i]
+	}
+	return result
+}
+
+// Scale returns a new vector, this vector multiplied by a factor.
+func (v Vector) Scale(f float64) Vector {
+	result := make(Vector, len(v))
+	for i, e := range v {
+		result[i] = e * f
+	}
+	return result
+}
+
 // Dot calculates the dot-product of this vector and another.
+// If the two vectors have different dimensions, NaN is returned.
 func (v Vector) Dot(other Vector) float64 {
+	if len(v) != len(other) {
+		return math.NaN()
+	}
 	result := 0.0
 	for i, e := range v {
 		result += e * other[i]
@@ -24,8 +198,192 @@ func (v Vector) Dot(other Vector) float64 {
 }
 
 // Cosine calculates the cosine-similarity of this vector and another.
+// If the two vectors have different dimensions, or either is the zero vector, NaN is returned.
 func (v Vector) Cosine(other Vector) float64 {
 	dot := v.Dot(other)
 	cross := v.Length() * other.Length()
 	return dot / cross
 }
+
+// CosineBatch calculates the cosine-similarity of this vector and each of the others, computing this vector's length
+// only once (faster than calling Cosine for each vector). Results follow the same rules as Cosine.
+func (v Vector) CosineBatch(others []Vector) []float64 {
+	result := make([]float64, len(others))
+	length := v.Length()
+	for i, other := range others {
+		result[i] = v.Dot(other) / (length * other.Length())
+	}
+	return result
+}
+
+// EuclideanDistance calculates the Euclidean (L2) distance between this vector and another.
+// If the two vectors have different dimensions, NaN is returned.
+func (v Vector) EuclideanDistance(other Vector) float64 {
+	if len(v) != len(other) {
+		return math.NaN()
+	}
+	result := 0.0
+	for i, e := range v {
+		d := e - other[i]
+		result += d * d
+	}
+	return math.Sqrt(result)
+}
+
+// ManhattanDistance calculates the Manhattan (L1) distance between this vector and another.
+// If the two vectors have different dimensions, NaN is returned.
+func (v Vector) ManhattanDistance(other Vector) float64 {
+	if len(v) != len(other) {
+		return math.NaN()
+	}
+	result := 0.0
+	for i, e := range v {
+		result += math.Abs(e - other[i])
+	}
+	return result
+}
+
+// To32 converts this vector to a Vector32, halving its memory footprint at the cost of precision.
+func (v Vector) To32() Vector32 {
+	result := make(Vector32, len(v))
+	for i, e := range v {
+		result[i] = float32(e)
+	}
+	return result
+}
+
+// Vector32 is a single-precision embeddings vector, suitable to store large numbers of vectors for similarity search.
+//
+// Computations are carried out in float64; like Vector, mismatched dimensions return NaN.
+type Vector32 []float32
+
+// To64 converts this vector to a Vector.
+func (v Vector32) To64() Vector {
+	result := make(Vector, len(v))
+	for i, e := range v {
+		result[i] = float64(e)
+	}
+	return result
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler: the vector is encoded as its dimension (little-endian uint32)
+// followed by its elements as little-endian float32 values.
+func (v Vector32) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 4+4*len(v))
+	binary.LittleEndian.PutUint32(data, uint32(len(v)))
+	for i, e := range v {
+		binary.LittleEndian.PutUint32(data[4+i*4:], math.Float32bits(e))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding vectors encoded by either Vector32.MarshalBinary or
+// Vector.MarshalBinary (converted to single precision).
+func (v *Vector32) UnmarshalBinary(data []byte) error {
+	var vector Vector
+	if err := vector.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	*v = vector.To32()
+	return nil
+}
+
+// Length calculates the Euclidean norm/length of this vector.
+func (v Vector32) Length() float64 {
+	result := 0.0
+	for _, e := range v {
+		result += float64(e) * float64(e)
+	}
+	return math.Sqrt(result)
+}
+
+// Dot calculates the dot-product of this vector and another.
+// If the two vectors have different dimensions, NaN is returned.
+func (v Vector32) Dot(other Vector32) float64 {
+	if len(v) != len(other) {
+		return math.NaN()
+	}
+	result := 0.0
+	for i, e := range v {
+		result += float64(e) * float64(other[i])
+	}
+	return result
+}
+
+// Cosine calculates the cosine-similarity of this vector and another.
+// If the two vectors have different dimensions, or either is the zero vector, NaN is returned.
+func (v Vector32) Cosine(other Vector32) float64 {
+	return v.Dot(other) / (v.Length() * other.Length())
+}
+
+// Centroid calculates the element-wise mean of vectors, e.g. to represent a document by the average of the embeddings
+// of its chunks. ErrNoVectors is returned for an empty slice, and ErrDimensionMismatch if dimensions differ.
+//
+// Note: the centroid of unit vectors is generally not of unit length; normalize it if needed.
+func Centroid(vectors []Vector) (Vector, error) {
+	if len(vectors) == 0 {
+		return nil, ErrNoVectors
+	}
+	result := make(Vector, len(vectors[0]))
+	for i, v := range vectors {
+		if len(v) != len(result) {
+			return nil, fmt.Errorf("%w: vector #%d has %d dimensions, expected %d", ErrDimensionMismatch, i, len(v), len(result))
+		}
+		for j, e := range v {
+			result[j] += e
+		}
+	}
+	n := float64(len(vectors))
+	for j := range result {
+		result[j] /= n
+	}
+	return result, nil
+}
+
+// VectorMatch is a candidate vector matched by a similarity search (see TopKCosine).
+type VectorMatch struct {
+	// Index is the position of the matched vector in the candidates list.
+	Index int
+	// Score is the similarity between the query and the matched vector.
+	Score float64
+}
+
+// TopKCosine finds the k candidates most similar to the query (by cosine-similarity), sorted by descending score.
+//
+// If k exceeds the number of candidates, all candidates are returned. Candidates whose similarity is NaN (different
+// dimension, or zero vector) are never matched. Ties keep the candidates' order.
+func TopKCosine(query Vector, candidates []Vector, k int) []VectorMatch {
+	matches := make([]VectorMatch, 0, len(candidates))
+	for i, score := range query.CosineBatch(candidates) {
+		if !math.IsNaN(score) {
+			matches = append(matches, VectorMatch{Index: i, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if k < 0 {
+		k = 0
+	}
+	if k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// OptHashSalt specifies the secret salt of HashUser.
+const OptHashSalt = "hash-salt"
+
+// HashUser derives a stable, non-reversible end-user identifier from a user id, to be sent as the "user" field of
+// inputs for abuse monitoring without leaking raw user ids.
+//
+// The result is the hex-encoded SHA-256 of id, or its HMAC-SHA256 keyed by the OptHashSalt option if supplied (which
+// prevents guessing ids by hashing candidates). Use the same salt across calls to keep identifiers stable.
+func HashUser(id string, opts ...Option) string {
+	var optList OptionList = opts
+	if salt, err := optList.GetString(OptHashSalt); err == nil && salt != "" {
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write([]byte(id))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	hash := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(hash[:])
+}
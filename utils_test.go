@@ -0,0 +1,287 @@
+package oaiaux
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVector_Normalize(t *testing.T) {
+	testName := "TestVector_Normalize"
+	testData := []struct {
+		name     string
+		input    Vector
+		expected Vector
+	}{
+		{name: "3-4", input: Vector{3, 4}, expected: Vector{0.6, 0.8}},
+		{name: "unit", input: Vector{0, 1, 0}, expected: Vector{0, 1, 0}},
+		{name: "zero", input: Vector{0, 0, 0}, expected: Vector{0, 0, 0}},
+		{name: "empty", input: Vector{}, expected: Vector{}},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			original := append(Vector{}, testCase.input...)
+			result := testCase.input.Normalize()
+			inPlace := append(Vector{}, testCase.input...)
+			inPlace.NormalizeInPlace()
+			for i := range testCase.expected {
+				if math.Abs(result[i]-testCase.expected[i]) > 1e-9 || math.Abs(inPlace[i]-testCase.expected[i]) > 1e-9 {
+					t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName+"/"+testCase.name, testCase.expected, result, inPlace)
+				}
+				if testCase.input[i] != original[i] {
+					t.Fatalf("%s failed: Normalize must not modify the vector", testName+"/"+testCase.name)
+				}
+			}
+			if len(result) != len(testCase.expected) {
+				t.Fatalf("%s failed: expected length %#v but received %#v", testName+"/"+testCase.name, len(testCase.expected), len(result))
+			}
+		})
+	}
+}
+
+func TestVector_Distances(t *testing.T) {
+	testName := "TestVector_Distances"
+	testData := []struct {
+		name              string
+		a, b              Vector
+		euclidean, manhat float64
+	}{
+		{name: "2d", a: Vector{0, 0}, b: Vector{3, 4}, euclidean: 5, manhat: 7},
+		{name: "negative", a: Vector{-1, 2, -3}, b: Vector{1, -2, 3}, euclidean: math.Sqrt(56), manhat: 12},
+		{name: "same", a: Vector{1, 2}, b: Vector{1, 2}, euclidean: 0, manhat: 0},
+		{name: "mismatch", a: Vector{1, 2}, b: Vector{1, 2, 3}, euclidean: math.NaN(), manhat: math.NaN()},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			euclidean, manhat := testCase.a.EuclideanDistance(testCase.b), testCase.a.ManhattanDistance(testCase.b)
+			if math.IsNaN(testCase.euclidean) {
+				if !math.IsNaN(euclidean) || !math.IsNaN(manhat) {
+					t.Fatalf("%s failed: expected NaN but received %#v / %#v", testName+"/"+testCase.name, euclidean, manhat)
+				}
+				return
+			}
+			if math.Abs(euclidean-testCase.euclidean) > 1e-9 || math.Abs(manhat-testCase.manhat) > 1e-9 {
+				t.Fatalf("%s failed: expected %#v / %#v but received %#v / %#v", testName+"/"+testCase.name,
+					testCase.euclidean, testCase.manhat, euclidean, manhat)
+			}
+		})
+	}
+}
+
+func TestVector_DimensionMismatch(t *testing.T) {
+	testName := "TestVector_DimensionMismatch"
+	a, b := Vector{1, 2, 3}, Vector{1, 2}
+	testData := map[string]float64{
+		"Dot":               a.Dot(b),
+		"Cosine":            a.Cosine(b),
+		"EuclideanDistance": b.EuclideanDistance(a),
+		"ManhattanDistance": b.ManhattanDistance(a),
+	}
+	for name, value := range testData {
+		if !math.IsNaN(value) {
+			t.Fatalf("%s failed: expected %s to return NaN but received %#v", testName, name, value)
+		}
+	}
+	if dot := a.Dot(Vector{1, 1, 1}); dot != 6 {
+		t.Fatalf("%s failed: expected %#v but received %#v", testName, 6.0, dot)
+	}
+}
+
+func TestVector_UnmarshalJSON(t *testing.T) {
+	testName := "TestVector_UnmarshalJSON"
+	expected := Vector{0.5, -1.25, 3}
+	raw := make([]byte, 4*len(expected))
+	for i, e := range expected {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(float32(e)))
+	}
+	testData := []struct {
+		name  string
+		input string
+	}{
+		{name: "float", input: `[0.5,-1.25,3]`},
+		{name: "base64", input: `"` + base64.StdEncoding.EncodeToString(raw) + `"`},
+	}
+	for _, testCase := range testData {
+		t.Run(testCase.name, func(t *testing.T) {
+			var v Vector
+			if err := json.Unmarshal([]byte(testCase.input), &v); err != nil || !reflect.DeepEqual(v, expected) {
+				t.Fatalf("%s failed: expected %#v but received %#v / %s", testName+"/"+testCase.name, expected, v, err)
+			}
+		})
+	}
+	var v Vector
+	if err := json.Unmarshal([]byte(`"AAAA"`), &v); err == nil {
+		t.Fatalf("%s failed: expected error for truncated base64 vector", testName)
+	}
+}
+
+func TestTopKCosine(t *testing.T) {
+	testName := "TestTopKCosine"
+	query := Vector{1, 0}
+	candidates := []Vector{{0, 1}, {1, 1}, {1, 0}, {1, 2, 3}, {0, 0}, {-1, 0}}
+	matches := TopKCosine(query, candidates, 2)
+	if len(matches) != 2 || matches[0].Index != 2 || matches[1].Index != 1 ||
+		math.Abs(matches[0].Score-1) > 1e-9 || math.Abs(matches[1].Score-math.Sqrt2/2) > 1e-9 {
+		t.Fatalf("%s failed: unexpected matches %#v", testName, matches)
+	}
+	matches = TopKCosine(query, candidates, 100)
+	expected := []int{2, 1, 0, 5}
+	if len(matches) != len(expected) {
+		t.Fatalf("%s failed: expected %d matches but received %#v", testName, len(expected), matches)
+	}
+	for i, m := range matches {
+		if m.Index != expected[i] {
+			t.Fatalf("%s failed: expected index %d at position %d but received %#v", testName, expected[i], i, matches)
+		}
+	}
+	if matches = TopKCosine(query, candidates, 0); len(matches) != 0 {
+		t.Fatalf("%s failed: expected no match but received %#v", testName, matches)
+	}
+	if matches = TopKCosine(query, nil, 3); len(matches) != 0 {
+		t.Fatalf("%s failed: expected no match but received %#v", testName, matches)
+	}
+}
+
+func TestVector_CosineBatch(t *testing.T) {
+	testName := "TestVector_CosineBatch"
+	query := Vector{3, 4}
+	others := []Vector{{4, 3}, {-3, -4}, {6, 8}, {1, 2, 3}, {0, 0}}
+	scores := query.CosineBatch(others)
+	if len(scores) != len(others) {
+		t.Fatalf("%s failed: expected %d scores but received %#v", testName, len(others), scores)
+	}
+	for i, other := range others {
+		expected := query.Cosine(other)
+		if math.IsNaN(expected) != math.IsNaN(scores[i]) || (!math.IsNaN(expected) && math.Abs(expected-scores[i]) > 1e-9) {
+			t.Fatalf("%s failed: expected %#v at position %d but received %#v", testName, expected, i, scores[i])
+		}
+	}
+	if scores = query.CosineBatch(nil); len(scores) != 0 {
+		t.Fatalf("%s failed: expected no score but received %#v", testName, scores)
+	}
+}
+
+func TestVector32(t *testing.T) {
+	testName := "TestVector32"
+	a, b := Vector{3, 4}, Vector{4, 3}
+	a32, b32 := a.To32(), b.To32()
+	if a32.Length() != a.Length() || a32.Dot(b32) != a.Dot(b) || math.Abs(a32.Cosine(b32)-a.Cosine(b)) > 1e-6 {
+		t.Fatalf("%s failed: unexpected results %#v / %#v / %#v", testName, a32.Length(), a32.Dot(b32), a32.Cosine(b32))
+	}
+	if !reflect.DeepEqual(a32.To64(), a) {
+		t.Fatalf("%s failed: expected %#v but received %#v", testName, a, a32.To64())
+	}
+	if dot, cosine := a32.Dot(Vector32{1}), a32.Cosine(Vector32{0, 0}); !math.IsNaN(dot) || !math.IsNaN(cosine) {
+		t.Fatalf("%s failed: expected NaN but received %#v / %#v", testName, dot, cosine)
+	}
+}
+
+func TestVector_MarshalBinary(t *testing.T) {
+	testName := "TestVector_MarshalBinary"
+	v := Vector{0.1, -2.5, math.Pi}
+	data, err := v.MarshalBinary()
+	if err != nil || len(data) != 4+8*len(v) || binary.LittleEndian.Uint32(data) != 3 {
+		t.Fatalf("%s failed: %#v / %d bytes", testName, err, len(data))
+	}
+	var decoded Vector
+	if err = decoded.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(decoded, v) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName, v, decoded, err)
+	}
+
+	// single precision: half the size, decodable as Vector or Vector32
+	data32, _ := v.To32().MarshalBinary()
+	if len(data32) != 4+4*len(v) {
+		t.Fatalf("%s failed: expected %d bytes but received %d", testName, 4+4*len(v), len(data32))
+	}
+	var decoded32 Vector32
+	if err = decoded32.UnmarshalBinary(data32); err != nil || !reflect.DeepEqual(decoded32, v.To32()) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName, v.To32(), decoded32, err)
+	}
+	if err = decoded.UnmarshalBinary(data32); err != nil || !reflect.DeepEqual(decoded, v.To32().To64()) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName, v.To32().To64(), decoded, err)
+	}
+	if err = decoded32.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(decoded32, v.To32()) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName, v.To32(), decoded32, err)
+	}
+
+	empty, _ := Vector{}.MarshalBinary()
+	if err = decoded.UnmarshalBinary(empty); err != nil || len(decoded) != 0 {
+		t.Fatalf("%s failed: expected empty vector but received %#v / %#v", testName, decoded, err)
+	}
+	for _, invalid := range [][]byte{nil, {1, 0}, data[:len(data)-1]} {
+		if err = decoded.UnmarshalBinary(invalid); err == nil {
+			t.Fatalf("%s failed: expected error for %#v", testName, invalid)
+		}
+	}
+}
+
+func TestVector_Base64(t *testing.T) {
+	testName := "TestVector_Base64"
+	v := Vector{0.5, -0.25, 1}
+	decoded, err := ParseVectorBase64(v.Base64())
+	if err != nil || !reflect.DeepEqual(decoded, v) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName, v, decoded, err)
+	}
+	var fromJson Vector
+	if err = json.Unmarshal([]byte(`"`+v.Base64()+`"`), &fromJson); err != nil || !reflect.DeepEqual(fromJson, v) {
+		t.Fatalf("%s failed: expected %#v but received %#v / %#v", testName, v, fromJson, err)
+	}
+	if _, err = ParseVectorBase64("AAA="); err == nil {
+		t.Fatalf("%s failed: expected error for truncated vector", testName)
+	}
+}
+
+func TestVector_Arithmetic(t *testing.T) {
+	testName := "TestVector_Arithmetic"
+	king, man, woman := Vector{0.9, 0.8, 0.1}, Vector{0.5, 0.1, 0.1}, Vector{0.5, 0.1, 0.9}
+	queen := king.Subtract(man).Add(woman)
+	expected := Vector{0.9, 0.8, 0.9}
+	for i := range expected {
+		if math.Abs(queen[i]-expected[i]) > 1e-9 {
+			t.Fatalf("%s failed: expected %#v but received %#v", testName, expected, queen)
+		}
+	}
+	if scaled := king.Scale(2); !reflect.DeepEqual(scaled, Vector{1.8, 1.6, 0.2}) || king[0] != 0.9 {
+		t.Fatalf("%s failed: unexpected scaled vector %#v (original %#v)", testName, scaled, king)
+	}
+	if v := king.Add(Vector{1}); v != nil {
+		t.Fatalf("%s failed: expected nil but received %#v", testName, v)
+	}
+	if v := king.Subtract(nil); v != nil {
+		t.Fatalf("%s failed: expected nil but received %#v", testName, v)
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	testName := "TestCentroid"
+	centroid, err := Centroid([]Vector{{1, 2}, {3, 4}, {5, 0}})
+	if err != nil || !reflect.DeepEqual(centroid, Vector{3, 2}) {
+		t.Fatalf("%s failed: unexpected centroid %#v / %v", testName, centroid, err)
+	}
+	if _, err = Centroid(nil); !errors.Is(err, ErrNoVectors) {
+		t.Fatalf("%s failed: expected ErrNoVectors but received %v", testName, err)
+	}
+	if _, err = Centroid([]Vector{{1, 2}, {3}}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("%s failed: expected ErrDimensionMismatch but received %v", testName, err)
+	}
+}
+
+func TestHashUser(t *testing.T) {
+	testName := "TestHashUser"
+	// sha256("user-123")
+	if h := HashUser("user-123"); h != "fcdec6df4d44dbc637c7c5b58efface52a7f8a88535423430255be0bb89bedd8" {
+		t.Fatalf("%s failed: unexpected hash %s", testName, h)
+	}
+	if HashUser("user-123") != HashUser("user-123") || HashUser("user-123") == HashUser("user-124") {
+		t.Fatalf("%s failed: hashes should be stable and distinct", testName)
+	}
+	salted := HashUser("user-123", Option{Key: OptHashSalt, Value: "secret"})
+	if salted == HashUser("user-123") || salted != HashUser("user-123", Option{Key: OptHashSalt, Value: "secret"}) ||
+		salted == HashUser("user-123", Option{Key: OptHashSalt, Value: "other"}) {
+		t.Fatalf("%s failed: unexpected salted hash %s", testName, salted)
+	}
+}